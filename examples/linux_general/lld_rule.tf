@@ -10,7 +10,7 @@ resource "zabbix_lld_rule" "filesystem_discovery_rule" {
             macro = "{#FSTYPE}"
             value = "@fs"
         }
-        eval_type = 0
+        eval_type = "and/or"
     }
 }
 
@@ -2,21 +2,61 @@ resource "zabbix_template" "base_linux_general" {
   host        = "Base_Linux_General"
   groups      = [zabbix_host_group.template_linux.name]
   description = "Linux general template without network and disk support"
-  macro = {
-    CPU_AVG                   = "85"
-    CPU_DISASTER              = "95"
-    CPU_HIGH                  = "90"
-    CPU_INTERVAL              = "60m"
-    CPU_LOAD_RATIO_AVG        = "2"
-    CPU_LOAD_RATIO_DISASTER   = "3"
-    CPU_LOAD_RATIO_HIGH       = "2.5"
-    CPU_LOAD_RATIO_INTERVAL   = "30m"
-    CPU_LOAD_RATIO_WARN       = "1.5"
-    CPU_WARN                  = "80"
-    MEMORY_PERCENTAGE_AVG     = "10"
-    MEMORY_PERCENTAGE_DISABLE = "2"
-    MEMORY_PERCENTAGE_HIGH    = "5"
-    MEMORY_PERCENTAGE_WARN    = "15"
+  macro {
+    name  = "CPU_AVG"
+    value = "85"
+  }
+  macro {
+    name  = "CPU_DISASTER"
+    value = "95"
+  }
+  macro {
+    name  = "CPU_HIGH"
+    value = "90"
+  }
+  macro {
+    name  = "CPU_INTERVAL"
+    value = "60m"
+  }
+  macro {
+    name  = "CPU_LOAD_RATIO_AVG"
+    value = "2"
+  }
+  macro {
+    name  = "CPU_LOAD_RATIO_DISASTER"
+    value = "3"
+  }
+  macro {
+    name  = "CPU_LOAD_RATIO_HIGH"
+    value = "2.5"
+  }
+  macro {
+    name  = "CPU_LOAD_RATIO_INTERVAL"
+    value = "30m"
+  }
+  macro {
+    name  = "CPU_LOAD_RATIO_WARN"
+    value = "1.5"
+  }
+  macro {
+    name  = "CPU_WARN"
+    value = "80"
+  }
+  macro {
+    name  = "MEMORY_PERCENTAGE_AVG"
+    value = "10"
+  }
+  macro {
+    name  = "MEMORY_PERCENTAGE_DISABLE"
+    value = "2"
+  }
+  macro {
+    name  = "MEMORY_PERCENTAGE_HIGH"
+    value = "5"
+  }
+  macro {
+    name  = "MEMORY_PERCENTAGE_WARN"
+    value = "15"
   }
 }
 
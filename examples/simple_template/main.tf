@@ -21,7 +21,8 @@ resource "zabbix_template" "demo_template" {
   name        = "simple template demo"
   description = "A simple template exemple"
   groups      = [zabbix_host_group.demo_group.name]
-  macro = {
-    MACRO_TEMPLATE = "12"
+  macro {
+    name  = "MACRO_TEMPLATE"
+    value = "12"
   }
 }
@@ -21,8 +21,9 @@ resource "zabbix_template" "demo_template" {
   name        = "template demo"
   description = "An exemple of template with item and trigger"
   groups      = [zabbix_host_group.demo_group.name]
-  macro = {
-    MACRO_TEMPLATE = "12"
+  macro {
+    name  = "MACRO_TEMPLATE"
+    value = "12"
   }
 }
 
@@ -31,8 +32,8 @@ resource "zabbix_item" "demo_item" {
   key         = "demo.key"
   delay       = "34"
   description = "Item for the demo template"
-  trends      = "300"
-  history     = "25"
+  trends      = "300d"
+  history     = "25d"
   host_id     = zabbix_template.demo_template.template_id
 }
 
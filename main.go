@@ -1,11 +1,21 @@
 package main
 
 import (
+	"log"
+	"os"
+
 	"github.com/claranet/terraform-provider-zabbix/zabbix"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/plugin"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "generate" {
+		if err := zabbix.Generate(os.Args[2:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	p := plugin.ServeOpts{
 		ProviderFunc: zabbix.Provider,
 	}
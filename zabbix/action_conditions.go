@@ -0,0 +1,361 @@
+package zabbix
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/claranet/go-zabbix-api"
+)
+
+// actionConditionLookups are the types of the objects referenced by the conditions of actions, resolved by name
+// through the cache of the client. The conditions on triggers and discovery checks reference objects without
+// unique names, named <host>:<description> and <discovery rule>:<type of check>.
+var actionConditionLookups = map[string]string{
+	"host_group":     lookupHostGroup,
+	"host":           lookupHost,
+	"host_template":  lookupTemplate,
+	"proxy":          lookupProxy,
+	"discovery_rule": lookupDiscoveryRule,
+}
+
+// discoveryCheckTypes are the names of the types of discovery checks
+var discoveryCheckTypes = map[string]string{
+	"0":  "ssh",
+	"1":  "ldap",
+	"2":  "smtp",
+	"3":  "ftp",
+	"4":  "http",
+	"5":  "pop",
+	"6":  "nntp",
+	"7":  "imap",
+	"8":  "tcp",
+	"9":  "zabbix_agent",
+	"10": "snmpv1",
+	"11": "snmpv2c",
+	"12": "icmp",
+	"13": "snmpv3",
+	"14": "https",
+	"15": "telnet",
+}
+
+var actionConditionIDRegexp = regexp.MustCompile(`^[0-9]+$`)
+
+// isActionConditionName is whether the value of a condition names the object it references, rather than giving
+// its ID, values made of digits being IDs. Host groups are always named, as they were before the other objects could be.
+func isActionConditionName(conditionType, value string) bool {
+	_, named := actionConditionLookups[conditionType]
+	switch {
+	case conditionType == "host_group":
+		return true
+	case !named && conditionType != "trigger" && conditionType != "discovery_check":
+		return false
+	}
+	return !actionConditionIDRegexp.MatchString(value)
+}
+
+// actionConditionIDs returns the values of the configured conditions, with the names of the objects they reference
+// replaced by their IDs. The names are resolved in a single request by type of object, and the objects referenced
+// by ID must exist, as objects named with digits only cannot be referenced by name.
+func actionConditionIDs(lst []interface{}, api *zabbixClient) ([]string, error) {
+	values := make([]string, len(lst))
+	names := map[string][]string{}
+	var referenced []string
+	referencedIDs := map[string][]string{}
+	for i, v := range lst {
+		m := v.(map[string]interface{})
+		conditionType := m["type"].(string)
+		values[i] = m["value"].(string)
+		lookup, ok := actionConditionLookups[conditionType]
+		switch {
+		case ok && isActionConditionName(conditionType, values[i]):
+			names[lookup] = append(names[lookup], values[i])
+		case ok || conditionType == "trigger" || conditionType == "discovery_check":
+			if _, seen := referencedIDs[conditionType]; !seen {
+				referenced = append(referenced, conditionType)
+			}
+			referencedIDs[conditionType] = append(referencedIDs[conditionType], values[i])
+		}
+	}
+
+	ids := map[string]map[string]string{}
+	for lookup, n := range names {
+		found, err := api.lookupIDs(lookup, n)
+		if err != nil {
+			return nil, err
+		}
+		ids[lookup] = found
+	}
+
+	for i, v := range lst {
+		conditionType := v.(map[string]interface{})["type"].(string)
+		if !isActionConditionName(conditionType, values[i]) {
+			continue
+		}
+
+		var err error
+		switch conditionType {
+		case "trigger":
+			values[i], err = actionConditionTriggerID(api, values[i])
+		case "discovery_check":
+			values[i], err = actionConditionDiscoveryCheckID(api, values[i])
+		default:
+			lookup := actionConditionLookups[conditionType]
+			id, ok := ids[lookup][values[i]]
+			if !ok {
+				return nil, fmt.Errorf("%s not found: %s", lookup, values[i])
+			}
+			values[i] = id
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The IDs are checked once the names are resolved, which caches the IDs of the objects found
+	for _, conditionType := range referenced {
+		if err := actionConditionCheckIDs(api, conditionType, referencedIDs[conditionType]); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// actionConditionCheckIDs returns an error when a condition references an object by an ID which does not exist
+func actionConditionCheckIDs(api *zabbixClient, conditionType string, ids []string) error {
+	objectType := actionConditionLookups[conditionType]
+	found := map[string]string{}
+	switch conditionType {
+	case "trigger", "discovery_check":
+		method, idField := "trigger.get", "triggerid"
+		if conditionType == "discovery_check" {
+			method, idField = "dcheck.get", "dcheckid"
+		}
+		objectType = strings.ReplaceAll(conditionType, "_", " ")
+		var objects []map[string]interface{}
+		if err := api.CallWithErrorParse(method, zabbix.Params{"output": []string{idField}, idField + "s": ids}, &objects); err != nil {
+			return err
+		}
+		for _, o := range objects {
+			id, _ := o[idField].(string)
+			found[id] = id
+		}
+	default:
+		var err error
+		if found, err = api.lookupNames(objectType, ids); err != nil {
+			return err
+		}
+	}
+
+	for _, id := range ids {
+		if _, ok := found[id]; !ok {
+			return fmt.Errorf("%s not found: %s, values made of digits are IDs", objectType, id)
+		}
+	}
+	return nil
+}
+
+// actionConditionNames returns the values of the conditions read from the API, with the IDs of the objects they
+// reference replaced by their names, unless the condition at the same position in the state references the same
+// object by ID. IDs of objects that cannot be named are kept.
+func actionConditionNames(cds zabbix.ActionFilterConditions, state []interface{}, api *zabbixClient) ([]string, error) {
+	values := make([]string, len(cds))
+	named := make([]bool, len(cds))
+	ids := map[string][]string{}
+	for i, c := range cds {
+		conditionType := ActionConditionTypeStringMap[c.ConditionType]
+		values[i] = c.Value
+		// Without the condition in the state, like on import, the objects are named
+		previous := ""
+		if i < len(state) {
+			if m := state[i].(map[string]interface{}); m["type"] == conditionType {
+				previous = m["value"].(string)
+			}
+		}
+		named[i] = isActionConditionName(conditionType, previous)
+		if lookup, ok := actionConditionLookups[conditionType]; ok && named[i] {
+			ids[lookup] = append(ids[lookup], c.Value)
+		}
+	}
+
+	names := map[string]map[string]string{}
+	for lookup, i := range ids {
+		found, err := api.lookupNames(lookup, i)
+		if err != nil {
+			return nil, err
+		}
+		names[lookup] = found
+	}
+
+	for i, c := range cds {
+		if !named[i] {
+			continue
+		}
+
+		var name string
+		var err error
+		switch conditionType := ActionConditionTypeStringMap[c.ConditionType]; conditionType {
+		case "trigger":
+			name, err = actionConditionTriggerName(api, c.Value)
+		case "discovery_check":
+			name, err = actionConditionDiscoveryCheckName(api, c.Value)
+		default:
+			name = names[actionConditionLookups[conditionType]][c.Value]
+		}
+		if err != nil {
+			return nil, err
+		}
+		if name != "" {
+			values[i] = name
+		}
+	}
+	return values, nil
+}
+
+// actionConditionTriggerID returns the ID of the trigger named <host>:<description>
+func actionConditionTriggerID(api *zabbixClient, name string) (string, error) {
+	host, description, ok := strings.Cut(name, ":")
+	if !ok {
+		return "", fmt.Errorf("trigger %q must be referenced by ID or as <host>:<description>", name)
+	}
+
+	var triggers []map[string]interface{}
+	err := api.CallWithErrorParse("trigger.get", zabbix.Params{
+		"output": []string{"triggerid"},
+		"host":   host,
+		"filter": map[string]interface{}{"description": description},
+	}, &triggers)
+	if err != nil {
+		return "", err
+	}
+	switch len(triggers) {
+	case 0:
+		return "", fmt.Errorf("trigger not found: %s", name)
+	case 1:
+		id, _ := triggers[0]["triggerid"].(string)
+		return id, nil
+	}
+	return "", fmt.Errorf("trigger %s is ambiguous, %d triggers have this description, reference it by ID", name, len(triggers))
+}
+
+// actionConditionTriggerName returns the name of a trigger as <host>:<description>, or nothing when the trigger
+// does not exist or depends on several hosts
+func actionConditionTriggerName(api *zabbixClient, id string) (string, error) {
+	var triggers []struct {
+		Description string `json:"description"`
+		Hosts       []struct {
+			Host string `json:"host"`
+		} `json:"hosts"`
+	}
+	err := api.CallWithErrorParse("trigger.get", zabbix.Params{
+		"output":      []string{"description"},
+		"triggerids":  id,
+		"selectHosts": []string{"host"},
+	}, &triggers)
+	if err != nil || len(triggers) != 1 || len(triggers[0].Hosts) != 1 {
+		return "", err
+	}
+	return triggers[0].Hosts[0].Host + ":" + triggers[0].Description, nil
+}
+
+// discoveryChecks returns the types of the checks of a discovery rule by ID
+func discoveryChecks(api *zabbixClient, ruleID string) (map[string]string, error) {
+	var checks []map[string]interface{}
+	err := api.CallWithErrorParse("dcheck.get", zabbix.Params{
+		"output":   []string{"dcheckid", "type"},
+		"druleids": ruleID,
+	}, &checks)
+	if err != nil {
+		return nil, err
+	}
+	types := make(map[string]string, len(checks))
+	for _, check := range checks {
+		id, _ := check["dcheckid"].(string)
+		checkType, _ := check["type"].(string)
+		types[id] = discoveryCheckTypes[checkType]
+	}
+	return types, nil
+}
+
+// actionConditionDiscoveryCheckID returns the ID of the discovery check named <discovery rule>:<type of check>
+func actionConditionDiscoveryCheckID(api *zabbixClient, name string) (string, error) {
+	i := strings.LastIndex(name, ":")
+	if i < 0 {
+		return "", fmt.Errorf("discovery check %q must be referenced by ID or as <discovery rule>:<type of check>", name)
+	}
+	rule, checkType := name[:i], name[i+1:]
+	known := false
+	for _, t := range discoveryCheckTypes {
+		known = known || t == checkType
+	}
+	if !known {
+		var types []string
+		for _, t := range discoveryCheckTypes {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+		return "", fmt.Errorf("discovery check %q has an unknown type of check %q, expected one of %s", name, checkType, strings.Join(types, ", "))
+	}
+
+	ruleIDs, err := api.lookupIDs(lookupDiscoveryRule, []string{rule})
+	if err != nil {
+		return "", err
+	}
+	ruleID, ok := ruleIDs[rule]
+	if !ok {
+		return "", fmt.Errorf("%s not found: %s", lookupDiscoveryRule, rule)
+	}
+	checks, err := discoveryChecks(api, ruleID)
+	if err != nil {
+		return "", err
+	}
+
+	var ids []string
+	for id, t := range checks {
+		if t == checkType {
+			ids = append(ids, id)
+		}
+	}
+	switch len(ids) {
+	case 0:
+		return "", fmt.Errorf("discovery check not found: %s", name)
+	case 1:
+		return ids[0], nil
+	}
+	return "", fmt.Errorf("discovery check %s is ambiguous, the rule has %d checks of this type, reference it by ID", name, len(ids))
+}
+
+// actionConditionDiscoveryCheckName returns the name of a discovery check as <discovery rule>:<type of check>, or
+// nothing when the check does not exist or its rule has other checks of the same type
+func actionConditionDiscoveryCheckName(api *zabbixClient, id string) (string, error) {
+	var checks []map[string]interface{}
+	err := api.CallWithErrorParse("dcheck.get", zabbix.Params{
+		"output":    []string{"druleid"},
+		"dcheckids": id,
+	}, &checks)
+	if err != nil || len(checks) != 1 {
+		return "", err
+	}
+	ruleID, _ := checks[0]["druleid"].(string)
+
+	rules, err := api.lookupNames(lookupDiscoveryRule, []string{ruleID})
+	if err != nil {
+		return "", err
+	}
+	rule, ok := rules[ruleID]
+	if !ok {
+		return "", nil
+	}
+	types, err := discoveryChecks(api, ruleID)
+	if err != nil {
+		return "", err
+	}
+	checkType := types[id]
+	for otherID, t := range types {
+		if otherID != id && t == checkType {
+			return "", nil
+		}
+	}
+	return rule + ":" + checkType, nil
+}
@@ -0,0 +1,204 @@
+package zabbix
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/claranet/go-zabbix-api"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestActionConditionNames(t *testing.T) {
+	for _, serverVersion := range testVersions {
+		s, api := newFakeZabbixClient(t, serverVersion)
+
+		hostID := fakeCreatedID(t, s, "host.create", "hostids", []interface{}{map[string]interface{}{
+			"host":       "web",
+			"groups":     []interface{}{map[string]string{"groupid": "2"}},
+			"interfaces": []interface{}{map[string]interface{}{"ip": "127.0.0.1", "main": "1", "port": "10050", "type": "1"}},
+		}})
+		templateID := fakeCreatedID(t, s, "template.create", "templateids", []interface{}{map[string]interface{}{
+			"host":   "template",
+			"groups": []interface{}{map[string]string{"groupid": "1"}},
+		}})
+		fakeCreatedID(t, s, "item.create", "itemids", []interface{}{map[string]interface{}{
+			"hostid": templateID, "name": "load", "key_": "load", "type": 2, "value_type": 0,
+		}})
+		expression := "{template:load.last()}>5"
+		if s.atLeast(triggerSyntaxVersion) {
+			expression = "last(/template/load)>5"
+		}
+		triggerID := fakeCreatedID(t, s, "trigger.create", "triggerids", []interface{}{map[string]interface{}{
+			"description": "High load", "expression": expression,
+		}})
+		proxyName := "host"
+		if s.atLeast("7.0") {
+			proxyName = "name"
+		}
+		proxyID := fakeCreatedID(t, s, "proxy.create", "proxyids", []interface{}{map[string]interface{}{proxyName: "proxy"}})
+		ruleID := fakeCreatedID(t, s, "drule.create", "druleids", []interface{}{map[string]interface{}{"name": "Local network"}})
+		s.mu.Lock()
+		s.objects["dcheck"] = []fakeObject{
+			{"dcheckid": "50001", "druleid": ruleID, "type": "12"},
+			{"dcheckid": "50002", "druleid": ruleID, "type": "9"},
+			{"dcheckid": "50003", "druleid": ruleID, "type": "9"},
+		}
+		s.mu.Unlock()
+
+		conditions := func(values ...string) []interface{} {
+			var lst []interface{}
+			for i := 0; i < len(values); i += 2 {
+				lst = append(lst, map[string]interface{}{
+					"condition_id": "", "type": values[i], "value": values[i+1], "value2": "", "formula_id": "", "operator": "equals",
+				})
+			}
+			return lst
+		}
+		configured := conditions(
+			"host_group", "Linux servers",
+			"host", "web",
+			"host_template", "template",
+			"trigger", "template:High load",
+			"proxy", "proxy",
+			"discovery_rule", "Local network",
+			"discovery_check", "Local network:icmp",
+			"host", hostID,
+			"trigger_name", "42",
+		)
+		ids := []string{"2", hostID, templateID, triggerID, proxyID, ruleID, "50001", hostID, "42"}
+
+		start := len(s.calls("host.get"))
+		items, err := createActionConditionObject(configured, api)
+		if err != nil {
+			t.Fatalf("%s: %s", serverVersion, err)
+		}
+		var values []string
+		for _, item := range items {
+			values = append(values, item.Value)
+		}
+		if !reflect.DeepEqual(values, ids) {
+			t.Errorf("%s: expected the IDs %v, got %v", serverVersion, ids, values)
+		}
+		if calls := len(s.calls("host.get")) - start; calls != 1 {
+			t.Errorf("%s: expected the hosts to be resolved in one request, got %d", serverVersion, calls)
+		}
+
+		read := func(state []interface{}) []string {
+			t.Helper()
+			lst, err := readActionConditions(items, zabbix.AndOr, state, api)
+			if err != nil {
+				t.Fatalf("%s: %s", serverVersion, err)
+			}
+			var values []string
+			for _, m := range lst {
+				values = append(values, m.(map[string]interface{})["value"].(string))
+			}
+			return values
+		}
+		expected := []string{"Linux servers", "web", "template", "template:High load", "proxy", "Local network", "Local network:icmp", hostID, "42"}
+		if got := read(configured); !reflect.DeepEqual(got, expected) {
+			t.Errorf("%s: expected the values %v, got %v", serverVersion, expected, got)
+		}
+		// Imported actions name the objects
+		expected[7] = "web"
+		if got := read(nil); !reflect.DeepEqual(got, expected) {
+			t.Errorf("%s: expected the imported values %v, got %v", serverVersion, expected, got)
+		}
+
+		for _, c := range []struct {
+			conditionType, value, expected string
+		}{
+			{"host", "missing", `host not found: missing`},
+			{"host_template", "web", `template not found: web`},
+			{"trigger", "High load", `must be referenced by ID or as <host>:<description>`},
+			{"trigger", "template:Low load", `trigger not found: template:Low load`},
+			{"discovery_check", "Local network:zabbix_agent", `ambiguous, the rule has 2 checks of this type`},
+			{"discovery_check", "Local network:ping", `unknown type of check "ping"`},
+			{"discovery_check", "Remote network:icmp", `discovery rule not found: Remote network`},
+			{"host", "99999", `host not found: 99999, values made of digits are IDs`},
+			{"trigger", "99999", `trigger not found: 99999`},
+			{"discovery_check", "99999", `discovery check not found: 99999`},
+		} {
+			_, err := createActionConditionObject(conditions(c.conditionType, c.value), api)
+			if err == nil || !regexp.MustCompile(regexp.QuoteMeta(c.expected)).MatchString(err.Error()) {
+				t.Errorf("%s: expected the %s condition %q to fail with %s, got %v", serverVersion, c.conditionType, c.value, c.expected, err)
+			}
+		}
+	}
+}
+
+func TestIsActionConditionName(t *testing.T) {
+	for _, c := range []struct {
+		conditionType, value string
+		named                bool
+	}{
+		{"host_group", "12", true},
+		{"host", "web", true},
+		{"host", "10084", false},
+		{"trigger", "web:High load", true},
+		{"trigger", "13491", false},
+		{"trigger_name", "High load", false},
+	} {
+		if named := isActionConditionName(c.conditionType, c.value); named != c.named {
+			t.Errorf("%s %q: expected named %v, got %v", c.conditionType, c.value, c.named, named)
+		}
+	}
+}
+
+func TestActionOperationConditions(t *testing.T) {
+	for _, serverVersion := range testVersions {
+		s, api := newFakeZabbixClient(t, serverVersion)
+
+		operation := func(step int, conditions ...interface{}) map[string]interface{} {
+			operation := map[string]interface{}{
+				"type":      "send_message",
+				"step_from": step,
+				"step_to":   step,
+				"condition": conditions,
+				"message": []interface{}{map[string]interface{}{
+					"default_message": false,
+					"subject":         "Subject",
+					"message":         "Message",
+					"target":          []interface{}{map[string]interface{}{"type": "user_group", "value": "Zabbix administrators"}},
+				}},
+			}
+			if len(conditions) > 0 {
+				operation["calculation"] = "and"
+			}
+			return operation
+		}
+		action := func(eventSource string) map[string]interface{} {
+			return map[string]interface{}{
+				"name":         "Action " + eventSource,
+				"event_source": eventSource,
+				"operation": []interface{}{
+					operation(1),
+					operation(2, map[string]interface{}{"type": "event_acknowledged", "operator": "no"}),
+				},
+			}
+		}
+
+		start := s.requestCount()
+		state := testApplyResource(t, serverVersion, resourceZabbixAction(), api, nil, action("trigger"))
+		testAssertRequests(t, serverVersion, s.requestsFrom(start), []fakePayload{
+			{"action.create", []interface{}{map[string]interface{}{
+				"operations": []interface{}{
+					map[string]interface{}{"evaltype": nil, "opconditions": nil},
+					map[string]interface{}{"evaltype": "1", "opconditions": []interface{}{
+						map[string]interface{}{"conditiontype": "14", "operator": "0", "value": "0"},
+					}},
+				},
+			}}},
+		})
+		if got := state.Attributes["operation.1.condition.0.operator"]; got != "no" {
+			t.Errorf("%s: expected the condition of the second operation to be read back, got %v", serverVersion, state.Attributes)
+		}
+
+		_, err = resourceZabbixAction().Diff(context.Background(), nil, terraform.NewResourceConfigRaw(action("discovery")), api)
+		if err == nil || !regexp.MustCompile(`operation 2: conditions are only supported`).MatchString(err.Error()) {
+			t.Errorf("%s: expected the conditions of a discovery action to be refused by the plan, got %v", serverVersion, err)
+		}
+	}
+}
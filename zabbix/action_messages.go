@@ -0,0 +1,143 @@
+package zabbix
+
+import (
+	"strings"
+
+	"github.com/claranet/go-zabbix-api"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// Kinds of operations whose messages media types template, as the recovery field of message templates
+const (
+	messageTemplateOperation         = "0"
+	messageTemplateRecoveryOperation = "1"
+	messageTemplateUpdateOperation   = "2"
+)
+
+// messageTemplateEventSources are the event sources of message templates by name of event source
+var messageTemplateEventSources = map[string]string{
+	"trigger":           "0",
+	"discovery":         "1",
+	"auto-registration": "2",
+	"internal":          "3",
+}
+
+// actionMessageConfig is the message of an operation as configured, before the schema defaults and the subject and
+// message read from its media type fill it
+type actionMessageConfig struct {
+	defaultMessageSet bool
+	subject, message  string
+}
+
+// actionMessageConfigs returns the configured messages of the operations of a block, nil for operations without
+// message, or nothing when the configuration is not available
+func actionMessageConfigs(d *schema.ResourceData, block string) []*actionMessageConfig {
+	raw := d.GetRawConfig()
+	if raw.IsNull() || !raw.IsKnown() {
+		return nil
+	}
+	operations := raw.GetAttr(block)
+	if operations.IsNull() || !operations.IsKnown() {
+		return nil
+	}
+
+	var configs []*actionMessageConfig
+	for it := operations.ElementIterator(); it.Next(); {
+		_, operation := it.Element()
+		var config *actionMessageConfig
+		if messages := operation.GetAttr("message"); !messages.IsNull() && messages.IsKnown() && messages.LengthInt() > 0 {
+			message := messages.Index(cty.NumberIntVal(0))
+			config = &actionMessageConfig{
+				defaultMessageSet: !message.GetAttr("default_message").IsNull(),
+				subject:           ctyString(message.GetAttr("subject")),
+				message:           ctyString(message.GetAttr("message")),
+			}
+		}
+		configs = append(configs, config)
+	}
+	return configs
+}
+
+// actionMessageConfigAt returns the configured message of the operation at an index, if any
+func actionMessageConfigAt(configs []*actionMessageConfig, i int) *actionMessageConfig {
+	if i < len(configs) {
+		return configs[i]
+	}
+	return nil
+}
+
+func ctyString(v cty.Value) string {
+	if v.IsNull() || !v.IsKnown() {
+		return ""
+	}
+	return v.AsString()
+}
+
+// suppressMediaTypeMessageDiff ignores the subject and message read from the media type of an operation sending
+// its default message, when they are not configured
+func suppressMediaTypeMessageDiff(k, old, new string, d *schema.ResourceData) bool {
+	prefix := k[:strings.LastIndex(k, ".")+1]
+	return new == "" && d.Get(prefix+"default_message").(bool)
+}
+
+// mediaTypeMessageTemplate is the message a media type sends by default for an event source and kind of operations
+type mediaTypeMessageTemplate struct {
+	EventSource string `json:"eventsource"`
+	Recovery    string `json:"recovery"`
+	Subject     string `json:"subject"`
+	Message     string `json:"message"`
+}
+
+// mediaTypeMessageTemplates reads the message templates of media types for the operations of an action, once per
+// media type. Media types have message templates from Zabbix 5.0.
+type mediaTypeMessageTemplates struct {
+	api         *zabbixClient
+	eventSource string
+	templates   map[string][]mediaTypeMessageTemplate
+}
+
+func newMediaTypeMessageTemplates(api *zabbixClient, eventSource string) *mediaTypeMessageTemplates {
+	if !versionAtLeast(api.ServerVersion, "5.0") {
+		return nil
+	}
+	return &mediaTypeMessageTemplates{
+		api:         api,
+		eventSource: messageTemplateEventSources[eventSource],
+		templates:   map[string][]mediaTypeMessageTemplate{},
+	}
+}
+
+// message returns the subject and message a media type sends by default for a kind of operations, or nothing
+// when the operation sends through all media types
+func (t *mediaTypeMessageTemplates) message(mediaTypeID, recovery string) (subject, message string, err error) {
+	if t == nil || mediaTypeID == "" || mediaTypeID == "0" {
+		return
+	}
+
+	templates, ok := t.templates[mediaTypeID]
+	if !ok {
+		var mediaTypes []struct {
+			MessageTemplates []mediaTypeMessageTemplate `json:"message_templates"`
+		}
+		err = t.api.CallWithErrorParse("mediatype.get", zabbix.Params{
+			"output":                 []string{"mediatypeid"},
+			"mediatypeids":           mediaTypeID,
+			"selectMessageTemplates": "extend",
+		}, &mediaTypes)
+		if err != nil {
+			return
+		}
+		if len(mediaTypes) == 1 {
+			templates = mediaTypes[0].MessageTemplates
+		}
+		t.templates[mediaTypeID] = templates
+	}
+
+	for _, template := range templates {
+		if template.EventSource == t.eventSource && template.Recovery == recovery {
+			return template.Subject, template.Message, nil
+		}
+	}
+	return
+}
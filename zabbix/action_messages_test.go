@@ -0,0 +1,90 @@
+package zabbix
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	ctyjson "github.com/hashicorp/go-cty/cty/json"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestActionMessageDefaults(t *testing.T) {
+	ctx := context.Background()
+	for _, serverVersion := range testVersions {
+		s, api := newFakeZabbixClient(t, serverVersion)
+		s.mu.Lock()
+		s.objects["mediatype"][0]["message_templates"] = []interface{}{
+			map[string]interface{}{"eventsource": "0", "recovery": "0", "subject": "Problem: {EVENT.NAME}", "message": "Problem started"},
+			map[string]interface{}{"eventsource": "0", "recovery": "1", "subject": "Resolved: {EVENT.NAME}", "message": "Problem resolved"},
+		}
+		s.mu.Unlock()
+
+		message := func(fields map[string]interface{}) []interface{} {
+			fields["media_type_id"] = "1"
+			fields["target"] = []interface{}{map[string]interface{}{"type": "user_group", "value": "Zabbix administrators"}}
+			return []interface{}{fields}
+		}
+		config := map[string]interface{}{
+			"name":         "Action",
+			"event_source": "trigger",
+			"operation": []interface{}{map[string]interface{}{
+				"type":    "send_message",
+				"message": message(map[string]interface{}{}),
+			}},
+			"recovery_operation": []interface{}{map[string]interface{}{
+				"type":    "send_message",
+				"message": message(map[string]interface{}{"subject": "Resolved"}),
+			}},
+		}
+
+		r := resourceZabbixAction()
+		raw, err := json.Marshal(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rawConfig, err := ctyjson.Unmarshal(raw, r.CoreConfigSchema().ImpliedType())
+		if err != nil {
+			t.Fatalf("%s: %s", serverVersion, err)
+		}
+		diff, err := r.Diff(ctx, nil, terraform.NewResourceConfigRaw(config), api)
+		if err != nil {
+			t.Fatalf("%s: %s", serverVersion, err)
+		}
+		diff.RawConfig = rawConfig
+		state, diags := r.Apply(ctx, nil, diff, api)
+		if diags.HasError() {
+			t.Fatalf("%s: %v", serverVersion, diags)
+		}
+		state, diags = r.RefreshWithoutUpgrade(ctx, state, api)
+		if diags.HasError() {
+			t.Fatalf("%s: %v", serverVersion, diags)
+		}
+
+		expected := map[string]string{
+			"operation.0.message.0.default_message":          "false",
+			"operation.0.message.0.subject":                  "",
+			"recovery_operation.0.message.0.default_message": "false",
+			"recovery_operation.0.message.0.subject":         "Resolved",
+		}
+		if versionAtLeast(serverVersion, "5.0") {
+			expected["operation.0.message.0.default_message"] = "true"
+			expected["operation.0.message.0.subject"] = "Problem: {EVENT.NAME}"
+			expected["operation.0.message.0.message"] = "Problem started"
+		}
+		for key, value := range expected {
+			if got := state.Attributes[key]; got != value {
+				t.Errorf("%s: expected %s to be %q, got %q", serverVersion, key, value, got)
+			}
+		}
+
+		// The messages of the media type are not configured
+		diff, err = r.Diff(ctx, state, terraform.NewResourceConfigRaw(config), api)
+		if err != nil {
+			t.Fatalf("%s: %s", serverVersion, err)
+		}
+		if diff != nil && !diff.Empty() {
+			t.Errorf("%s: expected nothing to plan once applied, got %v", serverVersion, diff.Attributes)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package zabbix
+
+import (
+	"log"
+	"strings"
+	"sync"
+)
+
+// apiCache memoizes the objects read from the API during a run of the provider, like a refresh. The items are
+// flushed whenever the provider writes to the API, and the names of the other objects whenever the provider writes
+// objects of their type, so that the reads following a change never see objects as they were before it.
+type apiCache struct {
+	mu sync.Mutex
+	// generation changes on every flush, so that the objects fetched before a write are not cached after it
+	generation int
+	items      map[string]triggerItem
+	// itemLookups and itemRequests count the items resolved and the requests sent for them, reported in debug logs
+	itemLookups, itemRequests int
+	// ids and names map the names of objects to their IDs and back, by type of objects
+	ids, names map[string]map[string]string
+}
+
+// written flushes what a write through the method may have changed
+func (c *apiCache) written(method string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.items = nil
+	for _, objectType := range lookupMethods[strings.SplitN(method, ".", 2)[0]] {
+		delete(c.ids, objectType)
+		delete(c.names, objectType)
+	}
+}
+
+// lookups returns the IDs by name, or the names by ID, of the objects of a type found in the cache, the keys
+// missing from it, and the generation to store them with once fetched
+func (c *apiCache) lookups(objectType string, keys []string, byID bool) (map[string]string, []string, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached := c.ids[objectType]
+	if byID {
+		cached = c.names[objectType]
+	}
+	result := make(map[string]string, len(keys))
+	var missing []string
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if value, ok := cached[key]; ok {
+			result[key] = value
+		} else if !seen[key] {
+			missing = append(missing, key)
+		}
+		seen[key] = true
+	}
+	return result, missing, c.generation
+}
+
+// storeLookups caches the names of objects of a type by ID, unless the cache was flushed since they were asked
+func (c *apiCache) storeLookups(objectType string, generation int, names map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation != generation {
+		return
+	}
+	if c.ids == nil {
+		c.ids = make(map[string]map[string]string)
+		c.names = make(map[string]map[string]string)
+	}
+	if c.ids[objectType] == nil {
+		c.ids[objectType] = make(map[string]string)
+		c.names[objectType] = make(map[string]string)
+	}
+	for id, name := range names {
+		c.ids[objectType][name] = id
+		c.names[objectType][id] = name
+	}
+}
+
+// triggerItems returns the items of the IDs, fetching the ones missing from the cache with a single call of get
+func (c *apiCache) triggerItems(api *zabbixClient, ids []string, get triggerItemsGetter) (map[string]triggerItem, error) {
+	items := make(map[string]triggerItem, len(ids))
+	var missing []string
+
+	c.mu.Lock()
+	generation := c.generation
+	for _, id := range ids {
+		if item, ok := c.items[id]; ok {
+			items[id] = item
+		} else if _, ok := items[id]; !ok {
+			items[id] = triggerItem{}
+			missing = append(missing, id)
+		}
+	}
+	c.itemLookups += len(ids)
+	c.mu.Unlock()
+
+	if len(missing) == 0 {
+		return items, nil
+	}
+	fetched, err := get(api, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.itemRequests++
+	if c.generation == generation && c.items == nil {
+		c.items = make(map[string]triggerItem)
+	}
+	for _, id := range missing {
+		item, ok := fetched[id]
+		if !ok {
+			delete(items, id)
+			continue
+		}
+		items[id] = item
+		if c.generation == generation {
+			c.items[id] = item
+		}
+	}
+	log.Printf("[DEBUG] Fetched %d of %d items of trigger functions in one request, %d requests for %d items so far", len(missing), len(ids), c.itemRequests, c.itemLookups)
+	return items, nil
+}
@@ -0,0 +1,130 @@
+package zabbix
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/claranet/go-zabbix-api"
+)
+
+// zabbixClient is the meta of the provider: the API, with the cache of what was read from it during the run
+type zabbixClient struct {
+	*zabbix.API
+	cache *apiCache
+}
+
+// Types of the objects whose names are resolved through the cache
+const (
+	lookupHostGroup     = "host group"
+	lookupTemplateGroup = "template group"
+	lookupTemplate      = "template"
+	lookupHost          = "host"
+	lookupUser          = "user"
+	lookupUserGroup     = "user group"
+	lookupMediaType     = "media type"
+	lookupProxy         = "proxy"
+	lookupDiscoveryRule = "discovery rule"
+)
+
+// lookupMethods are the types of objects changed by the methods of each API object, whose names are flushed
+// from the cache when the provider writes through them
+var lookupMethods = map[string][]string{
+	// Template groups are host groups before Zabbix 6.2
+	"hostgroup":     {lookupHostGroup, lookupTemplateGroup},
+	"templategroup": {lookupTemplateGroup},
+	"template":      {lookupTemplate},
+	"host":          {lookupHost},
+	"user":          {lookupUser},
+	"usergroup":     {lookupUserGroup},
+	"mediatype":     {lookupMediaType},
+	"proxy":         {lookupProxy},
+	"drule":         {lookupDiscoveryRule},
+	"configuration": {lookupHostGroup, lookupTemplateGroup, lookupTemplate, lookupHost, lookupMediaType},
+}
+
+// lookupObject is how to read the names and IDs of a type of objects
+type lookupObject struct {
+	method, idField, idsParameter, nameField string
+}
+
+func (c *zabbixClient) lookupObject(objectType string) lookupObject {
+	switch objectType {
+	case lookupHostGroup:
+		return lookupObject{"hostgroup.get", "groupid", "groupids", "name"}
+	case lookupTemplateGroup:
+		if versionAtLeast(c.ServerVersion, "6.2") {
+			return lookupObject{"templategroup.get", "groupid", "groupids", "name"}
+		}
+		return lookupObject{"hostgroup.get", "groupid", "groupids", "name"}
+	case lookupTemplate:
+		return lookupObject{"template.get", "templateid", "templateids", "host"}
+	case lookupHost:
+		return lookupObject{"host.get", "hostid", "hostids", "host"}
+	case lookupUser:
+		if versionAtLeast(c.ServerVersion, "5.4") {
+			return lookupObject{"user.get", "userid", "userids", "username"}
+		}
+		return lookupObject{"user.get", "userid", "userids", "alias"}
+	case lookupUserGroup:
+		return lookupObject{"usergroup.get", "usrgrpid", "usrgrpids", "name"}
+	case lookupMediaType:
+		if versionAtLeast(c.ServerVersion, "4.4") {
+			return lookupObject{"mediatype.get", "mediatypeid", "mediatypeids", "name"}
+		}
+		return lookupObject{"mediatype.get", "mediatypeid", "mediatypeids", "description"}
+	case lookupProxy:
+		if versionAtLeast(c.ServerVersion, "7.0") {
+			return lookupObject{"proxy.get", "proxyid", "proxyids", "name"}
+		}
+		return lookupObject{"proxy.get", "proxyid", "proxyids", "host"}
+	case lookupDiscoveryRule:
+		return lookupObject{"drule.get", "druleid", "druleids", "name"}
+	}
+	panic(fmt.Sprintf("unknown type of objects %q", objectType))
+}
+
+// lookupIDs returns the IDs of the objects of a type by name, fetching the ones missing from the cache in a single
+// request. The names of objects that do not exist are left out.
+func (c *zabbixClient) lookupIDs(objectType string, names []string) (map[string]string, error) {
+	return c.lookup(objectType, names, false)
+}
+
+// lookupNames returns the names of the objects of a type by ID, fetching the ones missing from the cache in a
+// single request. The IDs of objects that do not exist are left out.
+func (c *zabbixClient) lookupNames(objectType string, ids []string) (map[string]string, error) {
+	return c.lookup(objectType, ids, true)
+}
+
+func (c *zabbixClient) lookup(objectType string, keys []string, byID bool) (map[string]string, error) {
+	result, missing, generation := c.cache.lookups(objectType, keys, byID)
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	object := c.lookupObject(objectType)
+	params := zabbix.Params{"output": []string{object.idField, object.nameField}}
+	if byID {
+		params[object.idsParameter] = missing
+	} else {
+		params["filter"] = map[string]interface{}{object.nameField: missing}
+	}
+	var objects []map[string]interface{}
+	if err := c.CallWithErrorParse(object.method, params, &objects); err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]string, len(objects))
+	for _, o := range objects {
+		id, _ := o[object.idField].(string)
+		name, _ := o[object.nameField].(string)
+		found[id] = name
+		if byID {
+			result[id] = name
+		} else {
+			result[name] = id
+		}
+	}
+	c.cache.storeLookups(objectType, generation, found)
+	log.Printf("[DEBUG] Fetched %d of %d %ss in one request", len(missing), len(keys), objectType)
+	return result, nil
+}
@@ -0,0 +1,73 @@
+package zabbix
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestClientLookups(t *testing.T) {
+	for _, serverVersion := range testVersions {
+		s, api := newFakeZabbixClient(t, serverVersion)
+
+		lookup := func(objectType string, keys []string, byID bool, expected map[string]string, requests int) {
+			t.Helper()
+			start := s.requestCount()
+			var got map[string]string
+			if byID {
+				got, err = api.lookupNames(objectType, keys)
+			} else {
+				got, err = api.lookupIDs(objectType, keys)
+			}
+			if err != nil {
+				t.Fatalf("%s: %s", serverVersion, err)
+			}
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("%s: expected the %ss %v, got %v", serverVersion, objectType, expected, got)
+			}
+			if sent := s.requestCount() - start; sent != requests {
+				t.Errorf("%s: expected %d requests for the %ss %v, got %d", serverVersion, requests, objectType, keys, sent)
+			}
+		}
+
+		lookup(lookupHostGroup, []string{"Linux servers", "Zabbix servers", "Linux servers", "missing"}, false,
+			map[string]string{"Linux servers": "2", "Zabbix servers": "4"}, 1)
+		lookup(lookupHostGroup, []string{"Zabbix servers"}, false, map[string]string{"Zabbix servers": "4"}, 0)
+		lookup(lookupHostGroup, []string{"2"}, true, map[string]string{"2": "Linux servers"}, 0)
+		lookup(lookupTemplateGroup, []string{"Templates"}, false, map[string]string{"Templates": "1"}, 1)
+		lookup(lookupUser, []string{fakeZabbixUser, "guest"}, false, map[string]string{fakeZabbixUser: "1", "guest": "2"}, 1)
+		lookup(lookupUserGroup, []string{"7"}, true, map[string]string{"7": "Zabbix administrators"}, 1)
+		lookup(lookupMediaType, []string{"Email"}, false, map[string]string{"Email": "1"}, 1)
+
+		// Writing other objects keeps the names
+		if _, err := api.CallWithError("usergroup.update", []interface{}{map[string]interface{}{"usrgrpid": "8", "name": "Visitors"}}); err != nil {
+			t.Fatalf("%s: %s", serverVersion, err)
+		}
+		lookup(lookupHostGroup, []string{"Linux servers"}, false, map[string]string{"Linux servers": "2"}, 0)
+		lookup(lookupUserGroup, []string{"8"}, true, map[string]string{"8": "Visitors"}, 1)
+
+		// Creating a host group flushes the names of the host groups
+		groupID := ""
+		response, err := api.CallWithError("hostgroup.create", []interface{}{map[string]interface{}{"name": "created"}})
+		if err == nil {
+			groupID, err = idFromResponse(response, "groupids")
+		}
+		if err != nil {
+			t.Fatalf("%s: %s", serverVersion, err)
+		}
+		lookup(lookupHostGroup, []string{"Linux servers", "created"}, false, map[string]string{"Linux servers": "2", "created": groupID}, 1)
+		lookup(lookupUser, []string{"guest"}, false, map[string]string{"guest": "2"}, 0)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := api.lookupNames(lookupHostGroup, []string{"2", "4", groupID}); err != nil {
+					t.Errorf("%s: %s", serverVersion, err)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+}
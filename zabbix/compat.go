@@ -0,0 +1,303 @@
+package zabbix
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+const (
+	// minimumZabbixVersion is the oldest version of Zabbix the provider supports
+	minimumZabbixVersion = "3.4"
+	// latestZabbixVersion is the latest major version of Zabbix the provider is tested against
+	latestZabbixVersion = "7.0"
+	// bearerAuthVersion is the first version of Zabbix accepting the session in the Authorization header
+	bearerAuthVersion = "6.4"
+)
+
+// checkZabbixVersion fails for servers older than minimumZabbixVersion. Newer servers than latestZabbixVersion
+// are only warned about, as most of the API is left unchanged between versions.
+func checkZabbixVersion(serverVersion *version.Version) error {
+	if serverVersion.LessThan(version.Must(version.NewVersion(minimumZabbixVersion))) {
+		return fmt.Errorf("Zabbix %s is not supported, the provider requires Zabbix %s or later", serverVersion, minimumZabbixVersion)
+	}
+	latest := version.Must(version.NewVersion(latestZabbixVersion)).Segments()
+	if segments := serverVersion.Segments(); segments[0] > latest[0] || segments[0] == latest[0] && segments[1] > latest[1] {
+		log.Printf("[WARN] Zabbix %s is newer than Zabbix %s, the latest version supported by the provider", serverVersion, latestZabbixVersion)
+	}
+	return nil
+}
+
+// compatRule rewrites the parameters of the requests sent to the servers of the version since or later, and their
+// results
+type compatRule struct {
+	since string
+	// methods are the methods the rule applies to, or their prefix when they end with a dot
+	methods  []string
+	request  func(params interface{}) interface{}
+	response func(result interface{}) interface{}
+}
+
+func (r compatRule) appliesTo(method string) bool {
+	for _, m := range r.methods {
+		if m == method || strings.HasSuffix(m, ".") && strings.HasPrefix(method, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// compatRules adapt the requests of the go-zabbix-api library, written for older versions of Zabbix, to the
+// changes of the API
+var compatRules = []compatRule{
+	{
+		since:   "5.0",
+		methods: []string{"host.create", "host.update"},
+		request: compatObjects(compatSNMPInterfaces),
+	},
+	{
+		since:   "5.0",
+		methods: []string{"action.create", "action.update"},
+		request: compatRemove("def_shortdata", "def_longdata", "r_shortdata", "r_longdata", "ack_shortdata", "ack_longdata"),
+	},
+	{
+		since:   "5.4",
+		methods: []string{"user.login"},
+		request: compatRename(map[string]string{"user": "username"}),
+	},
+	{
+		since:   "5.4",
+		methods: []string{"item.", "itemprototype."},
+		request: compatRemove("applications", "applicationids", "selectApplications"),
+	},
+	{
+		since:    "6.0",
+		methods:  []string{"action."},
+		request:  compatRename(map[string]string{"acknowledge_operations": "update_operations", "selectAcknowledgeOperations": "selectUpdateOperations"}),
+		response: compatCopy(map[string]string{"update_operations": "acknowledge_operations"}),
+	},
+	{
+		since:    "7.0",
+		methods:  []string{"host."},
+		request:  compatRename(map[string]string{"proxy_hostid": "proxyid"}),
+		response: compatCopy(map[string]string{"proxyid": "proxy_hostid"}),
+	},
+}
+
+// compatObjects applies a rewrite to the parameters, or to each of them when they are a list of objects
+func compatObjects(rewrite func(map[string]interface{})) func(interface{}) interface{} {
+	return func(params interface{}) interface{} {
+		switch p := params.(type) {
+		case map[string]interface{}:
+			rewrite(p)
+		case []interface{}:
+			for _, element := range p {
+				if object, ok := element.(map[string]interface{}); ok {
+					rewrite(object)
+				}
+			}
+		}
+		return params
+	}
+}
+
+// compatRename renames properties, including the ones listed in the output and filter of get requests
+func compatRename(names map[string]string) func(interface{}) interface{} {
+	return compatObjects(func(object map[string]interface{}) {
+		renameProperties(object, names)
+		if filter, ok := object["filter"].(map[string]interface{}); ok {
+			renameProperties(filter, names)
+		}
+		if output, ok := object["output"].([]interface{}); ok {
+			for i, field := range output {
+				if new, ok := names[fmt.Sprint(field)]; ok {
+					output[i] = new
+				}
+			}
+		}
+	})
+}
+
+func renameProperties(object map[string]interface{}, names map[string]string) {
+	for old, new := range names {
+		if value, ok := object[old]; ok {
+			delete(object, old)
+			object[new] = value
+		}
+	}
+}
+
+// compatRemove removes properties unknown to the server
+func compatRemove(names ...string) func(interface{}) interface{} {
+	return compatObjects(func(object map[string]interface{}) {
+		for _, name := range names {
+			delete(object, name)
+		}
+	})
+}
+
+// compatCopy copies properties of the results under the name the library knows them, so that it finds them
+// whatever the version it was written for
+func compatCopy(names map[string]string) func(interface{}) interface{} {
+	return compatObjects(func(object map[string]interface{}) {
+		for from, to := range names {
+			if value, ok := object[from]; ok {
+				if _, exists := object[to]; !exists {
+					object[to] = value
+				}
+			}
+		}
+	})
+}
+
+// compatSNMPInterfaces moves the bulk property of interfaces to their details, where Zabbix 5.0 expects it
+func compatSNMPInterfaces(host map[string]interface{}) {
+	interfaces, _ := host["interfaces"].([]interface{})
+	for _, i := range interfaces {
+		hostInterface, ok := i.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		bulk, hasBulk := hostInterface["bulk"]
+		delete(hostInterface, "bulk")
+		if details, ok := hostInterface["details"].(map[string]interface{}); ok && hasBulk {
+			if _, set := details["bulk"]; !set {
+				details["bulk"] = bulk
+			}
+		}
+	}
+}
+
+// compatTransport rewrites the JSON-RPC requests sent by the go-zabbix-api library according to the version of
+// the server, and their responses back
+type compatTransport struct {
+	transport http.RoundTripper
+	// version is set once it is known, before any request needing a rewrite is sent
+	version *version.Version
+	// onWrite is called with the method of the requests that can change objects, before and after them, to flush
+	// what was read before
+	onWrite func(method string)
+}
+
+// isWriteMethod is whether a method can change objects, every method but the ones reading them or the session
+func isWriteMethod(method string) bool {
+	switch method {
+	case "apiinfo.version", "user.login", "user.logout", "user.checkAuthentication":
+		return false
+	}
+	return !strings.HasSuffix(method, ".get")
+}
+
+// setVersion keys the rewrites on the version of the server, after checking that it is supported
+func (t *compatTransport) setVersion(serverVersion string) error {
+	v, err := version.NewVersion(serverVersion)
+	if err != nil {
+		return fmt.Errorf("unexpected Zabbix version %q: %s", serverVersion, err)
+	}
+	if err := checkZabbixVersion(v); err != nil {
+		return err
+	}
+	t.version = v
+	return nil
+}
+
+func (t *compatTransport) atLeast(v string) bool {
+	return t.version != nil && t.version.GreaterThanOrEqual(version.Must(version.NewVersion(v)))
+}
+
+func (t *compatTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil {
+		return t.transport.RoundTrip(req)
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	var request map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&request); err != nil {
+		// Not a JSON-RPC request, sent as is
+		return t.send(req, body)
+	}
+	method, _ := request["method"].(string)
+
+	var responseRules []compatRule
+	for _, rule := range compatRules {
+		if !t.atLeast(rule.since) || !rule.appliesTo(method) {
+			continue
+		}
+		if rule.request != nil {
+			request["params"] = rule.request(request["params"])
+		}
+		if rule.response != nil {
+			responseRules = append(responseRules, rule)
+		}
+	}
+
+	// apiinfo.version must be called without session, which is sent in the Authorization header when supported
+	auth, _ := request["auth"].(string)
+	if method == "apiinfo.version" || auth == "" || t.atLeast(bearerAuthVersion) {
+		delete(request, "auth")
+	}
+	req = req.Clone(req.Context())
+	if auth != "" && method != "apiinfo.version" && t.atLeast(bearerAuthVersion) {
+		req.Header.Set("Authorization", "Bearer "+auth)
+	}
+
+	if body, err = json.Marshal(request); err != nil {
+		return nil, err
+	}
+	if t.onWrite != nil && isWriteMethod(method) {
+		t.onWrite(method)
+		defer t.onWrite(method)
+	}
+	resp, err := t.send(req, body)
+	if err != nil || len(responseRules) == 0 {
+		return resp, err
+	}
+	return compatResponse(resp, responseRules)
+}
+
+func (t *compatTransport) send(req *http.Request, body []byte) (*http.Response, error) {
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return t.transport.RoundTrip(req)
+}
+
+// compatResponse rewrites the result of a response with the rules of its request
+func compatResponse(resp *http.Response, rules []compatRule) (*http.Response, error) {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	var response map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&response); err == nil && response["result"] != nil {
+		for _, rule := range rules {
+			response["result"] = rule.response(response["result"])
+		}
+		if rewritten, err := json.Marshal(response); err == nil {
+			body = rewritten
+		}
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Del("Content-Length")
+	return resp, nil
+}
@@ -0,0 +1,158 @@
+package zabbix
+
+import (
+	"testing"
+
+	"github.com/claranet/go-zabbix-api"
+	"github.com/hashicorp/go-version"
+)
+
+func TestCheckZabbixVersion(t *testing.T) {
+	cases := []struct {
+		version   string
+		supported bool
+	}{
+		{"3.0.0", false},
+		{"3.2.11", false},
+		{"3.4.0", true},
+		{"6.4.0", true},
+		{"7.0.0", true},
+		{"7.2.0", true},
+	}
+
+	for _, c := range cases {
+		err := checkZabbixVersion(version.Must(version.NewVersion(c.version)))
+		if c.supported && err != nil {
+			t.Errorf("%s: expected the version to be supported, got %s", c.version, err)
+		}
+		if !c.supported && err == nil {
+			t.Errorf("%s: expected the version not to be supported", c.version)
+		}
+	}
+}
+
+func TestCompatTransportUnsupportedVersion(t *testing.T) {
+	server := newFakeZabbixServer("3.2.0")
+	defer server.Close()
+
+	if _, err := newZabbixClient(server.URL+"/api_jsonrpc.php", fakeZabbixUser, fakeZabbixPassword, "test"); err == nil {
+		t.Fatal("Expected the provider to refuse Zabbix 3.2")
+	}
+	if logins := server.calls("user.login"); len(logins) != 0 {
+		t.Fatalf("Expected no login to an unsupported server, got %v", logins)
+	}
+}
+
+func TestCompatTransportSession(t *testing.T) {
+	for _, serverVersion := range testVersions {
+		server, api := newFakeZabbixClient(t, serverVersion)
+		if _, err := api.HostGroupsGet(zabbix.Params{"output": "extend"}); err != nil {
+			t.Fatalf("%s: %s", serverVersion, err)
+		}
+
+		bearer := server.atLeast(bearerAuthVersion)
+		for _, request := range server.requestsFrom(0) {
+			switch request.Method {
+			case "apiinfo.version", "user.login":
+				if request.Bearer {
+					t.Errorf("%s: expected %s to be sent without session", serverVersion, request.Method)
+				}
+			default:
+				if request.Bearer != bearer {
+					t.Errorf("%s: expected the session of %s in the Authorization header to be %t", serverVersion, request.Method, bearer)
+				}
+			}
+		}
+
+		if server.atLeast("5.4") {
+			login := normalizeFakeValue(server.calls("user.login")[0])
+			if err := matchFakeParams("params", fakeObject{"username": fakeZabbixUser, "user": nil}, login); err != nil {
+				t.Errorf("%s: unexpected user.login request: %s", serverVersion, err)
+			}
+		}
+	}
+}
+
+func TestCompatTransportRewrites(t *testing.T) {
+	snmpHost := []interface{}{map[string]interface{}{
+		"host":   "snmp",
+		"groups": []interface{}{map[string]string{"groupid": "2"}},
+		"interfaces": []interface{}{map[string]interface{}{
+			"type": "2", "main": "1", "useip": "1", "ip": "127.0.0.1", "dns": "", "port": "161", "bulk": "0",
+		}},
+	}}
+	snmpDetailsHost := []interface{}{map[string]interface{}{
+		"host":   "snmp",
+		"groups": []interface{}{map[string]string{"groupid": "2"}},
+		"interfaces": []interface{}{map[string]interface{}{
+			"type": "2", "main": "1", "useip": "1", "ip": "127.0.0.1", "dns": "", "port": "161", "bulk": "0",
+			"details": map[string]interface{}{"version": "3", "securityname": "zabbix"},
+		}},
+	}}
+	proxyHost := []interface{}{map[string]interface{}{
+		"host":         "proxied",
+		"groups":       []interface{}{map[string]string{"groupid": "2"}},
+		"proxy_hostid": "0",
+	}}
+	action := []interface{}{map[string]interface{}{
+		"name":                   "action",
+		"eventsource":            "0",
+		"def_shortdata":          "subject",
+		"def_longdata":           "message",
+		"acknowledge_operations": []interface{}{},
+	}}
+
+	cases := []struct {
+		version  string
+		method   string
+		params   interface{}
+		expected interface{}
+	}{
+		{"4.0.0", "host.create", snmpHost, []interface{}{fakeObject{
+			"interfaces": []interface{}{fakeObject{"bulk": "0", "details": nil}},
+		}}},
+		{"5.0.0", "host.create", snmpDetailsHost, []interface{}{fakeObject{
+			"interfaces": []interface{}{fakeObject{"bulk": nil, "details": fakeObject{"version": "3", "bulk": "0", "securityname": "zabbix", "community": nil}}},
+		}}},
+		{"6.4.0", "host.create", proxyHost, []interface{}{fakeObject{"proxy_hostid": "0", "proxyid": nil}}},
+		{"7.0.0", "host.create", proxyHost, []interface{}{fakeObject{"proxy_hostid": nil, "proxyid": "0"}}},
+		{"7.0.0", "host.get", map[string]interface{}{"output": []string{"host", "proxy_hostid"}}, fakeObject{
+			"output": []interface{}{"host", "proxyid"},
+		}},
+		{"4.0.0", "action.create", action, []interface{}{fakeObject{"def_shortdata": "subject", "acknowledge_operations": []interface{}{}}}},
+		{"5.0.0", "action.create", action, []interface{}{fakeObject{"def_shortdata": nil, "def_longdata": nil, "acknowledge_operations": []interface{}{}}}},
+		{"6.0.0", "action.create", action, []interface{}{fakeObject{"acknowledge_operations": nil, "update_operations": []interface{}{}}}},
+	}
+
+	for _, c := range cases {
+		server, api := newFakeZabbixClient(t, c.version)
+
+		start := server.requestCount()
+		if _, err := api.CallWithError(c.method, c.params); err != nil {
+			t.Errorf("%s: %s: %s", c.version, c.method, err)
+		}
+		testAssertRequests(t, c.version, server.requestsFrom(start), []fakePayload{{c.method, c.expected}})
+	}
+}
+
+func TestCompatTransportResult(t *testing.T) {
+	server, api := newFakeZabbixClient(t, "7.0.0")
+	if _, err := api.CallWithError("host.create", []interface{}{map[string]interface{}{
+		"host":   "proxied",
+		"groups": []interface{}{map[string]string{"groupid": "2"}},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := api.CallWithError("host.get", zabbix.Params{"output": []string{"host", "proxy_hostid"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hosts, _ := response.Result.([]interface{})
+	if len(hosts) != 1 {
+		t.Fatalf("Expected one host, got %v", response.Result)
+	}
+	if host := hosts[0].(map[string]interface{}); host["proxy_hostid"] != "0" || host["proxyid"] != "0" {
+		t.Fatalf("Expected the proxy of the host under both names, got %v", host)
+	}
+}
@@ -0,0 +1,218 @@
+package zabbix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// versionCheck validates a planned resource against the version of the Zabbix server
+type versionCheck func(d *schema.ResourceDiff, serverVersion *version.Version) error
+
+// customizeDiffVersion runs checks against the version of the server, which is asked once when the provider is
+// configured, so that unsupported configurations fail at plan time rather than on apply
+func customizeDiffVersion(checks ...versionCheck) schema.CustomizeDiffFunc {
+	return func(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+		api, ok := meta.(*zabbixClient)
+		if !ok || api.ServerVersion == nil {
+			return nil
+		}
+
+		var errs []error
+		for _, check := range checks {
+			if err := check(d, api.ServerVersion); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	}
+}
+
+// diffValues returns the planned values of an attribute, where * stands for every element of a list or a set.
+// Values unknown until apply are left out.
+func diffValues(d *schema.ResourceDiff, attribute string) []interface{} {
+	parts := strings.SplitN(attribute, ".*.", 2)
+	if len(parts) == 1 {
+		if !d.NewValueKnown(attribute) {
+			return nil
+		}
+		return []interface{}{d.Get(attribute)}
+	}
+
+	var elements []interface{}
+	switch value := d.Get(parts[0]).(type) {
+	case []interface{}:
+		elements = value
+	case *schema.Set:
+		elements = value.List()
+	}
+	var values []interface{}
+	for _, element := range elements {
+		if element, ok := element.(map[string]interface{}); ok {
+			values = append(values, element[parts[1]])
+		}
+	}
+	return values
+}
+
+// isUnset is whether a value is the unset one, or an empty block or map
+func isUnset(value, unset interface{}) bool {
+	switch v := value.(type) {
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	case *schema.Set:
+		return v.Len() == 0
+	}
+	return value == unset
+}
+
+func versionAtLeast(serverVersion *version.Version, v string) bool {
+	return serverVersion.GreaterThanOrEqual(version.Must(version.NewVersion(v)))
+}
+
+// requiresVersion rejects an attribute set to another value than unset on servers older than since. Blocks and
+// maps are unset when empty.
+func requiresVersion(since, attribute string, unset interface{}) versionCheck {
+	return func(d *schema.ResourceDiff, serverVersion *version.Version) error {
+		if versionAtLeast(serverVersion, since) {
+			return nil
+		}
+		for _, value := range diffValues(d, attribute) {
+			if !isUnset(value, unset) {
+				return fmt.Errorf("%s requires Zabbix %s or later, the server runs Zabbix %s", attribute, since, serverVersion)
+			}
+		}
+		return nil
+	}
+}
+
+// removedIn rejects an attribute set to another value than unset on servers that removed it. Blocks and maps are
+// unset when empty.
+func removedIn(removed, attribute string, unset interface{}) versionCheck {
+	return func(d *schema.ResourceDiff, serverVersion *version.Version) error {
+		if !versionAtLeast(serverVersion, removed) {
+			return nil
+		}
+		for _, value := range diffValues(d, attribute) {
+			if !isUnset(value, unset) {
+				return fmt.Errorf("%s was removed in Zabbix %s, the server runs Zabbix %s", attribute, removed, serverVersion)
+			}
+		}
+		return nil
+	}
+}
+
+// valueRequiresVersion rejects a value of an attribute on servers older than since
+func valueRequiresVersion(since, attribute string, value interface{}) versionCheck {
+	return func(d *schema.ResourceDiff, serverVersion *version.Version) error {
+		if versionAtLeast(serverVersion, since) {
+			return nil
+		}
+		for _, v := range diffValues(d, attribute) {
+			if v == value {
+				return fmt.Errorf("%s %v requires Zabbix %s or later, the server runs Zabbix %s", attribute, value, since, serverVersion)
+			}
+		}
+		return nil
+	}
+}
+
+// valueRemovedIn rejects a value of an attribute on servers that removed it
+func valueRemovedIn(removed, attribute string, value interface{}) versionCheck {
+	return func(d *schema.ResourceDiff, serverVersion *version.Version) error {
+		if !versionAtLeast(serverVersion, removed) {
+			return nil
+		}
+		for _, v := range diffValues(d, attribute) {
+			if v == value {
+				return fmt.Errorf("%s %v was removed in Zabbix %s, the server runs Zabbix %s", attribute, value, removed, serverVersion)
+			}
+		}
+		return nil
+	}
+}
+
+// requiredSince rejects an empty attribute on servers from version since
+func requiredSince(since, attribute string) versionCheck {
+	return func(d *schema.ResourceDiff, serverVersion *version.Version) error {
+		if !versionAtLeast(serverVersion, since) {
+			return nil
+		}
+		for _, value := range diffValues(d, attribute) {
+			if value == "" {
+				return fmt.Errorf("%s is required from Zabbix %s, the server runs Zabbix %s", attribute, since, serverVersion)
+			}
+		}
+		return nil
+	}
+}
+
+// resourceRequiresVersion rejects a resource on servers older than since
+func resourceRequiresVersion(since, resource string) versionCheck {
+	return func(d *schema.ResourceDiff, serverVersion *version.Version) error {
+		if !versionAtLeast(serverVersion, since) {
+			return fmt.Errorf("%s requires Zabbix %s or later, the server runs Zabbix %s", resource, since, serverVersion)
+		}
+		return nil
+	}
+}
+
+var (
+	macroDurationPattern   = regexp.MustCompile(`^\{[$#][^}]+\}$`)
+	secondsDurationPattern = regexp.MustCompile(`^\d+$`)
+)
+
+// serverDurationUnits returns the time suffixes the server accepts, from getZabbixServerUnit* for its version
+func serverDurationUnits(serverVersion *version.Version) string {
+	v := serverVersion.String()
+	return getZabbixServerUnitSeconds(v) + getZabbixServerUnitMinutes(v) + getZabbixServerUnitHours(v) +
+		getZabbixServerUnitDays(v) + getZabbixServerUnitWeeks(v)
+}
+
+// durations rejects the durations that the server would not accept. Only the update interval of flexible or
+// scheduling intervals is checked, and macros are left to the server.
+func durations(attributes ...string) versionCheck {
+	return func(d *schema.ResourceDiff, serverVersion *version.Version) error {
+		units := serverDurationUnits(serverVersion)
+		pattern, expected := secondsDurationPattern, "a number of seconds"
+		if units != "" {
+			pattern = regexp.MustCompile(`^\d+[` + units + `]?$`)
+			expected = "a number optionally followed by one of the units " + strings.Join(strings.Split(units, ""), ", ")
+		}
+
+		for _, attribute := range attributes {
+			for _, value := range diffValues(d, attribute) {
+				duration, _ := value.(string)
+				duration = strings.SplitN(duration, ";", 2)[0]
+				if duration != "" && !macroDurationPattern.MatchString(duration) && !pattern.MatchString(duration) {
+					return fmt.Errorf("%s: %q is not a valid duration for Zabbix %s, which expects %s", attribute, duration, serverVersion, expected)
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// validateStoragePeriod warns about the storage periods given as a number, which all the supported versions of
+// Zabbix read as seconds rather than days
+func validateStoragePeriod(value interface{}, path cty.Path) diag.Diagnostics {
+	duration, _ := value.(string)
+	if duration == "0" || !secondsDurationPattern.MatchString(duration) {
+		return nil
+	}
+	return diag.Diagnostics{{
+		Severity:      diag.Warning,
+		Summary:       fmt.Sprintf("%q is a number of seconds", duration),
+		Detail:        fmt.Sprintf("Zabbix reads the storage periods given as a number as seconds, use %sd for %s days.", duration, duration),
+		AttributePath: path,
+	}}
+}
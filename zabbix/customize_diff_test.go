@@ -0,0 +1,146 @@
+package zabbix
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/claranet/go-zabbix-api"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestCustomizeDiffVersion(t *testing.T) {
+	cases := []struct {
+		name     string
+		resource func() *schema.Resource
+		version  string
+		config   map[string]interface{}
+		// expected matches the error of the plan, which must succeed when empty
+		expected string
+	}{
+		{"item data_type", resourceZabbixItem, "3.4.0",
+			map[string]interface{}{"host_id": "1", "key": "key", "name": "item", "data_type": 1},
+			`data_type was removed in Zabbix 3.4`},
+		{"item durations", resourceZabbixItem, "6.0.0",
+			map[string]interface{}{"host_id": "1", "key": "key", "name": "item", "delay": "30s;10s/1-5,09:00-18:00", "history": "90d", "trends": "{$TRENDS}"},
+			``},
+		{"item history days", resourceZabbixItem, "6.0.0",
+			map[string]interface{}{"host_id": "1", "key": "key", "name": "item", "history": "90 days"},
+			`history: "90 days" is not a valid duration for Zabbix 6.0.0, which expects a number optionally followed by one of the units s, m, h, d, w`},
+		{"item prototype durations", resourceZabbixItemPrototype, "6.0.0",
+			map[string]interface{}{"host_id": "1", "rule_id": "2", "key": "key[{#NAME}]", "name": "prototype", "delay": "{#DELAY}", "trends": "1y"},
+			`trends: "1y" is not a valid duration`},
+		{"lld rule delay", resourceZabbixLLDRule, "5.0.0",
+			map[string]interface{}{"host_id": "1", "key": "key", "name": "rule", "delay": "1 hour"},
+			`delay: "1 hour" is not a valid duration`},
+		{"lld rule macro paths", resourceZabbixLLDRule, "4.0.0",
+			map[string]interface{}{"host_id": "1", "key": "key", "name": "rule", "delay": "1h", "lld_macro_paths": map[string]interface{}{"{#NAME}": "$.name"}},
+			`lld_macro_paths requires Zabbix 4.2 or later`},
+		{"lld rule without override", resourceZabbixLLDRule, "4.2.0",
+			map[string]interface{}{"host_id": "1", "key": "key", "name": "rule", "delay": "1h", "lld_macro_paths": map[string]interface{}{"{#NAME}": "$.name"}},
+			``},
+		{"action default subject before 5.0", resourceZabbixAction, "4.0.0",
+			map[string]interface{}{"name": "action", "event_source": "trigger", "default_subject": "Problem"},
+			``},
+		{"action default subject", resourceZabbixAction, "5.0.0",
+			map[string]interface{}{"name": "action", "event_source": "trigger", "default_subject": "Problem"},
+			`default_subject was removed in Zabbix 5.0`},
+		{"action application condition", resourceZabbixAction, "5.4.0",
+			map[string]interface{}{"name": "action", "event_source": "trigger", "condition": []interface{}{
+				map[string]interface{}{"type": "application", "value": "CPU"},
+			}},
+			`condition.\*.type application was removed in Zabbix 5.4`},
+		{"action step duration", resourceZabbixAction, "6.0.0",
+			map[string]interface{}{"name": "action", "event_source": "trigger", "default_step_duration": "1 hour"},
+			`default_step_duration: "1 hour" is not a valid duration`},
+		{"action operation calculation", resourceZabbixAction, "6.0.0",
+			map[string]interface{}{"name": "action", "event_source": "trigger", "operation": []interface{}{
+				map[string]interface{}{"type": "send_message", "calculation": "and"},
+			}},
+			`operation 1: calculation and requires conditions`},
+		{"user macro description", resourceZabbixUserMacro, "4.0.0",
+			map[string]interface{}{"host_id": "1", "name": "A", "value": "1", "description": "Macro"},
+			`description requires Zabbix 4.4 or later, the server runs Zabbix 4.0.0`},
+		{"global macro type", resourceZabbixGlobalMacro, "4.4.0",
+			map[string]interface{}{"name": "A", "value": "1", "description": "Macro", "type": "secret"},
+			`type requires Zabbix 5.0 or later`},
+		{"host macro type", resourceZabbixHost, "4.4.0",
+			map[string]interface{}{"host": "host", "groups": []interface{}{"group"}, "macro": []interface{}{
+				map[string]interface{}{"name": "A", "value": "1", "type": "secret"},
+			}},
+			`macro.\*.type requires Zabbix 5.0 or later`},
+		{"template macros", resourceZabbixTemplate, "5.0.0",
+			map[string]interface{}{"host": "template", "groups": []interface{}{"group"}, "macro": []interface{}{
+				map[string]interface{}{"name": "A", "value": "1", "type": "secret", "description": "Macro"},
+			}},
+			``},
+		{"global value map", resourceZabbixValueMap, "5.4.0",
+			map[string]interface{}{"name": "map", "mapping": []interface{}{map[string]interface{}{"value": "1", "new_value": "up"}}},
+			`host_id is required from Zabbix 5.4`},
+		{"host value map", resourceZabbixValueMap, "5.0.0",
+			map[string]interface{}{"name": "map", "host_id": "1", "mapping": []interface{}{map[string]interface{}{"value": "1", "new_value": "up"}}},
+			`host_id requires Zabbix 5.4 or later`},
+		{"value map mapping type", resourceZabbixValueMap, "5.4.0",
+			map[string]interface{}{"name": "map", "host_id": "1", "mapping": []interface{}{map[string]interface{}{"value": "1", "new_value": "up", "type": "regexp"}}},
+			`mapping.\*.type requires Zabbix 6.0 or later`},
+		{"template group", resourceZabbixTemplateGroup, "6.0.0",
+			map[string]interface{}{"name": "group"},
+			`zabbix_template_group requires Zabbix 6.2 or later`},
+		{"template group 6.2", resourceZabbixTemplateGroup, "6.2.0",
+			map[string]interface{}{"name": "group"},
+			``},
+		{"yaml import", resourceZabbixConfigurationImport, "5.0.0",
+			map[string]interface{}{"source": "{}", "rules": []interface{}{map[string]interface{}{}}},
+			`format yaml requires Zabbix 5.2 or later`},
+		{"json import", resourceZabbixConfigurationImport, "5.0.0",
+			map[string]interface{}{"format": "json", "source": "{}", "rules": []interface{}{map[string]interface{}{}}},
+			``},
+		{"trigger legacy expression check", resourceZabbixTrigger, "6.0.0",
+			map[string]interface{}{"description": "trigger", "expression": "{host:key.last()}>5", "expression_syntax": "check"},
+			`expression: "\{host:key.last\(\)\}>5" must be migrated to the syntax of Zabbix 6.0.0: last\(/host/key\)>5`},
+		{"trigger expression check", resourceZabbixTrigger, "6.0.0",
+			map[string]interface{}{"description": "trigger", "expression": "last(/host/key)>5", "expression_syntax": "check"},
+			``},
+		{"trigger legacy expression auto", resourceZabbixTrigger, "6.0.0",
+			map[string]interface{}{"description": "trigger", "expression": "{host:key.last()}>5", "expression_syntax": "auto"},
+			``},
+		{"trigger prototype expression auto", resourceZabbixTriggerPrototype, "5.0.0",
+			map[string]interface{}{"description": "prototype", "expression": "avg(/host/key[{#NAME}],1h:now/h)>5", "expression_syntax": "auto"},
+			`cannot be translated to the syntax of Zabbix 5.0.0: the period 1h:now/h has no equivalent before Zabbix 5.4`},
+		{"service increase", resourceZabbixService, "6.0.0",
+			map[string]interface{}{"name": "service", "propagation_rule": "increase"},
+			`propagation_value must be between 1 and 5 to increase the status, got 0`},
+		{"service as is", resourceZabbixService, "6.0.0",
+			map[string]interface{}{"name": "service", "propagation_value": 2},
+			`propagation_value must be 0 when propagation_rule is as_is, got 2`},
+		{"service fixed", resourceZabbixService, "6.0.0",
+			map[string]interface{}{"name": "service", "propagation_rule": "fixed", "propagation_value": -1},
+			``},
+	}
+
+	for _, c := range cases {
+		api := &zabbixClient{API: &zabbix.API{ServerVersion: version.Must(version.NewVersion(c.version))}}
+		_, err := c.resource().Diff(context.Background(), nil, terraform.NewResourceConfigRaw(c.config), api)
+		switch {
+		case c.expected == "" && err != nil:
+			t.Errorf("%s: expected the plan to succeed, got %s", c.name, err)
+		case c.expected != "" && err == nil:
+			t.Errorf("%s: expected the plan to fail with %s", c.name, c.expected)
+		case c.expected != "" && !regexp.MustCompile(c.expected).MatchString(err.Error()):
+			t.Errorf("%s: expected the plan to fail with %s, got %s", c.name, c.expected, err)
+		}
+	}
+}
+
+func TestValidateStoragePeriod(t *testing.T) {
+	for value, warns := range map[string]bool{"90": true, "0": false, "90d": false, "{$HISTORY}": false} {
+		diags := validateStoragePeriod(value, cty.GetAttrPath("history"))
+		if got := len(diags) == 1 && diags[0].Severity == diag.Warning; got != warns {
+			t.Errorf("%q: expected a warning %t, got %v", value, warns, diags)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package zabbix
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/claranet/go-zabbix-api"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+var configurationExportIDs = []string{
+	"template_ids",
+	"host_ids",
+	"host_group_ids",
+	"template_group_ids",
+	"map_ids",
+	"image_ids",
+	"media_type_ids",
+}
+
+func dataSourceZabbixConfigurationExport() *schema.Resource {
+	ids := map[string]string{
+		"template_ids":       "IDs of the templates to export.",
+		"host_ids":           "IDs of the hosts to export.",
+		"host_group_ids":     "IDs of the host groups to export.",
+		"template_group_ids": "IDs of the template groups to export. Requires Zabbix 6.2 or later.",
+		"map_ids":            "IDs of the maps to export.",
+		"image_ids":          "IDs of the images to export.",
+		"media_type_ids":     "IDs of the media types to export.",
+	}
+
+	dataSourceSchema := map[string]*schema.Schema{
+		"format": &schema.Schema{
+			Type:     schema.TypeString,
+			Optional: true,
+			Default:  "yaml",
+			ValidateFunc: validation.StringInSlice(
+				[]string{"yaml", "xml", "json"},
+				false,
+			),
+			Description: "Format of the export. yaml requires Zabbix 5.2 or later.",
+		},
+		"export": &schema.Schema{
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "Serialized configuration returned by the Zabbix server.",
+		},
+		"hash": &schema.Schema{
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "SHA-256 of the exported configuration, the export date is ignored.",
+		},
+	}
+	for key, description := range ids {
+		dataSourceSchema[key] = &schema.Schema{
+			Type:         schema.TypeSet,
+			Elem:         &schema.Schema{Type: schema.TypeString},
+			Optional:     true,
+			AtLeastOneOf: configurationExportIDs,
+			Description:  description,
+		}
+	}
+
+	return &schema.Resource{
+		ReadContext: dataSourceZabbixConfigurationExportRead,
+		Schema:      dataSourceSchema,
+	}
+}
+
+func dataSourceZabbixConfigurationExportRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
+
+	format := d.Get("format").(string)
+	if format == "yaml" && api.ServerVersion.LessThan(version.Must(version.NewVersion("5.2"))) {
+		return diag.Errorf("Exporting yaml requires Zabbix 5.2 or later")
+	}
+
+	options, err := createConfigurationExportOptions(d, api)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	export, err := exportConfiguration(api, format, options)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	hash, err := configurationHash(format, export)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(hash)
+	d.Set("export", export)
+	d.Set("hash", hash)
+	return nil
+}
+
+func createConfigurationExportOptions(d *schema.ResourceData, api *zabbixClient) (zabbix.Params, error) {
+	groups := api.ServerVersion.GreaterThanOrEqual(version.Must(version.NewVersion("6.2")))
+
+	hostGroups := "host_groups"
+	if !groups {
+		hostGroups = "groups"
+	}
+	optionNames := map[string]string{
+		"template_ids":       "templates",
+		"host_ids":           "hosts",
+		"host_group_ids":     hostGroups,
+		"template_group_ids": "template_groups",
+		"map_ids":            "maps",
+		"image_ids":          "images",
+		"media_type_ids":     "mediaTypes",
+	}
+
+	options := zabbix.Params{}
+	for _, key := range configurationExportIDs {
+		ids := d.Get(key).(*schema.Set).List()
+		if len(ids) == 0 {
+			continue
+		}
+		if key == "template_group_ids" && !groups {
+			return nil, fmt.Errorf("Exporting template groups requires Zabbix 6.2 or later")
+		}
+		options[optionNames[key]] = ids
+	}
+	return options, nil
+}
+
+// configurationHash hashes the parsed export without its date so that exporting the same objects twice
+// gives the same hash
+func configurationHash(format, export string) (string, error) {
+	config, err := parseConfiguration(format, export)
+	if err != nil {
+		return "", err
+	}
+	delete(config, "date")
+
+	content, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
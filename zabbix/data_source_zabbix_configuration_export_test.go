@@ -0,0 +1,75 @@
+package zabbix
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccZabbixDataSourceConfigurationExport_basic(t *testing.T) {
+	strID := acctest.RandString(5)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t); testAccPreCheckRealServer(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccZabbixDataSourceConfigurationExportConfig(strID),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.zabbix_configuration_export.test", "format", "json"),
+					resource.TestMatchResourceAttr("data.zabbix_configuration_export.test", "export", regexp.MustCompile(fmt.Sprintf("template_%s", strID))),
+					resource.TestMatchResourceAttr("data.zabbix_configuration_export.test", "hash", regexp.MustCompile("^[0-9a-f]{64}$")),
+					resource.TestCheckResourceAttrPair("data.zabbix_configuration_export.test", "hash", "data.zabbix_configuration_export.test_again", "hash"),
+				),
+			},
+		},
+	})
+}
+
+func TestConfigurationHash(t *testing.T) {
+	first, err := configurationHash("yaml", "zabbix_export:\n  version: '4.0'\n  date: '2020-01-01T00:00:00Z'\n  templates:\n    - template: a\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := configurationHash("yaml", "zabbix_export:\n  version: '4.0'\n  date: '2021-06-01T12:00:00Z'\n  templates:\n    - template: a\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Fatalf("Expected the same hash for exports differing by their date, got %s and %s", first, second)
+	}
+
+	third, err := configurationHash("yaml", "zabbix_export:\n  version: '4.0'\n  templates:\n    - template: b\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == third {
+		t.Fatalf("Expected different hashes for different exports")
+	}
+}
+
+func testAccZabbixDataSourceConfigurationExportConfig(strID string) string {
+	return fmt.Sprintf(`
+		resource "zabbix_template_group" "template_group_test" {
+			name = "template_group_%[1]s"
+		}
+
+		resource "zabbix_template" "template_test" {
+			host   = "template_%[1]s"
+			groups = [zabbix_template_group.template_group_test.name]
+		}
+
+		data "zabbix_configuration_export" "test" {
+			format       = "json"
+			template_ids = [zabbix_template.template_test.id]
+		}
+
+		data "zabbix_configuration_export" "test_again" {
+			format       = "json"
+			template_ids = [zabbix_template.template_test.id]
+		}
+	`, strID)
+}
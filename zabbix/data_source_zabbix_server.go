@@ -1,17 +1,19 @@
 package zabbix
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/mcuadros/go-version"
 )
 
 func dataSourceZabbixServer() *schema.Resource {
 	return &schema.Resource{
-		Read: dataSourceZabbixServerRead,
+		ReadContext: dataSourceZabbixServerRead,
 		Schema: map[string]*schema.Schema{
 			"server_version": &schema.Schema{
 				Type:        schema.TypeString,
@@ -74,7 +76,7 @@ func dataSourceZabbixServer() *schema.Resource {
 	}
 }
 
-func dataSourceZabbixServerRead(d *schema.ResourceData, meta interface{}) (err error) {
+func dataSourceZabbixServerRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	var serverVersion string
 	if v, ok := d.GetOkExists("server_version"); ok {
 		serverVersion = v.(string)
@@ -82,7 +84,7 @@ func dataSourceZabbixServerRead(d *schema.ResourceData, meta interface{}) (err e
 	} else {
 		serverVersion = getZabbixServerVersion(meta)
 		if serverVersion == "" {
-			return fmt.Errorf("Failed to get Zabbix Server version")
+			return diag.Errorf("Failed to get Zabbix Server version")
 		}
 
 		log.Printf("[DEBUG] Actual Zabbix Server version is %s\n", serverVersion)
@@ -0,0 +1,163 @@
+package zabbix
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/claranet/go-zabbix-api"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// slaSLI is the result of sla.getsli, the SLI of each service by reporting period. Zabbix returns numbers, read
+// as json.Number to accept strings as well.
+type slaSLI struct {
+	Periods []struct {
+		PeriodFrom json.Number `json:"period_from"`
+		PeriodTo   json.Number `json:"period_to"`
+	} `json:"periods"`
+	ServiceIDs []json.Number `json:"serviceids"`
+	SLI        [][]struct {
+		Uptime      json.Number `json:"uptime"`
+		Downtime    json.Number `json:"downtime"`
+		SLI         json.Number `json:"sli"`
+		ErrorBudget json.Number `json:"error_budget"`
+	} `json:"sli"`
+}
+
+func dataSourceZabbixSLASLI() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceZabbixSLASLIRead,
+		Schema: map[string]*schema.Schema{
+			"sla_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "ID of the SLA.",
+			},
+			"period_from": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Unix timestamp of the start of the reporting periods to return.",
+			},
+			"period_to": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Unix timestamp of the end of the reporting periods to return.",
+			},
+			"periods": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntBetween(1, 100),
+				Description:  "Number of reporting periods to return, counted from period_from or back from period_to. Zabbix returns up to 20 by default.",
+			},
+			"service_ids": &schema.Schema{
+				Type:        schema.TypeSet,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Optional:    true,
+				Description: "IDs of the services to return, all the services of the SLA when not given.",
+			},
+			"sli": &schema.Schema{
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "SLI of each service by reporting period, ordered by period then service.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"period_from": &schema.Schema{
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"period_to": &schema.Schema{
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"service_id": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"uptime": &schema.Schema{
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Seconds the service was up during the scheduled time of the period.",
+						},
+						"downtime": &schema.Schema{
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Seconds the service was down during the scheduled time of the period.",
+						},
+						"sli": &schema.Schema{
+							Type:        schema.TypeFloat,
+							Computed:    true,
+							Description: "Service level indicator, the percentage of uptime.",
+						},
+						"error_budget": &schema.Schema{
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "Seconds of downtime left before the SLI falls below the SLO, negative once it did.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceZabbixSLASLIRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
+
+	if !versionAtLeast(api.ServerVersion, "6.0") {
+		return diag.Errorf("zabbix_sla_sli requires Zabbix 6.0 or later, the server runs Zabbix %s", api.ServerVersion)
+	}
+
+	slaID := d.Get("sla_id").(string)
+	params := zabbix.Params{"slaid": slaID}
+	for _, argument := range []string{"period_from", "period_to", "periods"} {
+		if v, ok := d.GetOk(argument); ok {
+			params[argument] = v.(int)
+		}
+	}
+	if v, ok := d.GetOk("service_ids"); ok {
+		params["serviceids"] = v.(*schema.Set).List()
+	}
+
+	var result slaSLI
+	if err := api.CallWithErrorParse("sla.getsli", params, &result); err != nil {
+		return diag.FromErr(err)
+	}
+
+	var lst []interface{}
+	for i, period := range result.Periods {
+		from, _ := period.PeriodFrom.Int64()
+		to, _ := period.PeriodTo.Int64()
+		for j, serviceID := range result.ServiceIDs {
+			if i >= len(result.SLI) || j >= len(result.SLI[i]) {
+				return diag.Errorf("sla.getsli returned no SLI for service %s in period %d-%d", serviceID, from, to)
+			}
+			sli := result.SLI[i][j]
+			uptime, _ := sli.Uptime.Int64()
+			downtime, _ := sli.Downtime.Int64()
+			value, _ := sli.SLI.Float64()
+			errorBudget, _ := sli.ErrorBudget.Int64()
+			lst = append(lst, map[string]interface{}{
+				"period_from":  int(from),
+				"period_to":    int(to),
+				"service_id":   serviceID.String(),
+				"uptime":       int(uptime),
+				"downtime":     int(downtime),
+				"sli":          value,
+				"error_budget": int(errorBudget),
+			})
+		}
+	}
+
+	id := slaID
+	if len(result.Periods) > 0 {
+		id = fmt.Sprintf("%s_%s_%s", slaID, result.Periods[0].PeriodFrom, result.Periods[len(result.Periods)-1].PeriodTo)
+	}
+	d.SetId(id)
+	d.Set("sli", lst)
+	return nil
+}
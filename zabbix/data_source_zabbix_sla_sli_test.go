@@ -0,0 +1,103 @@
+package zabbix
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccZabbixDataSourceSLASLI_basic(t *testing.T) {
+	name := fmt.Sprintf("sla_%s", acctest.RandString(5))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccZabbixDataSourceSLASLIConfig(name),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrPair("data.zabbix_sla_sli.sli", "sla_id", "zabbix_sla.sla", "id"),
+					resource.TestCheckResourceAttr("data.zabbix_sla_sli.sli", "sli.#", "1"),
+					resource.TestCheckResourceAttrPair("data.zabbix_sla_sli.sli", "sli.0.service_id", "zabbix_service.service", "id"),
+					resource.TestCheckResourceAttrSet("data.zabbix_sla_sli.sli", "sli.0.sli"),
+				),
+			},
+		},
+	})
+}
+
+func TestSLASLI(t *testing.T) {
+	s, api := newFakeZabbixClient(t, "6.0.0")
+
+	tag := []interface{}{map[string]interface{}{"tag": "sla", "value": "shop"}}
+	service := testApplyResource(t, "service", resourceZabbixService(), api, nil, map[string]interface{}{"name": "Shop", "tag": tag})
+	testApplyResource(t, "service", resourceZabbixService(), api, nil, map[string]interface{}{"name": "Other"})
+	sla := testApplyResource(t, "sla", resourceZabbixSLA(), api, nil, map[string]interface{}{"name": "Shop", "slo": 99, "service_tag": tag})
+
+	d := dataSourceZabbixSLASLI().TestResourceData()
+	d.Set("sla_id", sla.ID)
+	d.Set("period_from", 1767225600)
+	d.Set("period_to", 1767312000)
+	if diags := dataSourceZabbixSLASLIRead(context.Background(), d, api); diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	lst := d.Get("sli").([]interface{})
+	if len(lst) != 1 {
+		t.Fatalf("expected the SLI of the tagged service, got %v", lst)
+	}
+	sli := lst[0].(map[string]interface{})
+	expected := map[string]interface{}{
+		"period_from":  1767225600,
+		"period_to":    1767312000,
+		"service_id":   service.ID,
+		"uptime":       86400,
+		"downtime":     0,
+		"sli":          100.0,
+		"error_budget": 864,
+	}
+	for key, value := range expected {
+		if sli[key] != value {
+			t.Errorf("expected %s to be %v, got %v", key, value, sli[key])
+		}
+	}
+	if id := fmt.Sprintf("%s_1767225600_1767312000", sla.ID); d.Id() != id {
+		t.Errorf("expected the ID %s, got %s", id, d.Id())
+	}
+
+	// SLAs are unknown before Zabbix 6.0
+	old, oldAPI := newFakeZabbixClient(t, "5.4.0")
+	if diags := dataSourceZabbixSLASLIRead(context.Background(), d, oldAPI); !diags.HasError() {
+		t.Errorf("expected the SLI to require Zabbix 6.0")
+	}
+}
+
+func testAccZabbixDataSourceSLASLIConfig(name string) string {
+	return fmt.Sprintf(`
+		resource "zabbix_service" "service" {
+			name = "%s"
+
+			tag {
+				tag   = "sla"
+				value = "%s"
+			}
+		}
+
+		resource "zabbix_sla" "sla" {
+			name = "%s"
+			slo  = 99.9
+
+			service_tag {
+				tag   = "sla"
+				value = "%s"
+			}
+		}
+
+		data "zabbix_sla_sli" "sli" {
+			sla_id      = zabbix_sla.sla.id
+			service_ids = [zabbix_service.service.id]
+		}`, name, name, name, name)
+}
@@ -0,0 +1,1588 @@
+package zabbix
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/claranet/go-zabbix-api"
+	"github.com/hashicorp/go-version"
+)
+
+const (
+	fakeZabbixUser     = "Admin"
+	fakeZabbixPassword = "zabbix"
+)
+
+// fakeObjectType describes how the fake server stores one kind of Zabbix object
+type fakeObjectType struct {
+	// idField is the property holding the ID of the objects
+	idField string
+	// createKey and deleteKey list the IDs in the results of the create/update and delete methods
+	createKey string
+	deleteKey string
+	// since is the first version of Zabbix knowing the object
+	since string
+}
+
+var fakeObjectTypes = map[string]fakeObjectType{
+	"host":             {idField: "hostid", createKey: "hostids", deleteKey: "hostids"},
+	"template":         {idField: "templateid", createKey: "templateids", deleteKey: "templateids"},
+	"hostgroup":        {idField: "groupid", createKey: "groupids", deleteKey: "groupids"},
+	"templategroup":    {idField: "groupid", createKey: "groupids", deleteKey: "groupids", since: "6.2"},
+	"item":             {idField: "itemid", createKey: "itemids", deleteKey: "itemids"},
+	"discoveryrule":    {idField: "itemid", createKey: "itemids", deleteKey: "ruleids"},
+	"itemprototype":    {idField: "itemid", createKey: "itemids", deleteKey: "prototypeids"},
+	"trigger":          {idField: "triggerid", createKey: "triggerids", deleteKey: "triggerids"},
+	"triggerprototype": {idField: "triggerid", createKey: "triggerids", deleteKey: "triggerids"},
+	"action":           {idField: "actionid", createKey: "actionids", deleteKey: "actionids"},
+	"usermacro":        {idField: "hostmacroid", createKey: "hostmacroids", deleteKey: "hostmacroids"},
+	"globalmacro":      {idField: "globalmacroid", createKey: "globalmacroids", deleteKey: "globalmacroids"},
+	"valuemap":         {idField: "valuemapid", createKey: "valuemapids", deleteKey: "valuemapids"},
+	"user":             {idField: "userid", createKey: "userids", deleteKey: "userids"},
+	"usergroup":        {idField: "usrgrpid", createKey: "usrgrpids", deleteKey: "usrgrpids"},
+	"mediatype":        {idField: "mediatypeid", createKey: "mediatypeids", deleteKey: "mediatypeids"},
+	"script":           {idField: "scriptid", createKey: "scriptids", deleteKey: "scriptids"},
+	"proxy":            {idField: "proxyid", createKey: "proxyids", deleteKey: "proxyids"},
+	"drule":            {idField: "druleid", createKey: "druleids", deleteKey: "druleids"},
+	"dcheck":           {idField: "dcheckid"},
+	"service":          {idField: "serviceid", createKey: "serviceids", deleteKey: "serviceids", since: "6.0"},
+	"sla":              {idField: "slaid", createKey: "slaids", deleteKey: "slaids", since: "6.0"},
+}
+
+// fakeSinceProperties lists the properties that Zabbix only knows from a given version, by object type.
+// Mappings are the mappings of value maps, interfaces the interfaces of hosts.
+var fakeSinceProperties = map[string]map[string]string{
+	"host":        {"proxyid": "7.0"},
+	"interface":   {"details": "5.0"},
+	"usermacro":   {"description": "4.4", "type": "5.0"},
+	"globalmacro": {"description": "4.4", "type": "5.0"},
+	"valuemap":    {"hostid": "5.4"},
+	"mapping":     {"type": "6.0"},
+}
+
+// fakeUntilProperties lists the properties that Zabbix removed in a given version, by object type
+var fakeUntilProperties = map[string]map[string]string{
+	"host":          {"proxy_hostid": "7.0"},
+	"interface":     {"bulk": "5.0"},
+	"item":          {"applications": "5.4"},
+	"itemprototype": {"applications": "5.4"},
+	"action": {
+		"def_shortdata": "5.0", "def_longdata": "5.0", "r_shortdata": "5.0", "r_longdata": "5.0",
+		"ack_shortdata": "5.0", "ack_longdata": "5.0", "acknowledge_operations": "6.0",
+	},
+}
+
+// fakeDefaults are the values of the properties omitted when an object is created
+var fakeDefaults = map[string]map[string]string{
+	"host":             {"status": "0", "proxy_hostid": "0", "proxyid": "0"},
+	"item":             {"status": "0"},
+	"discoveryrule":    {"status": "0"},
+	"itemprototype":    {"status": "0"},
+	"trigger":          {"status": "0", "priority": "0"},
+	"triggerprototype": {"status": "0", "priority": "0"},
+	"action":           {"status": "0"},
+	"usermacro":        {"description": "", "type": "0"},
+	"globalmacro":      {"description": "", "type": "0"},
+	"mapping":          {"type": "0"},
+}
+
+// fakeObject is a Zabbix object as returned by the API, every scalar is a string
+type fakeObject map[string]interface{}
+
+// fakeRequest is a JSON-RPC request received by the fake server
+type fakeRequest struct {
+	Method string
+	Params interface{}
+	// Bearer is whether the session was sent in the Authorization header
+	Bearer bool
+}
+
+// fakeError is the error member of a JSON-RPC response
+type fakeError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    string `json:"data"`
+}
+
+func (e *fakeError) Error() string {
+	return fmt.Sprintf("%d (%s): %s", e.Code, e.Message, e.Data)
+}
+
+func invalidParams(format string, args ...interface{}) *fakeError {
+	return &fakeError{Code: -32602, Message: "Invalid params.", Data: fmt.Sprintf(format, args...)}
+}
+
+// fakeZabbixServer is an in-process Zabbix JSON-RPC API keeping its objects in memory, so that resources can be
+// tested with plain go test. It implements the methods and parameters used by the provider, not the whole API.
+type fakeZabbixServer struct {
+	*httptest.Server
+	version *version.Version
+
+	mu       sync.Mutex
+	lastID   int
+	objects  map[string][]fakeObject
+	requests []fakeRequest
+}
+
+// newFakeZabbixServer starts a fake server reporting the given version, with the user, groups and media types a
+// fresh Zabbix installation comes with
+func newFakeZabbixServer(serverVersion string) *fakeZabbixServer {
+	s := &fakeZabbixServer{
+		version: version.Must(version.NewVersion(serverVersion)),
+		objects: map[string][]fakeObject{},
+		lastID:  10000,
+	}
+
+	userName := "alias"
+	if s.atLeast("5.4") {
+		userName = "username"
+	}
+	s.objects["usergroup"] = []fakeObject{
+		{"usrgrpid": "7", "name": "Zabbix administrators"},
+		{"usrgrpid": "8", "name": "Guests"},
+	}
+	s.objects["user"] = []fakeObject{
+		{"userid": "1", userName: fakeZabbixUser, "name": "Zabbix", "surname": "Administrator", "usrgrps": []interface{}{fakeObject{"usrgrpid": "7"}}},
+		{"userid": "2", userName: "guest", "name": "", "surname": "", "usrgrps": []interface{}{fakeObject{"usrgrpid": "8"}}},
+	}
+	s.objects["hostgroup"] = []fakeObject{
+		{"groupid": "2", "name": "Linux servers"},
+		{"groupid": "4", "name": "Zabbix servers"},
+		{"groupid": "5", "name": "Discovered hosts"},
+	}
+	if s.atLeast("6.2") {
+		s.objects["templategroup"] = []fakeObject{{"groupid": "1", "name": "Templates"}}
+	} else {
+		s.objects["hostgroup"] = append(s.objects["hostgroup"], fakeObject{"groupid": "1", "name": "Templates"})
+	}
+	// Media types were named by their description before Zabbix 4.4
+	mediaTypeName := "description"
+	if s.atLeast("4.4") {
+		mediaTypeName = "name"
+	}
+	s.objects["mediatype"] = []fakeObject{{"mediatypeid": "1", mediaTypeName: "Email", "type": "0"}}
+	s.objects["script"] = []fakeObject{{"scriptid": "1", "name": "Ping", "command": "ping -c 3 {HOST.CONN}"}}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// newFakeZabbixClient starts a fake server reporting the given version, closed with the test, and returns it with a
+// client logged in to it
+func newFakeZabbixClient(t *testing.T, serverVersion string) (*fakeZabbixServer, *zabbixClient) {
+	t.Helper()
+	s := newFakeZabbixServer(serverVersion)
+	t.Cleanup(s.Close)
+
+	api, err := newZabbixClient(s.URL+"/api_jsonrpc.php", fakeZabbixUser, fakeZabbixPassword, "test")
+	if err != nil {
+		t.Fatalf("%s: %s", serverVersion, err)
+	}
+	return s, api
+}
+
+func (s *fakeZabbixServer) atLeast(v string) bool {
+	return s.version.GreaterThanOrEqual(version.Must(version.NewVersion(v)))
+}
+
+// requestsFrom returns the requests received after the first n ones
+func (s *fakeZabbixServer) requestsFrom(n int) []fakeRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]fakeRequest(nil), s.requests[n:]...)
+}
+
+// requestCount returns the number of requests received so far
+func (s *fakeZabbixServer) requestCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.requests)
+}
+
+// calls returns the parameters of the requests received for method, in order
+func (s *fakeZabbixServer) calls(method string) []interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var params []interface{}
+	for _, request := range s.requests {
+		if request.Method == method {
+			params = append(params, request.Params)
+		}
+	}
+	return params
+}
+
+func (s *fakeZabbixServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		JSONRPC string          `json:"jsonrpc"`
+		Method  string          `json:"method"`
+		Params  json.RawMessage `json:"params"`
+		Auth    string          `json:"auth"`
+		ID      interface{}     `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var params interface{}
+	if len(request.Params) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(request.Params))
+		decoder.UseNumber()
+		if err := decoder.Decode(&params); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	auth := request.Auth
+	bearer, hasBearer := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if hasBearer {
+		auth = bearer
+	}
+
+	s.mu.Lock()
+	method := strings.ToLower(request.Method)
+	s.requests = append(s.requests, fakeRequest{Method: method, Params: params, Bearer: hasBearer})
+	result, err := s.call(method, auth, normalizeFakeValue(params))
+	s.mu.Unlock()
+
+	response := map[string]interface{}{"jsonrpc": "2.0", "id": request.ID}
+	if err != nil {
+		response["error"] = err
+	} else {
+		response["result"] = result
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func (s *fakeZabbixServer) call(method, auth string, params interface{}) (interface{}, *fakeError) {
+	switch method {
+	case "apiinfo.version":
+		if auth != "" {
+			return nil, invalidParams(`The "apiinfo.version" method must be called without the "auth" parameter.`)
+		}
+		return s.version.String(), nil
+	case "user.login":
+		return s.login(params)
+	case "user.logout":
+		return true, nil
+	}
+
+	if auth != "fake-session" {
+		return nil, &fakeError{Code: -32602, Message: "Invalid params.", Data: "Session terminated, re-login, please."}
+	}
+
+	objectType, operation := method, ""
+	if i := strings.Index(method, "."); i > 0 {
+		objectType, operation = method[:i], method[i+1:]
+	}
+	if objectType == "usermacro" && strings.HasSuffix(operation, "global") {
+		objectType, operation = "globalmacro", strings.TrimSuffix(operation, "global")
+	}
+	if objectType == "usermacro" && operation == "get" {
+		if p, ok := params.(fakeObject); ok && fakeBool(p["globalmacro"]) {
+			objectType = "globalmacro"
+		}
+	}
+
+	t, ok := fakeObjectTypes[objectType]
+	if !ok || (t.since != "" && !s.atLeast(t.since)) {
+		return nil, &fakeError{Code: -32601, Message: "Method not found.", Data: fmt.Sprintf("Incorrect API %q.", objectType)}
+	}
+
+	switch operation {
+	case "get":
+		p, _ := params.(fakeObject)
+		return s.get(objectType, p)
+	case "getsli":
+		p, _ := params.(fakeObject)
+		return s.getSLI(p)
+	case "create":
+		return s.create(objectType, t, params)
+	case "update":
+		return s.update(objectType, t, params)
+	case "delete":
+		return s.delete(objectType, t, params)
+	}
+	return nil, &fakeError{Code: -32601, Message: "Method not found.", Data: fmt.Sprintf("Incorrect method %q.", method)}
+}
+
+func (s *fakeZabbixServer) login(params interface{}) (interface{}, *fakeError) {
+	p, _ := params.(fakeObject)
+	user, ok := p["username"]
+	if !ok && !s.atLeast("6.4") {
+		user = p["user"]
+	}
+	if user != fakeZabbixUser || p["password"] != fakeZabbixPassword {
+		return nil, invalidParams("Incorrect user name or password or account is temporarily blocked.")
+	}
+	return "fake-session", nil
+}
+
+// normalizeFakeValue turns numbers and booleans into strings the way Zabbix returns them
+func normalizeFakeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		return v.String()
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case map[string]interface{}:
+		object := fakeObject{}
+		for key, value := range v {
+			object[key] = normalizeFakeValue(value)
+		}
+		return object
+	case fakeObject:
+		return normalizeFakeValue(map[string]interface{}(v))
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, value := range v {
+			list[i] = normalizeFakeValue(value)
+		}
+		return list
+	}
+	return value
+}
+
+func fakeBool(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "1" || v == "true"
+	}
+	return false
+}
+
+// fakeStrings returns the strings of a parameter accepting either one value or a list
+func fakeStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		var values []string
+		for _, value := range v {
+			values = append(values, fakeStrings(value)...)
+		}
+		return values
+	case []string:
+		return v
+	case fakeObject, map[string]interface{}:
+		return nil
+	}
+	return []string{fmt.Sprint(value)}
+}
+
+func fakeContains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// fakeIDs returns the values of field in a list of objects such as the groups of a host
+func fakeIDs(value interface{}, field string) []string {
+	var ids []string
+	list, _ := value.([]interface{})
+	for _, element := range list {
+		if object, ok := element.(fakeObject); ok {
+			if id, ok := object[field].(string); ok {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+func (s *fakeZabbixServer) nextID() string {
+	s.lastID++
+	return strconv.Itoa(s.lastID)
+}
+
+// find returns the object with the ID among objectTypes
+func (s *fakeZabbixServer) find(id string, objectTypes ...string) (string, fakeObject) {
+	for _, objectType := range objectTypes {
+		idField := fakeObjectTypes[objectType].idField
+		for _, object := range s.objects[objectType] {
+			if object[idField] == id {
+				return objectType, object
+			}
+		}
+	}
+	return "", nil
+}
+
+// knownProperty tells whether any object of the type has the property, filters on others being ignored
+func (s *fakeZabbixServer) knownProperty(objectType, property string) bool {
+	for _, object := range s.objects[objectType] {
+		if _, ok := object[property]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// hostIDByName returns the ID of the host or template with the technical name
+func (s *fakeZabbixServer) hostIDByName(name string) string {
+	for _, objectType := range []string{"host", "template"} {
+		for _, object := range s.objects[objectType] {
+			if object["host"] == name {
+				return object[fakeObjectTypes[objectType].idField].(string)
+			}
+		}
+	}
+	return ""
+}
+
+// hostObject returns a host or a template the way it is listed in the hosts of an item or a trigger
+func (s *fakeZabbixServer) hostObject(id string) fakeObject {
+	objectType, object := s.find(id, "host", "template")
+	if object == nil {
+		return nil
+	}
+	status := "0"
+	if objectType == "template" {
+		status = "3"
+	} else if value, ok := object["status"].(string); ok {
+		status = value
+	}
+	return fakeObject{"hostid": id, "host": object["host"], "name": fakeName(object), "status": status}
+}
+
+func fakeName(object fakeObject) interface{} {
+	if name, ok := object["name"].(string); ok && name != "" {
+		return name
+	}
+	return object["host"]
+}
+
+// functionHosts returns the IDs of the hosts of the items used by a trigger
+func (s *fakeZabbixServer) functionHosts(trigger fakeObject) []string {
+	var hostIDs []string
+	for _, itemID := range fakeIDs(trigger["functions"], "itemid") {
+		if _, item := s.find(itemID, "item", "itemprototype"); item != nil && !fakeContains(hostIDs, item["hostid"].(string)) {
+			hostIDs = append(hostIDs, item["hostid"].(string))
+		}
+	}
+	return hostIDs
+}
+
+// groupsOf returns the IDs of the groups of hosts or templates
+func (s *fakeZabbixServer) groupsOf(ids []string) []string {
+	var groupIDs []string
+	for _, id := range ids {
+		if _, object := s.find(id, "host", "template"); object != nil {
+			groupIDs = append(groupIDs, fakeIDs(object["groups"], "groupid")...)
+		}
+	}
+	return groupIDs
+}
+
+func (s *fakeZabbixServer) get(objectType string, params map[string]interface{}) (interface{}, *fakeError) {
+	t := fakeObjectTypes[objectType]
+	var result []interface{}
+
+	for _, object := range s.objects[objectType] {
+		match, err := s.matches(objectType, t, object, params)
+		if err != nil {
+			return nil, err
+		}
+		if match {
+			result = append(result, s.output(objectType, object, params))
+		}
+	}
+	if result == nil {
+		result = []interface{}{}
+	}
+	if fakeBool(params["countOutput"]) {
+		return strconv.Itoa(len(result)), nil
+	}
+	return result, nil
+}
+
+// matches tells whether an object is selected by the parameters of a get request
+func (s *fakeZabbixServer) matches(objectType string, t fakeObjectType, object fakeObject, params map[string]interface{}) (bool, *fakeError) {
+	for param, value := range params {
+		values := fakeStrings(value)
+
+		switch param {
+		case "output", "sortfield", "sortorder", "limit", "preservekeys", "editable", "countOutput", "globalmacro", "templated":
+			continue
+		case "filter", "search":
+			conditions, _ := value.(fakeObject)
+			for field, condition := range conditions {
+				if !s.knownProperty(objectType, field) {
+					continue
+				}
+				actual := fmt.Sprint(object[field])
+				if _, ok := object[field]; !ok {
+					actual = ""
+				}
+				found := false
+				for _, expected := range fakeStrings(condition) {
+					if param == "filter" && actual == expected || param == "search" && strings.Contains(strings.ToLower(actual), strings.ToLower(expected)) {
+						found = true
+					}
+				}
+				if !found {
+					return false, nil
+				}
+			}
+			continue
+		case "inherited":
+			inherited := object["templateid"] != nil && object["templateid"] != "0"
+			if inherited != fakeBool(value) {
+				return false, nil
+			}
+			continue
+		case "host":
+			if host, ok := value.(string); ok {
+				if hostID, ok := object["hostid"].(string); !ok || s.hostIDByName(host) != hostID {
+					if objectType != "trigger" && objectType != "triggerprototype" || !fakeContains(s.functionHosts(object), s.hostIDByName(host)) {
+						return false, nil
+					}
+				}
+			}
+			continue
+		}
+
+		// Like older versions of Zabbix, unknown parameters are ignored unless they would filter the objects
+		if strings.HasPrefix(param, "select") || !strings.HasSuffix(param, "ids") {
+			continue
+		}
+
+		var ids []string
+		switch {
+		case param == strings.TrimSuffix(t.idField, "id")+"ids" || param == t.createKey:
+			ids = []string{object[t.idField].(string)}
+		case param == "hostids" || param == "templateids":
+			switch objectType {
+			case "host", "template":
+				if objectType == "template" && param == "hostids" {
+					// Templates linked to the hosts
+					for _, hostID := range values {
+						if _, host := s.find(hostID, "host", "template"); host != nil && fakeContains(fakeIDs(host["templates"], "templateid"), object["templateid"].(string)) {
+							ids = append(ids, hostID)
+						}
+					}
+					break
+				}
+				ids = []string{object[t.idField].(string)}
+			case "hostgroup", "templategroup":
+				if !fakeContains(s.groupsOf(values), object["groupid"].(string)) {
+					return false, nil
+				}
+				continue
+			case "trigger", "triggerprototype":
+				ids = s.functionHosts(object)
+			default:
+				ids = fakeStrings(object["hostid"])
+			}
+		case param == "groupids":
+			ids = fakeIDs(object["groups"], "groupid")
+		case param == "parentTemplateids":
+			ids = fakeIDs(object["templates"], "templateid")
+		case param == "discoveryids":
+			switch objectType {
+			case "itemprototype":
+				ids = fakeStrings(object["ruleid"])
+			case "triggerprototype":
+				for _, itemID := range fakeIDs(object["functions"], "itemid") {
+					if _, item := s.find(itemID, "itemprototype"); item != nil {
+						ids = append(ids, item["ruleid"].(string))
+					}
+				}
+			}
+		case param == "usrgrpids" && objectType == "user":
+			ids = fakeIDs(object["usrgrps"], "usrgrpid")
+		case param == "druleids" && objectType == "dcheck":
+			ids = fakeStrings(object["druleid"])
+		default:
+			return false, invalidParams("Invalid parameter \"/\": unexpected parameter \"%s\".", param)
+		}
+
+		found := false
+		for _, id := range ids {
+			if fakeContains(values, id) {
+				found = true
+			}
+		}
+		if !found {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// output copies an object with the properties requested by output and the select parameters
+func (s *fakeZabbixServer) output(objectType string, object fakeObject, params map[string]interface{}) fakeObject {
+	result := fakeObject{}
+	output := fakeStrings(params["output"])
+	for key, value := range object {
+		if params["output"] == nil || fakeContains(output, "extend") || fakeContains(output, key) || key == fakeObjectTypes[objectType].idField {
+			result[key] = value
+		}
+	}
+	if (result["name"] == nil || result["name"] == "") && (objectType == "host" || objectType == "template") && (params["output"] == nil || fakeContains(output, "extend") || fakeContains(output, "name")) {
+		result["name"] = fakeName(object)
+	}
+
+	for param, selected := range params {
+		if !strings.HasPrefix(param, "select") {
+			continue
+		}
+		subOutput := map[string]interface{}{"output": selected}
+
+		switch param {
+		case "selectHosts":
+			switch objectType {
+			case "template":
+				// Hosts linked to the template
+				var hosts []interface{}
+				for _, linkedType := range []string{"host", "template"} {
+					for _, host := range s.objects[linkedType] {
+						if fakeContains(fakeIDs(host["templates"], "templateid"), object["templateid"].(string)) {
+							hosts = append(hosts, s.hostObject(host[fakeObjectTypes[linkedType].idField].(string)))
+						}
+					}
+				}
+				result["hosts"] = fakeList(hosts)
+			case "trigger", "triggerprototype":
+				var hosts []interface{}
+				for _, hostID := range s.functionHosts(object) {
+					hosts = append(hosts, s.hostObject(hostID))
+				}
+				result["hosts"] = fakeList(hosts)
+			default:
+				var hosts []interface{}
+				if hostID, ok := object["hostid"].(string); ok {
+					if host := s.hostObject(hostID); host != nil {
+						hosts = append(hosts, host)
+					}
+				}
+				result["hosts"] = fakeList(hosts)
+			}
+		case "selectParentTemplates":
+			var templates []interface{}
+			for _, templateID := range fakeIDs(object["templates"], "templateid") {
+				if _, template := s.find(templateID, "template"); template != nil {
+					templates = append(templates, s.output("template", template, subOutput))
+				}
+			}
+			result["parentTemplates"] = fakeList(templates)
+		case "selectGroups", "selectHostGroups", "selectTemplateGroups":
+			groupType := "hostgroup"
+			if objectType == "template" && s.atLeast("6.2") {
+				groupType = "templategroup"
+			}
+			var groups []interface{}
+			for _, groupID := range fakeIDs(object["groups"], "groupid") {
+				if _, group := s.find(groupID, groupType); group != nil {
+					groups = append(groups, s.output(groupType, group, subOutput))
+				}
+			}
+			key := map[string]string{"selectGroups": "groups", "selectHostGroups": "hostgroups", "selectTemplateGroups": "templategroups"}[param]
+			result[key] = fakeList(groups)
+		case "selectMacros":
+			var macros []interface{}
+			for _, macro := range s.objects["usermacro"] {
+				if macro["hostid"] == object[fakeObjectTypes[objectType].idField] {
+					macros = append(macros, s.output("usermacro", macro, subOutput))
+				}
+			}
+			result["macros"] = fakeList(macros)
+		case "selectFunctions":
+			result["functions"] = fakeList(object["functions"])
+		case "selectItems":
+			var items []interface{}
+			for _, itemID := range fakeIDs(object["functions"], "itemid") {
+				if itemType, item := s.find(itemID, "item", "itemprototype"); item != nil {
+					items = append(items, s.output(itemType, item, subOutput))
+				}
+			}
+			result["items"] = fakeList(items)
+		case "selectDependencies":
+			var dependencies []interface{}
+			for _, triggerID := range fakeIDs(object["dependencies"], "triggerid") {
+				if triggerType, trigger := s.find(triggerID, "trigger", "triggerprototype"); trigger != nil {
+					dependencies = append(dependencies, s.output(triggerType, trigger, subOutput))
+				}
+			}
+			result["dependencies"] = fakeList(dependencies)
+		case "selectChildren":
+			// The links between services are stored as the parents of the children
+			var children []interface{}
+			for _, child := range s.objects["service"] {
+				if fakeContains(fakeIDs(child["parents"], "serviceid"), object["serviceid"].(string)) {
+					children = append(children, fakeObject{"serviceid": child["serviceid"]})
+				}
+			}
+			result["children"] = fakeList(children)
+		case "selectDiscoveryRule":
+			if ruleID, ok := object["ruleid"].(string); ok {
+				if _, rule := s.find(ruleID, "discoveryrule"); rule != nil {
+					result["discoveryRule"] = s.output("discoveryrule", rule, subOutput)
+				}
+			}
+		default:
+			// selectFilter, selectOperations, selectMappings... return the property as it was stored
+			key := fakeSelectedProperty(param)
+			if value, ok := object[key]; ok {
+				result[key] = value
+			}
+		}
+	}
+	return result
+}
+
+var fakeSelectedProperties = map[string]string{
+	"selectLLDMacroPaths":         "lld_macro_paths",
+	"selectRecoveryOperations":    "recovery_operations",
+	"selectUpdateOperations":      "update_operations",
+	"selectAcknowledgeOperations": "acknowledge_operations",
+	"selectInterfaces":            "interfaces",
+	"selectUsrgrps":               "usrgrps",
+	"selectMessageTemplates":      "message_templates",
+	"selectProblemTags":           "problem_tags",
+	"selectStatusRules":           "status_rules",
+	"selectExcludedDowntimes":     "excluded_downtimes",
+	"selectServiceTags":           "service_tags",
+}
+
+func fakeSelectedProperty(param string) string {
+	if property, ok := fakeSelectedProperties[param]; ok {
+		return property
+	}
+	return strings.ToLower(strings.TrimPrefix(param, "select"))
+}
+
+func fakeList(value interface{}) interface{} {
+	if list, ok := value.([]interface{}); ok && list != nil {
+		return list
+	}
+	return []interface{}{}
+}
+
+// fakeObjectsParam returns the objects of a create or update request, which takes either one object or a list
+func fakeObjectsParam(params interface{}) ([]fakeObject, *fakeError) {
+	var objects []fakeObject
+	switch p := params.(type) {
+	case fakeObject:
+		objects = []fakeObject{p}
+	case []interface{}:
+		for _, element := range p {
+			object, ok := element.(fakeObject)
+			if !ok {
+				return nil, invalidParams("Invalid parameter \"/1\": an array is expected.")
+			}
+			objects = append(objects, object)
+		}
+	default:
+		return nil, invalidParams("Invalid parameter \"/\": an array is expected.")
+	}
+	return objects, nil
+}
+
+func (s *fakeZabbixServer) create(objectType string, t fakeObjectType, params interface{}) (interface{}, *fakeError) {
+	objects, err := fakeObjectsParam(params)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []interface{}
+	for i, object := range objects {
+		if id, ok := object[t.idField]; ok && id != "" {
+			return nil, invalidParams("Invalid parameter \"/%d\": unexpected parameter \"%s\".", i+1, t.idField)
+		}
+		for key, value := range object {
+			if value == nil {
+				delete(object, key)
+			}
+		}
+		object[t.idField] = s.nextID()
+		if err := s.store(objectType, object, nil); err != nil {
+			return nil, err
+		}
+		s.setDefaults(objectType, object)
+		s.objects[objectType] = append(s.objects[objectType], object)
+		ids = append(ids, object[t.idField])
+	}
+	return fakeObject{t.createKey: ids}, nil
+}
+
+func (s *fakeZabbixServer) update(objectType string, t fakeObjectType, params interface{}) (interface{}, *fakeError) {
+	objects, err := fakeObjectsParam(params)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []interface{}
+	for i, changes := range objects {
+		id, _ := changes[t.idField].(string)
+		_, existing := s.find(id, objectType)
+		if existing == nil {
+			return nil, invalidParams("Invalid parameter \"/%d/%s\": object does not exist, or you have no permissions to it.", i+1, t.idField)
+		}
+
+		updated := fakeObject{}
+		for key, value := range existing {
+			updated[key] = value
+		}
+		for key, value := range changes {
+			if value != nil {
+				updated[key] = value
+			}
+		}
+		if err := s.store(objectType, updated, existing); err != nil {
+			return nil, err
+		}
+		for key := range updated {
+			existing[key] = updated[key]
+		}
+		ids = append(ids, id)
+	}
+	return fakeObject{t.createKey: ids}, nil
+}
+
+// store validates an object before it is created or updated and maintains the objects derived from it
+func (s *fakeZabbixServer) store(objectType string, object, existing fakeObject) *fakeError {
+	t := fakeObjectTypes[objectType]
+	unique := func(field string, scope ...string) *fakeError {
+		for _, other := range s.objects[objectType] {
+			if other[t.idField] == object[t.idField] || other[field] != object[field] {
+				continue
+			}
+			sameScope := true
+			for _, f := range scope {
+				sameScope = sameScope && other[f] == object[f]
+			}
+			if sameScope {
+				return invalidParams("%s with %s \"%s\" already exists.", objectType, field, object[field])
+			}
+		}
+		return nil
+	}
+	required := func(fields ...string) *fakeError {
+		for _, field := range fields {
+			if value, ok := object[field]; !ok || value == "" {
+				return invalidParams("Invalid parameter \"/1\": the parameter \"%s\" is missing.", field)
+			}
+		}
+		return nil
+	}
+
+	if err := s.checkProperties(objectType, object); err != nil {
+		return err
+	}
+
+	if objectType == "action" {
+		fakeFormulaIDs(object["filter"])
+		for _, key := range []string{"operations", "recovery_operations", "update_operations", "acknowledge_operations"} {
+			operations, _ := object[key].([]interface{})
+			for _, operation := range operations {
+				if operation, ok := operation.(fakeObject); ok && operation["operationid"] == nil {
+					operation["operationid"] = s.nextID()
+				}
+			}
+		}
+	}
+
+	switch objectType {
+	case "hostgroup", "templategroup", "action":
+		if err := required("name"); err != nil {
+			return err
+		}
+		return unique("name")
+	case "valuemap":
+		if err := required("name"); err != nil {
+			return err
+		}
+		mappings, _ := object["mappings"].([]interface{})
+		for _, mapping := range mappings {
+			if mapping, ok := mapping.(fakeObject); ok {
+				if err := s.checkProperties("mapping", mapping); err != nil {
+					return err
+				}
+				s.setDefaults("mapping", mapping)
+			}
+		}
+		if s.atLeast("5.4") {
+			if err := required("hostid"); err != nil {
+				return err
+			}
+			return unique("name", "hostid")
+		}
+		return unique("name")
+	case "host", "template":
+		if err := required("host", "groups"); err != nil {
+			return err
+		}
+		if err := unique("host"); err != nil {
+			return err
+		}
+		for _, other := range s.objects[map[string]string{"host": "template", "template": "host"}[objectType]] {
+			if other["host"] == object["host"] {
+				return invalidParams("Host with the same name \"%s\" already exists.", object["host"])
+			}
+		}
+		groupType := "hostgroup"
+		if objectType == "template" && s.atLeast("6.2") {
+			groupType = "templategroup"
+		}
+		for i, groupID := range fakeIDs(object["groups"], "groupid") {
+			if _, group := s.find(groupID, groupType); group == nil {
+				return invalidParams("Invalid parameter \"/1/groups/%d\": object does not exist, or you have no permissions to it.", i+1)
+			}
+		}
+		macros, _ := object["macros"].([]interface{})
+		for _, macro := range macros {
+			if macro, ok := macro.(fakeObject); ok {
+				if err := s.checkProperties("usermacro", macro); err != nil {
+					return err
+				}
+			}
+		}
+		interfaces, _ := object["interfaces"].([]interface{})
+		for i, hostInterface := range interfaces {
+			if hostInterface, ok := hostInterface.(fakeObject); ok {
+				if err := s.checkProperties("interface", hostInterface); err != nil {
+					return err
+				}
+				if hostInterface["type"] == "2" && s.atLeast("5.0") {
+					details, _ := hostInterface["details"].(fakeObject)
+					if details == nil {
+						return invalidParams("Invalid parameter \"/1/interfaces/%d\": the parameter \"details\" is missing.", i+1)
+					}
+					if details["version"] == nil {
+						return invalidParams("Invalid parameter \"/1/interfaces/%d/details\": the parameter \"version\" is missing.", i+1)
+					}
+					if details["version"] != "3" && (details["community"] == nil || details["community"] == "") {
+						return invalidParams("Invalid parameter \"/1/interfaces/%d/details/community\": cannot be empty.", i+1)
+					}
+				}
+			}
+		}
+		s.storeHost(objectType, object)
+	case "item", "discoveryrule", "itemprototype":
+		if err := required("hostid", "key_", "name"); err != nil {
+			return err
+		}
+		if objectType == "itemprototype" {
+			if err := required("ruleid"); err != nil {
+				return err
+			}
+		}
+		if _, host := s.find(object["hostid"].(string), "host", "template"); host == nil {
+			return invalidParams("Invalid parameter \"/1/hostid\": object does not exist.")
+		}
+		if err := unique("key_", "hostid"); err != nil {
+			return err
+		}
+		if objectType == "discoveryrule" {
+			if object["filter"] == nil {
+				object["filter"] = fakeObject{"evaltype": "0", "formula": "", "conditions": []interface{}{}}
+			}
+			if err := fakeCheckCustomFilter(object["filter"]); err != nil {
+				return err
+			}
+			fakeFormulaIDs(object["filter"])
+		}
+	case "trigger", "triggerprototype":
+		if err := required("description", "expression"); err != nil {
+			return err
+		}
+		if existing == nil || object["expression"] != existing["expression"] {
+			if err := s.compileExpression(object); err != nil {
+				return err
+			}
+		}
+	case "service":
+		if err := required("name", "algorithm", "sortorder"); err != nil {
+			return err
+		}
+		id := object["serviceid"].(string)
+		for _, key := range []string{"parents", "children"} {
+			for i, linkedID := range fakeIDs(object[key], "serviceid") {
+				if _, linked := s.find(linkedID, "service"); linked == nil || linkedID == id {
+					return invalidParams("Invalid parameter \"/1/%s/%d\": object does not exist, or you have no permissions to it.", key, i+1)
+				}
+			}
+		}
+		// The children replace the links to the service in the parents of the other services
+		if children, ok := object["children"]; ok {
+			childIDs := fakeIDs(children, "serviceid")
+			for _, other := range s.objects["service"] {
+				var parents []interface{}
+				for _, parentID := range fakeIDs(other["parents"], "serviceid") {
+					if parentID != id {
+						parents = append(parents, fakeObject{"serviceid": parentID})
+					}
+				}
+				if fakeContains(childIDs, other["serviceid"].(string)) {
+					parents = append(parents, fakeObject{"serviceid": id})
+				}
+				other["parents"] = fakeList(parents)
+			}
+			delete(object, "children")
+		}
+	case "sla":
+		if err := required("name", "period", "slo", "timezone"); err != nil {
+			return err
+		}
+		if tags, _ := object["service_tags"].([]interface{}); len(tags) == 0 {
+			return invalidParams("Invalid parameter \"/1/service_tags\": cannot be empty.")
+		}
+		return unique("name")
+	case "usermacro", "globalmacro":
+		if err := required("macro"); err != nil {
+			return err
+		}
+		if objectType == "usermacro" {
+			return unique("macro", "hostid")
+		}
+		return unique("macro")
+	}
+
+	return nil
+}
+
+// checkProperties rejects the properties unknown to the version of the server
+func (s *fakeZabbixServer) checkProperties(objectType string, object fakeObject) *fakeError {
+	for property := range object {
+		if !s.knowsProperty(objectType, property) {
+			return invalidParams("Invalid parameter \"/1\": unexpected parameter \"%s\".", property)
+		}
+	}
+	return nil
+}
+
+// knowsProperty is whether the version of the server knows a property, regardless of the objects stored
+func (s *fakeZabbixServer) knowsProperty(objectType, property string) bool {
+	if since, ok := fakeSinceProperties[objectType][property]; ok && !s.atLeast(since) {
+		return false
+	}
+	if until, ok := fakeUntilProperties[objectType][property]; ok && s.atLeast(until) {
+		return false
+	}
+	return true
+}
+
+// setDefaults sets the omitted properties known to the version of the server
+func (s *fakeZabbixServer) setDefaults(objectType string, object fakeObject) {
+	for property, value := range fakeDefaults[objectType] {
+		if _, set := object[property]; !set && s.knowsProperty(objectType, property) {
+			object[property] = value
+		}
+	}
+}
+
+// storeHost assigns IDs to the interfaces of a host, stores its macros as user macros and unlinks templates
+func (s *fakeZabbixServer) storeHost(objectType string, object fakeObject) {
+	id := object[fakeObjectTypes[objectType].idField].(string)
+
+	interfaces, _ := object["interfaces"].([]interface{})
+	for _, i := range interfaces {
+		if i, ok := i.(fakeObject); ok {
+			if i["interfaceid"] == nil || i["interfaceid"] == "" || i["interfaceid"] == "0" {
+				i["interfaceid"] = s.nextID()
+			}
+			i["hostid"] = id
+			// Zabbix returns the details of the interfaces other than SNMP as an empty list
+			if i["details"] == nil && s.atLeast("5.0") {
+				i["details"] = []interface{}{}
+			}
+		}
+	}
+
+	if clear, ok := object["templates_clear"]; ok {
+		var templates []interface{}
+		for _, templateID := range fakeIDs(object["templates"], "templateid") {
+			if !fakeContains(fakeIDs(clear, "templateid"), templateID) {
+				templates = append(templates, fakeObject{"templateid": templateID})
+			}
+		}
+		object["templates"] = fakeList(templates)
+		delete(object, "templates_clear")
+	}
+
+	if macros, ok := object["macros"].([]interface{}); ok {
+		var kept []fakeObject
+		for _, macro := range s.objects["usermacro"] {
+			if macro["hostid"] != id {
+				kept = append(kept, macro)
+			}
+		}
+		for _, macro := range macros {
+			if macro, ok := macro.(fakeObject); ok {
+				stored := fakeObject{"hostmacroid": s.nextID(), "hostid": id}
+				for key, value := range macro {
+					stored[key] = value
+				}
+				s.setDefaults("usermacro", stored)
+				kept = append(kept, stored)
+			}
+		}
+		s.objects["usermacro"] = kept
+		delete(object, "macros")
+	}
+}
+
+// fakeCheckCustomFilter rejects the custom filters without a formula or with conditions it cannot reference
+func fakeCheckCustomFilter(filter interface{}) *fakeError {
+	f, ok := filter.(fakeObject)
+	if !ok || f["evaltype"] != "3" {
+		return nil
+	}
+	if f["formula"] == nil || f["formula"] == "" {
+		return invalidParams("Invalid parameter \"/1/filter/formula\": cannot be empty.")
+	}
+	conditions, _ := f["conditions"].([]interface{})
+	for i, condition := range conditions {
+		if condition, ok := condition.(fakeObject); ok && (condition["formulaid"] == nil || condition["formulaid"] == "") {
+			return invalidParams("Invalid parameter \"/1/filter/conditions/%d\": the parameter \"formulaid\" is missing.", i+1)
+		}
+	}
+	return nil
+}
+
+// fakeFormulaIDs names the conditions of a filter the way Zabbix does
+func fakeFormulaIDs(filter interface{}) {
+	f, ok := filter.(fakeObject)
+	if !ok {
+		return
+	}
+	conditions, _ := f["conditions"].([]interface{})
+	for i, condition := range conditions {
+		if condition, ok := condition.(fakeObject); ok && (condition["formulaid"] == nil || condition["formulaid"] == "") {
+			condition["formulaid"] = string(rune('A' + i))
+		}
+	}
+}
+
+var (
+	fakeExpressionFunction    = regexp.MustCompile(`([a-z]+)\(/([^/()]*)/`)
+	fakeOldExpressionFunction = regexp.MustCompile(`\{([^:{}]+):(.+?)\.([a-z]+)\(([^()]*)\)\}`)
+)
+
+// compileExpression replaces the functions of a trigger expression with their IDs, like the Zabbix server
+// stores them
+func (s *fakeZabbixServer) compileExpression(trigger fakeObject) *fakeError {
+	expression, _ := trigger["expression"].(string)
+	var functions []interface{}
+
+	addFunction := func(host, key, function, parameter string) (string, *fakeError) {
+		itemID := ""
+		hostID := s.hostIDByName(host)
+		for _, itemType := range []string{"item", "itemprototype"} {
+			for _, item := range s.objects[itemType] {
+				if item["hostid"] == hostID && item["key_"] == key {
+					itemID = item["itemid"].(string)
+				}
+			}
+		}
+		if itemID == "" {
+			return "", invalidParams("Invalid parameter \"/1/expression\": incorrect item key \"%s\" provided for trigger expression on \"%s\".", key, host)
+		}
+		functionID := s.nextID()
+		functions = append(functions, fakeObject{
+			"functionid": functionID,
+			"itemid":     itemID,
+			"triggerid":  trigger["triggerid"],
+			"function":   function,
+			"parameter":  parameter,
+		})
+		return "{" + functionID + "}", nil
+	}
+
+	var compiled strings.Builder
+	if s.atLeast("5.4") {
+		for {
+			match := fakeExpressionFunction.FindStringSubmatchIndex(expression)
+			if match == nil {
+				break
+			}
+			function, host := expression[match[2]:match[3]], expression[match[4]:match[5]]
+			rest := expression[match[1]:]
+
+			// The key ends at the first comma or parenthesis outside of its brackets
+			depth, end := 0, -1
+			for i, c := range rest {
+				if c == '[' {
+					depth++
+				} else if c == ']' {
+					depth--
+				} else if depth == 0 && (c == ',' || c == ')') {
+					end = i
+					break
+				}
+			}
+			if end < 0 {
+				return invalidParams("Invalid parameter \"/1/expression\": incorrect trigger expression starting from \"%s\".", expression[match[0]:])
+			}
+			close := strings.Index(rest[end:], ")")
+			if close < 0 {
+				return invalidParams("Invalid parameter \"/1/expression\": incorrect trigger expression starting from \"%s\".", expression[match[0]:])
+			}
+
+			id, err := addFunction(host, rest[:end], function, "$"+rest[end:end+close])
+			if err != nil {
+				return err
+			}
+			compiled.WriteString(expression[:match[0]] + id)
+			expression = rest[end+close+1:]
+		}
+	} else {
+		for {
+			match := fakeOldExpressionFunction.FindStringSubmatchIndex(expression)
+			if match == nil {
+				break
+			}
+			id, err := addFunction(expression[match[2]:match[3]], expression[match[4]:match[5]], expression[match[6]:match[7]], expression[match[8]:match[9]])
+			if err != nil {
+				return err
+			}
+			compiled.WriteString(expression[:match[0]] + id)
+			expression = expression[match[1]:]
+		}
+	}
+	compiled.WriteString(expression)
+
+	if len(functions) == 0 {
+		return invalidParams("Invalid parameter \"/1/expression\": trigger expression must contain at least one /host/key reference.")
+	}
+	trigger["expression"] = compiled.String()
+	trigger["functions"] = functions
+	return nil
+}
+
+func (s *fakeZabbixServer) delete(objectType string, t fakeObjectType, params interface{}) (interface{}, *fakeError) {
+	list, ok := params.([]interface{})
+	if !ok {
+		return nil, invalidParams("Invalid parameter \"/\": an array is expected.")
+	}
+
+	var ids []string
+	for _, element := range list {
+		if object, ok := element.(fakeObject); ok {
+			element = object[t.idField]
+		}
+		id, _ := element.(string)
+		if _, object := s.find(id, objectType); object == nil {
+			return nil, invalidParams("No permissions to referred object or it does not exist!")
+		}
+		ids = append(ids, id)
+	}
+
+	deleted := make([]interface{}, len(ids))
+	for i, id := range ids {
+		s.remove(objectType, id)
+		deleted[i] = id
+	}
+	return fakeObject{t.deleteKey: deleted}, nil
+}
+
+// getSLI reports the services of an SLA as always up over one period, from period_from to period_to or for a day
+func (s *fakeZabbixServer) getSLI(params fakeObject) (interface{}, *fakeError) {
+	_, sla := s.find(strings.Join(fakeStrings(params["slaid"]), ""), "sla")
+	if sla == nil {
+		return nil, invalidParams("Invalid parameter \"/slaid\": object does not exist, or you have no permissions to it.")
+	}
+	from, _ := strconv.Atoi(strings.Join(fakeStrings(params["period_from"]), ""))
+	to, err := strconv.Atoi(strings.Join(fakeStrings(params["period_to"]), ""))
+	if err != nil {
+		to = from + 86400
+	}
+	slo, _ := strconv.ParseFloat(sla["slo"].(string), 64)
+
+	serviceIDs := []interface{}{}
+	sli := []interface{}{}
+	for _, service := range s.objects["service"] {
+		id := service["serviceid"].(string)
+		if params["serviceids"] != nil {
+			if !fakeContains(fakeStrings(params["serviceids"]), id) {
+				continue
+			}
+		} else if !fakeTagsMatch(service["tags"], sla["service_tags"]) {
+			continue
+		}
+		serviceID, _ := strconv.Atoi(id)
+		serviceIDs = append(serviceIDs, serviceID)
+		sli = append(sli, fakeObject{
+			"uptime":             to - from,
+			"downtime":           0,
+			"sli":                100,
+			"error_budget":       int(float64(to-from) * (100 - slo) / 100),
+			"excluded_downtimes": []interface{}{},
+		})
+	}
+	return fakeObject{
+		"periods":    []interface{}{fakeObject{"period_from": from, "period_to": to}},
+		"serviceids": serviceIDs,
+		"sli":        []interface{}{sli},
+	}, nil
+}
+
+// fakeTagsMatch is whether tags match one of the tags of an SLA, by value or by part of the value
+func fakeTagsMatch(tags, slaTags interface{}) bool {
+	list, _ := tags.([]interface{})
+	slaList, _ := slaTags.([]interface{})
+	for _, tag := range list {
+		tag, _ := tag.(fakeObject)
+		for _, slaTag := range slaList {
+			slaTag, _ := slaTag.(fakeObject)
+			value, _ := tag["value"].(string)
+			expected, _ := slaTag["value"].(string)
+			if tag["tag"] == slaTag["tag"] && (value == expected || slaTag["operator"] == "2" && strings.Contains(value, expected)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// remove deletes an object and the objects depending on it
+func (s *fakeZabbixServer) remove(objectType, id string) {
+	idField := fakeObjectTypes[objectType].idField
+	var kept []fakeObject
+	for _, object := range s.objects[objectType] {
+		if object[idField] != id {
+			kept = append(kept, object)
+		}
+	}
+	s.objects[objectType] = kept
+
+	dependents := func(dependentType string, depends func(fakeObject) bool) {
+		var ids []string
+		for _, object := range s.objects[dependentType] {
+			if depends(object) {
+				ids = append(ids, object[fakeObjectTypes[dependentType].idField].(string))
+			}
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			s.remove(dependentType, id)
+		}
+	}
+
+	switch objectType {
+	case "host", "template":
+		for _, dependentType := range []string{"item", "discoveryrule", "usermacro", "valuemap"} {
+			dependents(dependentType, func(object fakeObject) bool { return object["hostid"] == id })
+		}
+		for _, linkedType := range []string{"host", "template"} {
+			for _, object := range s.objects[linkedType] {
+				var templates []interface{}
+				for _, templateID := range fakeIDs(object["templates"], "templateid") {
+					if templateID != id {
+						templates = append(templates, fakeObject{"templateid": templateID})
+					}
+				}
+				if object["templates"] != nil {
+					object["templates"] = fakeList(templates)
+				}
+			}
+		}
+	case "discoveryrule":
+		dependents("itemprototype", func(object fakeObject) bool { return object["ruleid"] == id })
+	case "service":
+		for _, child := range s.objects["service"] {
+			var parents []interface{}
+			for _, parentID := range fakeIDs(child["parents"], "serviceid") {
+				if parentID != id {
+					parents = append(parents, fakeObject{"serviceid": parentID})
+				}
+			}
+			if child["parents"] != nil {
+				child["parents"] = fakeList(parents)
+			}
+		}
+	case "item", "itemprototype":
+		for _, triggerType := range []string{"trigger", "triggerprototype"} {
+			dependents(triggerType, func(object fakeObject) bool {
+				return fakeContains(fakeIDs(object["functions"], "itemid"), id)
+			})
+		}
+	}
+}
+
+// fakeCall sends a JSON-RPC request to the fake server
+func fakeCall(t *testing.T, s *fakeZabbixServer, method, auth string, params interface{}) (interface{}, *fakeError) {
+	t.Helper()
+
+	request := map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params, "id": 1}
+	if auth != "" {
+		request["auth"] = auth
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := http.Post(s.URL+"/api_jsonrpc.php", "application/json-rpc", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	var result struct {
+		Result interface{} `json:"result"`
+		Error  *fakeError  `json:"error"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	return result.Result, result.Error
+}
+
+// fakeMustCall sends a JSON-RPC request to the fake server with the session of the user and fails on errors
+func fakeMustCall(t *testing.T, s *fakeZabbixServer, method string, params interface{}) interface{} {
+	t.Helper()
+
+	result, err := fakeCall(t, s, method, "fake-session", params)
+	if err != nil {
+		t.Fatalf("%s: %s", method, err)
+	}
+	return result
+}
+
+// fakeCreatedID returns the ID of the object created by a request to the fake server
+func fakeCreatedID(t *testing.T, s *fakeZabbixServer, method, key string, params interface{}) string {
+	t.Helper()
+
+	ids := fakeMustCall(t, s, method, params).(map[string]interface{})[key].([]interface{})
+	if len(ids) != 1 {
+		t.Fatalf("%s: expected one ID and got %v", method, ids)
+	}
+	return ids[0].(string)
+}
+
+func TestFakeZabbixServerLogin(t *testing.T) {
+	s := newFakeZabbixServer("7.0.0")
+	defer s.Close()
+
+	if result, err := fakeCall(t, s, "apiinfo.version", "", []string{}); err != nil || result != "7.0.0" {
+		t.Fatalf("Expected version 7.0.0, got %v (%v)", result, err)
+	}
+	if _, err := fakeCall(t, s, "apiinfo.version", "fake-session", []string{}); err == nil {
+		t.Fatal("Expected apiinfo.version to fail when called with a session")
+	}
+	if _, err := fakeCall(t, s, "user.login", "", map[string]string{"user": fakeZabbixUser, "password": fakeZabbixPassword}); err == nil {
+		t.Fatal("Expected Zabbix 7.0 to reject the user parameter of user.login")
+	}
+	if _, err := fakeCall(t, s, "user.login", "", map[string]string{"username": fakeZabbixUser, "password": "wrong"}); err == nil {
+		t.Fatal("Expected user.login to fail with a wrong password")
+	}
+	if result, err := fakeCall(t, s, "user.login", "", map[string]string{"username": fakeZabbixUser, "password": fakeZabbixPassword}); err != nil || result != "fake-session" {
+		t.Fatalf("Expected a session, got %v (%v)", result, err)
+	}
+	if _, err := fakeCall(t, s, "hostgroup.get", "", map[string]string{}); err == nil {
+		t.Fatal("Expected hostgroup.get to fail without a session")
+	}
+	if _, err := fakeCall(t, s, "host.unknown", "fake-session", map[string]string{}); err == nil || err.Code != -32601 {
+		t.Fatalf("Expected an unknown method to fail with -32601, got %v", err)
+	}
+}
+
+func TestFakeZabbixServerTriggerExpression(t *testing.T) {
+	cases := []struct {
+		version    string
+		expression string
+		function   string
+		parameter  string
+	}{
+		{"5.0.0", "{template_test:system.cpu.load[percpu,avg1].avg(5m)}>2", "avg", "5m"},
+		{"6.4.0", "avg(/template_test/system.cpu.load[percpu,avg1],5m)>2", "avg", "$,5m"},
+		{"6.4.0", "last(/template_test/system.cpu.load[percpu,avg1])>2", "last", "$"},
+	}
+
+	for _, c := range cases {
+		s := newFakeZabbixServer(c.version)
+		t.Cleanup(s.Close)
+
+		templateID := fakeCreatedID(t, s, "template.create", "templateids", []interface{}{map[string]interface{}{
+			"host":   "template_test",
+			"groups": []interface{}{map[string]string{"groupid": "1"}},
+		}})
+		itemID := fakeCreatedID(t, s, "item.create", "itemids", []interface{}{map[string]interface{}{
+			"hostid": templateID,
+			"name":   "CPU load",
+			"key_":   "system.cpu.load[percpu,avg1]",
+			"type":   2,
+		}})
+		triggerID := fakeCreatedID(t, s, "trigger.create", "triggerids", []interface{}{map[string]interface{}{
+			"description": "High CPU load",
+			"expression":  c.expression,
+			"priority":    3,
+		}})
+
+		triggers := fakeMustCall(t, s, "trigger.get", map[string]interface{}{
+			"triggerids":      triggerID,
+			"output":          "extend",
+			"selectFunctions": "extend",
+			"selectHosts":     []string{"host"},
+		}).([]interface{})
+		if len(triggers) != 1 {
+			t.Fatalf("%s: expected one trigger and got %d", c.version, len(triggers))
+		}
+		trigger := triggers[0].(map[string]interface{})
+		function := trigger["functions"].([]interface{})[0].(map[string]interface{})
+
+		if expected := fmt.Sprintf("{%s}>2", function["functionid"]); trigger["expression"] != expected {
+			t.Errorf("%s: expected the expression %s, got %s", c.version, expected, trigger["expression"])
+		}
+		if function["itemid"] != itemID || function["function"] != c.function || function["parameter"] != c.parameter {
+			t.Errorf("%s: unexpected function %v", c.version, function)
+		}
+		if trigger["priority"] != "3" {
+			t.Errorf("%s: expected the priority as a string, got %#v", c.version, trigger["priority"])
+		}
+		if hosts := trigger["hosts"].([]interface{}); len(hosts) != 1 || hosts[0].(map[string]interface{})["host"] != "template_test" {
+			t.Errorf("%s: unexpected hosts %v", c.version, hosts)
+		}
+
+		fakeMustCall(t, s, "template.delete", []string{templateID})
+		for _, method := range []string{"item.get", "trigger.get"} {
+			if objects := fakeMustCall(t, s, method, map[string]interface{}{"templateids": templateID}).([]interface{}); len(objects) != 0 {
+				t.Errorf("%s: expected %s to return nothing once the template is deleted, got %v", c.version, method, objects)
+			}
+		}
+	}
+}
+
+func TestFakeZabbixServerAPI(t *testing.T) {
+	s, api := newFakeZabbixClient(t, "6.0.0")
+	if api.ServerVersion.String() != "6.0.0" {
+		t.Fatalf("Expected the server version 6.0.0, got %s", api.ServerVersion)
+	}
+
+	groups, err := api.HostGroupsGet(zabbix.Params{
+		"output": "extend",
+		"filter": map[string]interface{}{"name": "Linux servers"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 1 || groups[0].GroupID != "2" {
+		t.Fatalf("Expected the Linux servers host group, got %v", groups)
+	}
+	if len(s.calls("hostgroup.get")) != 1 {
+		t.Fatalf("Expected one hostgroup.get request, got %v", s.calls("hostgroup.get"))
+	}
+}
@@ -0,0 +1,524 @@
+package zabbix
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/claranet/go-zabbix-api"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// generateReferences lists, for each resource, the attributes holding the ID or the name of another generated
+// object and the kinds of objects they may reference, tried in order
+var generateReferences = map[string]map[string][]string{
+	"zabbix_host": {
+		"groups":    {"host_group.name"},
+		"templates": {"template.name"},
+	},
+	"zabbix_template": {
+		"groups":          {"template_group.name", "host_group.name"},
+		"linked_template": {"template.id"},
+	},
+	"zabbix_item": {
+		"host_id":      {"host.id", "template.id"},
+		"interface_id": {"interface.id"},
+	},
+	"zabbix_lld_rule": {
+		"host_id":       {"host.id", "template.id"},
+		"interface_id":  {"interface.id"},
+		"master_itemid": {"item.id"},
+	},
+	"zabbix_trigger": {
+		"dependencies": {"trigger.id"},
+	},
+	"zabbix_action": {
+		"operation.host_groups":                   {"host_group.name"},
+		"operation.templates":                     {"template.host"},
+		"operation.command.target.value":          {"target"},
+		"recovery_operation.command.target.value": {"target"},
+		"update_operation.command.target.value":   {"target"},
+		"condition.value":                         {"condition"},
+	},
+}
+
+// generateTypedReferences lists the kinds of objects referenced by the value of action conditions and
+// command targets depending on their type
+var generateTypedReferences = map[string]map[string][]string{
+	"condition": {
+		"host_group":    {"host_group.name"},
+		"host":          {"host.id"},
+		"host_template": {"template.id"},
+		"trigger":       {"trigger.id"},
+	},
+	"target": {
+		"host_group": {"host_group.name"},
+		"host":       {"host.host"},
+	},
+}
+
+var generateLabelRegexp = regexp.MustCompile("[^a-z0-9]+")
+
+type generatedResource struct {
+	resourceType string
+	label        string
+	id           string
+	data         *schema.ResourceData
+}
+
+type generator struct {
+	api        *zabbixClient
+	resources  map[string]*schema.Resource
+	generated  []*generatedResource
+	labels     map[string]map[string]bool
+	hostLabels map[string]string
+	references map[string]map[string]hcl.Traversal
+}
+
+// Generate writes the resources and import blocks matching the objects of a Zabbix server
+func Generate(args []string, w io.Writer) error {
+	flags := flag.NewFlagSet("generate", flag.ContinueOnError)
+	serverURL := flags.String("server-url", os.Getenv("ZABBIX_SERVER_URL"), "URL of the Zabbix API, defaults to ZABBIX_SERVER_URL")
+	user := flags.String("user", os.Getenv("ZABBIX_USER"), "User of the Zabbix API, defaults to ZABBIX_USER")
+	password := flags.String("password", os.Getenv("ZABBIX_PASSWORD"), "Password of the Zabbix API, defaults to ZABBIX_PASSWORD")
+	output := flags.String("output", "", "File to write the configuration to, defaults to the standard output")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *serverURL == "" || *user == "" || *password == "" {
+		return fmt.Errorf("The server URL, user and password are required")
+	}
+
+	api, err := newZabbixClient(*serverURL, *user, *password, "terraform-provider-zabbix generate")
+	if err != nil {
+		return err
+	}
+
+	g := newGenerator(api)
+	if err := g.collect(); err != nil {
+		return err
+	}
+	content := g.write()
+
+	if *output != "" {
+		return os.WriteFile(*output, content, 0644)
+	}
+	_, err = w.Write(content)
+	return err
+}
+
+func newGenerator(api *zabbixClient) *generator {
+	return &generator{
+		api:        api,
+		resources:  Provider().ResourcesMap,
+		labels:     map[string]map[string]bool{},
+		hostLabels: map[string]string{},
+		references: map[string]map[string]hcl.Traversal{},
+	}
+}
+
+// collect reads every supported object, parents first so that their labels are known by their children
+func (g *generator) collect() error {
+	hostGroups, err := g.api.HostGroupsGet(zabbix.Params{"output": "extend"})
+	if err != nil {
+		return err
+	}
+	for _, group := range hostGroups {
+		r, err := g.add("zabbix_host_group", group.GroupID, group.Name)
+		if err != nil {
+			return err
+		}
+		if r == nil {
+			continue
+		}
+		g.register("host_group.name", group.Name, r, "name")
+	}
+
+	if g.api.ServerVersion.GreaterThanOrEqual(version.Must(version.NewVersion("6.2"))) {
+		templateGroups, err := g.api.TemplateGroupsGet(zabbix.Params{"output": "extend"})
+		if err != nil {
+			return err
+		}
+		for _, group := range templateGroups {
+			r, err := g.add("zabbix_template_group", group.GroupID, group.Name)
+			if err != nil {
+				return err
+			}
+			if r == nil {
+				continue
+			}
+			g.register("template_group.name", group.Name, r, "name")
+		}
+	}
+
+	templates, err := g.api.TemplatesGet(zabbix.Params{"output": "extend"})
+	if err != nil {
+		return err
+	}
+	var hostIDs []string
+	for _, template := range templates {
+		r, err := g.add("zabbix_template", template.TemplateID, template.Host)
+		if err != nil {
+			return err
+		}
+		if r == nil {
+			continue
+		}
+		g.hostLabels[template.TemplateID] = r.label
+		hostIDs = append(hostIDs, template.TemplateID)
+		g.register("template.id", template.TemplateID, r, "id")
+		g.register("template.host", template.Host, r, "host")
+		if r.data.Get("name").(string) != "" {
+			g.register("template.name", template.Name, r, "name")
+		} else {
+			g.register("template.name", template.Host, r, "host")
+		}
+	}
+
+	hosts, err := g.api.HostsGet(zabbix.Params{
+		"output": "extend",
+		"filter": map[string]interface{}{"flags": 0},
+	})
+	if err != nil {
+		return err
+	}
+	for _, host := range hosts {
+		r, err := g.add("zabbix_host", host.HostID, host.Host)
+		if err != nil {
+			return err
+		}
+		if r == nil {
+			continue
+		}
+		g.hostLabels[host.HostID] = r.label
+		hostIDs = append(hostIDs, host.HostID)
+		g.register("host.id", host.HostID, r, "id")
+		g.register("host.host", host.Host, r, "host")
+		for i, hostInterface := range r.data.Get("interfaces").([]interface{}) {
+			g.register("interface.id", hostInterface.(map[string]interface{})["interface_id"].(string), r, "interfaces", i, "interface_id")
+		}
+	}
+	if len(hostIDs) == 0 {
+		return nil
+	}
+
+	var items []struct {
+		ItemID string `json:"itemid"`
+		HostID string `json:"hostid"`
+		Key    string `json:"key_"`
+	}
+	err = g.api.CallWithErrorParse("item.get", zabbix.Params{
+		"output":    []string{"itemid", "hostid", "key_"},
+		"hostids":   hostIDs,
+		"inherited": false,
+		"filter":    map[string]interface{}{"flags": 0},
+	}, &items)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		r, err := g.add("zabbix_item", item.ItemID, g.hostLabels[item.HostID]+"_"+item.Key)
+		if err != nil {
+			return err
+		}
+		if r == nil {
+			continue
+		}
+		g.register("item.id", item.ItemID, r, "id")
+	}
+
+	var lldRules []struct {
+		ItemID string `json:"itemid"`
+		HostID string `json:"hostid"`
+		Key    string `json:"key_"`
+	}
+	err = g.api.CallWithErrorParse("discoveryrule.get", zabbix.Params{
+		"output":    []string{"itemid", "hostid", "key_"},
+		"hostids":   hostIDs,
+		"inherited": false,
+	}, &lldRules)
+	if err != nil {
+		return err
+	}
+	for _, lldRule := range lldRules {
+		if _, err := g.add("zabbix_lld_rule", lldRule.ItemID, g.hostLabels[lldRule.HostID]+"_"+lldRule.Key); err != nil {
+			return err
+		}
+	}
+
+	var triggers []struct {
+		TriggerID   string `json:"triggerid"`
+		Description string `json:"description"`
+		Hosts       []struct {
+			HostID string `json:"hostid"`
+		} `json:"hosts"`
+	}
+	err = g.api.CallWithErrorParse("trigger.get", zabbix.Params{
+		"output":      []string{"triggerid", "description"},
+		"hostids":     hostIDs,
+		"inherited":   false,
+		"selectHosts": []string{"hostid"},
+		"filter":      map[string]interface{}{"flags": 0},
+	}, &triggers)
+	if err != nil {
+		return err
+	}
+	for _, trigger := range triggers {
+		name := trigger.Description
+		if len(trigger.Hosts) > 0 {
+			name = g.hostLabels[trigger.Hosts[0].HostID] + "_" + name
+		}
+		r, err := g.add("zabbix_trigger", trigger.TriggerID, name)
+		if err != nil {
+			return err
+		}
+		if r == nil {
+			continue
+		}
+		g.register("trigger.id", trigger.TriggerID, r, "id")
+	}
+
+	var actions []struct {
+		ActionID string `json:"actionid"`
+		Name     string `json:"name"`
+	}
+	err = g.api.CallWithErrorParse("action.get", zabbix.Params{
+		"output": []string{"actionid", "name"},
+	}, &actions)
+	if err != nil {
+		return err
+	}
+	for _, action := range actions {
+		if _, err := g.add("zabbix_action", action.ActionID, action.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add reads an object with the Read function of its resource, the returned resource is nil when it doesn't exist
+func (g *generator) add(resourceType, id, name string) (*generatedResource, error) {
+	resource := g.resources[resourceType]
+	d := resource.Data(nil)
+	d.SetId(id)
+	for _, diagnostic := range resource.ReadContext(context.Background(), d, g.api) {
+		if diagnostic.Severity == diag.Error {
+			return nil, fmt.Errorf("Failed to read %s %s: %s", resourceType, id, diagnostic.Summary)
+		}
+	}
+	if d.Id() == "" {
+		log.Printf("[DEBUG] %s %s doesn't exist anymore", resourceType, id)
+		return nil, nil
+	}
+
+	r := &generatedResource{
+		resourceType: resourceType,
+		label:        g.label(resourceType, name),
+		id:           id,
+		data:         d,
+	}
+	g.generated = append(g.generated, r)
+	return r, nil
+}
+
+// label returns a unique Terraform name for the object
+func (g *generator) label(resourceType, name string) string {
+	label := strings.Trim(generateLabelRegexp.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if label == "" {
+		label = strings.TrimPrefix(resourceType, "zabbix_")
+	}
+	if label[0] >= '0' && label[0] <= '9' {
+		label = "_" + label
+	}
+
+	if g.labels[resourceType] == nil {
+		g.labels[resourceType] = map[string]bool{}
+	}
+	unique := label
+	for i := 2; g.labels[resourceType][unique]; i++ {
+		unique = fmt.Sprintf("%s_%d", label, i)
+	}
+	g.labels[resourceType][unique] = true
+	return unique
+}
+
+// register records the attribute of a generated resource to reference instead of value, values shared by
+// several objects are ambiguous and kept as is
+func (g *generator) register(kind, value string, r *generatedResource, attributes ...interface{}) {
+	if value == "" {
+		return
+	}
+	traversal := hcl.Traversal{
+		hcl.TraverseRoot{Name: r.resourceType},
+		hcl.TraverseAttr{Name: r.label},
+	}
+	for _, attribute := range attributes {
+		switch a := attribute.(type) {
+		case string:
+			traversal = append(traversal, hcl.TraverseAttr{Name: a})
+		case int:
+			traversal = append(traversal, hcl.TraverseIndex{Key: cty.NumberIntVal(int64(a))})
+		}
+	}
+
+	if g.references[kind] == nil {
+		g.references[kind] = map[string]hcl.Traversal{}
+	}
+	if _, ok := g.references[kind][value]; ok {
+		g.references[kind][value] = nil
+		return
+	}
+	g.references[kind][value] = traversal
+}
+
+// reference returns the traversal to use instead of the value of the attribute at path, or nil
+func (g *generator) reference(resourceType, path string, parent map[string]interface{}, value string) hcl.Traversal {
+	kinds := generateReferences[resourceType][path]
+	if len(kinds) == 1 && generateTypedReferences[kinds[0]] != nil {
+		valueType, _ := parent["type"].(string)
+		kinds = generateTypedReferences[kinds[0]][valueType]
+	}
+	for _, kind := range kinds {
+		if traversal := g.references[kind][value]; traversal != nil {
+			return traversal
+		}
+	}
+	return nil
+}
+
+func (g *generator) write() []byte {
+	f := hclwrite.NewEmptyFile()
+	body := f.Body()
+
+	for i, r := range g.generated {
+		if i > 0 {
+			body.AppendNewline()
+		}
+
+		importBlock := body.AppendNewBlock("import", nil)
+		importBlock.Body().SetAttributeTraversal("to", hcl.Traversal{
+			hcl.TraverseRoot{Name: r.resourceType},
+			hcl.TraverseAttr{Name: r.label},
+		})
+		importBlock.Body().SetAttributeValue("id", cty.StringVal(r.id))
+		body.AppendNewline()
+
+		resourceBlock := body.AppendNewBlock("resource", []string{r.resourceType, r.label})
+		values := map[string]interface{}{}
+		for key := range g.resources[r.resourceType].Schema {
+			values[key] = r.data.Get(key)
+		}
+		g.writeBody(resourceBlock.Body(), r.resourceType, "", g.resources[r.resourceType].Schema, values)
+	}
+	header := "# Generated by terraform-provider-zabbix generate, import blocks require Terraform 1.5 or later\n\n"
+	return append([]byte(header), hclwrite.Format(f.Bytes())...)
+}
+
+// writeBody writes the arguments of a resource or a nested block, computed attributes and unset or default
+// values are left out
+func (g *generator) writeBody(body *hclwrite.Body, resourceType, path string, schemas map[string]*schema.Schema, values map[string]interface{}) {
+	keys := make([]string, 0, len(schemas))
+	for key := range schemas {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if schemas[keys[i]].Required != schemas[keys[j]].Required {
+			return schemas[keys[i]].Required
+		}
+		return keys[i] < keys[j]
+	})
+
+	for _, key := range keys {
+		s := schemas[key]
+		if !s.Required && !s.Optional {
+			continue
+		}
+		value := values[key]
+		if set, ok := value.(*schema.Set); ok {
+			value = set.List()
+		}
+		if !s.Required && generateIsDefault(s, value) {
+			continue
+		}
+
+		keyPath := key
+		if path != "" {
+			keyPath = path + "." + key
+		}
+
+		if elem, ok := s.Elem.(*schema.Resource); ok {
+			for _, v := range value.([]interface{}) {
+				if v == nil {
+					continue
+				}
+				block := body.AppendNewBlock(key, nil)
+				g.writeBody(block.Body(), resourceType, keyPath, elem.Schema, v.(map[string]interface{}))
+			}
+			continue
+		}
+
+		switch v := value.(type) {
+		case []interface{}:
+			elems := make([]hclwrite.Tokens, len(v))
+			for i, elem := range v {
+				elems[i] = g.valueTokens(resourceType, keyPath, values, elem)
+			}
+			body.SetAttributeRaw(key, hclwrite.TokensForTuple(elems))
+		case map[string]interface{}:
+			attributes := make(map[string]cty.Value, len(v))
+			for k, elem := range v {
+				attributes[k] = cty.StringVal(fmt.Sprint(elem))
+			}
+			body.SetAttributeValue(key, cty.MapVal(attributes))
+		default:
+			body.SetAttributeRaw(key, g.valueTokens(resourceType, keyPath, values, v))
+		}
+	}
+}
+
+func (g *generator) valueTokens(resourceType, path string, parent map[string]interface{}, value interface{}) hclwrite.Tokens {
+	switch v := value.(type) {
+	case string:
+		if traversal := g.reference(resourceType, path, parent, v); traversal != nil {
+			return hclwrite.TokensForTraversal(traversal)
+		}
+		return hclwrite.TokensForValue(cty.StringVal(v))
+	case int:
+		return hclwrite.TokensForValue(cty.NumberIntVal(int64(v)))
+	case float64:
+		return hclwrite.TokensForValue(cty.NumberFloatVal(v))
+	case bool:
+		return hclwrite.TokensForValue(cty.BoolVal(v))
+	default:
+		return hclwrite.TokensForValue(cty.StringVal(fmt.Sprint(v)))
+	}
+}
+
+// generateIsDefault checks whether an optional value can be left out of the configuration
+func generateIsDefault(s *schema.Schema, value interface{}) bool {
+	if s.Default != nil {
+		return reflect.DeepEqual(s.Default, value)
+	}
+	switch v := value.(type) {
+	case nil:
+		return true
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	default:
+		return reflect.ValueOf(v).IsZero()
+	}
+}
@@ -0,0 +1,84 @@
+package zabbix
+
+import (
+	"testing"
+)
+
+func TestGeneratorWrite(t *testing.T) {
+	g := newGenerator(nil)
+
+	group := g.resources["zabbix_template_group"].Data(nil)
+	group.SetId("10")
+	group.Set("name", "Templates/Linux")
+	groupResource := &generatedResource{
+		resourceType: "zabbix_template_group",
+		label:        g.label("zabbix_template_group", "Templates/Linux"),
+		id:           "10",
+		data:         group,
+	}
+	g.generated = append(g.generated, groupResource)
+	g.register("template_group.name", "Templates/Linux", groupResource, "name")
+
+	template := g.resources["zabbix_template"].Data(nil)
+	template.SetId("20")
+	template.Set("host", "Linux by agent")
+	template.Set("groups", []string{"Templates/Linux", "Other"})
+	template.Set("macro", []interface{}{
+		map[string]interface{}{"name": "AGENT_PORT", "value": "10050", "type": "text"},
+	})
+	g.generated = append(g.generated, &generatedResource{
+		resourceType: "zabbix_template",
+		label:        g.label("zabbix_template", "Linux by agent"),
+		id:           "20",
+		data:         template,
+	})
+
+	expected := `# Generated by terraform-provider-zabbix generate, import blocks require Terraform 1.5 or later
+
+import {
+  to = zabbix_template_group.templates_linux
+  id = "10"
+}
+
+resource "zabbix_template_group" "templates_linux" {
+  name = "Templates/Linux"
+}
+
+import {
+  to = zabbix_template.linux_by_agent
+  id = "20"
+}
+
+resource "zabbix_template" "linux_by_agent" {
+  groups = ["Other", zabbix_template_group.templates_linux.name]
+  host   = "Linux by agent"
+  macro {
+    name  = "AGENT_PORT"
+    value = "10050"
+  }
+}
+`
+	if content := string(g.write()); content != expected {
+		t.Fatalf("Expected configuration:\n%s\nGot:\n%s", expected, content)
+	}
+}
+
+func TestGeneratorLabel(t *testing.T) {
+	g := newGenerator(nil)
+
+	labels := []struct {
+		name     string
+		expected string
+	}{
+		{"Linux by agent", "linux_by_agent"},
+		{"Linux by agent", "linux_by_agent_2"},
+		{"system.cpu.load[all,avg1]", "system_cpu_load_all_avg1"},
+		{"1 host", "_1_host"},
+		{"!!", "item"},
+	}
+	for _, l := range labels {
+		if label := g.label("zabbix_item", l.name); label != l.expected {
+			t.Fatalf("Expected label %s for %q and got %s", l.expected, l.name, label)
+		}
+	}
+}
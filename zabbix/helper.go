@@ -1,12 +1,15 @@
 package zabbix
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
 	"time"
 
 	"github.com/claranet/go-zabbix-api"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
@@ -19,13 +22,20 @@ func sqlError(err error) bool {
 }
 
 type deleteFunc func([]string) ([]interface{}, error)
-type createFunc func(interface{}, *zabbix.API) (string, error)
-type getParentFunc func(*zabbix.API, string) (string, error)
+type createFunc func(interface{}, *zabbixClient) (string, error)
+type getParentFunc func(*zabbixClient, string) (string, error)
 
-func deleteRetry(id string, get getParentFunc, delete deleteFunc, api *zabbix.API) error {
-	return resource.Retry(time.Minute, func() *resource.RetryError {
+// deleteRetry deletes the object with its copies on the hosts linked to its template, an object the parent getter
+// doesn't find anymore being already deleted
+func deleteRetry(ctx context.Context, id string, get getParentFunc, delete deleteFunc, api *zabbixClient, timeout time.Duration) diag.Diagnostics {
+	err := resource.RetryContext(ctx, timeout, func() *resource.RetryError {
 		parentID, err := get(api, id)
 		if err != nil {
+			var notFound *notFoundError
+			if errors.As(err, &notFound) {
+				log.Printf("[DEBUG] %s, it was already deleted", err)
+				return nil
+			}
 			if sqlError(err) {
 				return resource.RetryableError(err)
 			}
@@ -56,11 +66,12 @@ func deleteRetry(id string, get getParentFunc, delete deleteFunc, api *zabbix.AP
 			return resource.NonRetryableError(err)
 		}
 	})
+	return diag.FromErr(err)
 }
 
-func createRetry(d *schema.ResourceData, meta interface{}, create createFunc, createArg interface{}, read schema.ReadFunc) error {
-	return resource.Retry(time.Minute, func() *resource.RetryError {
-		api := meta.(*zabbix.API)
+func createRetry(ctx context.Context, d *schema.ResourceData, meta interface{}, create createFunc, createArg interface{}, read schema.ReadContextFunc, timeout time.Duration) diag.Diagnostics {
+	err := resource.RetryContext(ctx, timeout, func() *resource.RetryError {
+		api := meta.(*zabbixClient)
 		id, err := create(createArg, api)
 		if err != nil {
 			if sqlError(err) {
@@ -71,11 +82,126 @@ func createRetry(d *schema.ResourceData, meta interface{}, create createFunc, cr
 		if d.Id() == "" {
 			d.SetId(id)
 		}
-
-		err = read(d, meta)
-		if err != nil {
-			return resource.NonRetryableError(err)
-		}
 		return nil
 	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	return read(ctx, d, meta)
+}
+
+// notFoundError is returned when an object managed by a resource doesn't exist on the Zabbix server anymore
+type notFoundError struct {
+	objectType string
+	id         string
+}
+
+func (e *notFoundError) Error() string {
+	return fmt.Sprintf("No %s found with id %s", e.objectType, e.id)
+}
+
+// expectOne returns a notFoundError when no object was found with the ID and an error when several were
+func expectOne(objectType, id string, count int) error {
+	switch count {
+	case 0:
+		return &notFoundError{objectType: objectType, id: id}
+	case 1:
+		return nil
+	default:
+		return fmt.Errorf("Expected one %s with id %s and got %d", objectType, id, count)
+	}
+}
+
+// readError removes the resource from the state when err is a notFoundError, so that the next plan recreates the
+// object instead of failing
+func readError(d *schema.ResourceData, err error) diag.Diagnostics {
+	var notFound *notFoundError
+	if errors.As(err, &notFound) {
+		log.Printf("[WARN] %s, removing it from the state", err)
+		d.SetId("")
+		return nil
+	}
+	return diag.FromErr(err)
+}
+
+// idFromResponse returns the single ID listed under key in the result of a create or update call
+func idFromResponse(response zabbix.Response, key string) (string, error) {
+	result, ok := response.Result.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("Unexpected response: %#v", response.Result)
+	}
+	ids, ok := result[key].([]interface{})
+	if !ok || len(ids) != 1 {
+		return "", fmt.Errorf("Expected one id in %s and got %#v", key, result[key])
+	}
+	return ids[0].(string), nil
+}
+
+type lookupFunc func(*zabbixClient, string, string) ([]string, error)
+
+// importStateByName returns an importer accepting either the ID of the object or <field>:<value> for one of fields,
+// the object is then looked up with lookup
+func importStateByName(objectType string, lookup lookupFunc, fields ...string) schema.StateContextFunc {
+	return func(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+		api := meta.(*zabbixClient)
+
+		for _, field := range fields {
+			value := strings.TrimPrefix(d.Id(), field+":")
+			if value == d.Id() {
+				continue
+			}
+
+			ids, err := lookup(api, field, value)
+			if err != nil {
+				return nil, err
+			}
+			id, err := singleImportID(objectType, fmt.Sprintf("%s %q", field, value), ids)
+			if err != nil {
+				return nil, err
+			}
+			log.Printf("[DEBUG] Importing %s with %s %s as id %s", objectType, field, value, id)
+			d.SetId(id)
+			break
+		}
+		return []*schema.ResourceData{d}, nil
+	}
+}
+
+// singleImportID returns the only ID found when importing an object described by what
+func singleImportID(objectType, what string, ids []string) (string, error) {
+	switch len(ids) {
+	case 0:
+		return "", fmt.Errorf("No %s found with %s", objectType, what)
+	case 1:
+		return ids[0], nil
+	default:
+		return "", fmt.Errorf("%d %ss found with %s, import it by id instead", len(ids), objectType, what)
+	}
+}
+
+// splitImportID splits a composite import ID in at most n parts on the slashes outside of item key parameters,
+// the last part keeps its slashes
+func splitImportID(id string, n int) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, c := range id {
+		if len(parts) == n-1 {
+			break
+		}
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			if depth > 0 {
+				depth--
+			}
+		case '/':
+			if depth == 0 {
+				parts = append(parts, id[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, id[start:])
 }
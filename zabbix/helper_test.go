@@ -0,0 +1,38 @@
+package zabbix
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestSplitImportID(t *testing.T) {
+	cases := []struct {
+		id       string
+		n        int
+		expected []string
+	}{
+		{"12345", 2, []string{"12345"}},
+		{"Linux/CPU load / 5m", 2, []string{"Linux", "CPU load / 5m"}},
+		{"Linux/vfs.fs.discovery/vfs.fs.size[{#FSNAME},pused]", 3, []string{"Linux", "vfs.fs.discovery", "vfs.fs.size[{#FSNAME},pused]"}},
+		{"Linux/vfs.file.discovery[/etc]/vfs.file.size[/etc/{#FILE}]", 3, []string{"Linux", "vfs.file.discovery[/etc]", "vfs.file.size[/etc/{#FILE}]"}},
+	}
+
+	for _, c := range cases {
+		if parts := splitImportID(c.id, c.n); !reflect.DeepEqual(parts, c.expected) {
+			t.Fatalf("Expected %q to be split in %q and got %q", c.id, c.expected, parts)
+		}
+	}
+}
+
+func TestReadError(t *testing.T) {
+	d := resourceZabbixHost().Data(nil)
+
+	d.SetId("12345")
+	if diags := readError(d, expectOne("host", d.Id(), 2)); !diags.HasError() || d.Id() != "12345" {
+		t.Fatalf("Expected an error and the id to be kept and got %v and id %q", diags, d.Id())
+	}
+	if diags := readError(d, fmt.Errorf("Failed to read: %w", expectOne("host", d.Id(), 0))); diags.HasError() || d.Id() != "" {
+		t.Fatalf("Expected the host to be removed from the state and got %v and id %q", diags, d.Id())
+	}
+}
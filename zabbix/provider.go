@@ -33,21 +33,30 @@ func Provider() *schema.Provider {
 		},
 
 		DataSourcesMap: map[string]*schema.Resource{
-			"zabbix_server": dataSourceZabbixServer(),
+			"zabbix_server":               dataSourceZabbixServer(),
+			"zabbix_configuration_export": dataSourceZabbixConfigurationExport(),
+			"zabbix_sla_sli":              dataSourceZabbixSLASLI(),
 		},
 
 		ResourcesMap: map[string]*schema.Resource{
-			"zabbix_host":              resourceZabbixHost(),
-			"zabbix_host_group":        resourceZabbixHostGroup(),
-			"zabbix_item":              resourceZabbixItem(),
-			"zabbix_trigger":           resourceZabbixTrigger(),
-			"zabbix_template":          resourceZabbixTemplate(),
-			"zabbix_template_group":    resourceZabbixTemplateGroup(),
-			"zabbix_template_link":     resourceZabbixTemplateLink(),
-			"zabbix_lld_rule":          resourceZabbixLLDRule(),
-			"zabbix_item_prototype":    resourceZabbixItemPrototype(),
-			"zabbix_trigger_prototype": resourceZabbixTriggerPrototype(),
-			"zabbix_action":            resourceZabbixAction(),
+			"zabbix_host":                 resourceZabbixHost(),
+			"zabbix_host_group":           resourceZabbixHostGroup(),
+			"zabbix_item":                 resourceZabbixItem(),
+			"zabbix_trigger":              resourceZabbixTrigger(),
+			"zabbix_template":             resourceZabbixTemplate(),
+			"zabbix_template_group":       resourceZabbixTemplateGroup(),
+			"zabbix_template_link":        resourceZabbixTemplateLink(),
+			"zabbix_lld_rule":             resourceZabbixLLDRule(),
+			"zabbix_lld_rule_link":        resourceZabbixLLDRuleLink(),
+			"zabbix_item_prototype":       resourceZabbixItemPrototype(),
+			"zabbix_trigger_prototype":    resourceZabbixTriggerPrototype(),
+			"zabbix_action":               resourceZabbixAction(),
+			"zabbix_value_map":            resourceZabbixValueMap(),
+			"zabbix_global_macro":         resourceZabbixGlobalMacro(),
+			"zabbix_user_macro":           resourceZabbixUserMacro(),
+			"zabbix_configuration_import": resourceZabbixConfigurationImport(),
+			"zabbix_service":              resourceZabbixService(),
+			"zabbix_sla":                  resourceZabbixSLA(),
 		},
 	}
 
@@ -65,28 +74,49 @@ func Provider() *schema.Provider {
 }
 
 func providerConfigure(d *schema.ResourceData, terraformVersion string) (interface{}, error) {
-	api, err := zabbix.NewAPI(d.Get("server_url").(string))
+	return newZabbixClient(
+		d.Get("server_url").(string),
+		d.Get("user").(string),
+		d.Get("password").(string),
+		fmt.Sprintf("HashiCorp/1.0 Terraform/%s", terraformVersion),
+	)
+}
+
+// newZabbixClient returns a client logged in the Zabbix server
+func newZabbixClient(serverURL, user, password, userAgent string) (*zabbixClient, error) {
+	api, err := zabbix.NewAPI(serverURL)
 	if err != nil {
 		return nil, err
 	}
+	client := &zabbixClient{API: api, cache: &apiCache{}}
 
-	api.UserAgent = fmt.Sprintf("HashiCorp/1.0 Terraform/%s", terraformVersion)
+	api.UserAgent = userAgent
 
+	transport := &compatTransport{transport: http.DefaultTransport}
 	if logging.IsDebugOrHigher() {
-		httpClient := http.Client{}
-		httpClient.Transport = logging.NewTransport("Zabbix", http.DefaultTransport)
-		api.SetClient(&httpClient)
+		transport.transport = logging.NewTransport("Zabbix", http.DefaultTransport)
+	}
+	transport.onWrite = client.cache.written
+	api.SetClient(&http.Client{Transport: transport})
+
+	// The version is needed to rewrite the login request, so it is asked before
+	serverVersion, err := api.Version()
+	if err != nil {
+		return nil, err
+	}
+	if err := transport.setVersion(serverVersion); err != nil {
+		return nil, err
 	}
 
-	if _, err := api.Login(d.Get("user").(string), d.Get("password").(string)); err != nil {
+	if _, err := api.Login(user, password); err != nil {
 		return nil, err
 	}
 
-	return api, nil
+	return client, nil
 }
 
 func getZabbixServerVersion(meta interface{}) string {
-	api := meta.(*zabbix.API)
+	api := meta.(*zabbixClient)
 	v, err := api.Version()
 	if err != nil {
 		log.Printf("[WARN] Failed to get Zabbix Server version: %v\n", err)
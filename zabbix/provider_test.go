@@ -12,6 +12,30 @@ import (
 var testAccProviders map[string]*schema.Provider
 var testAccProvider *schema.Provider
 
+// testAccFakeServer is the fake Zabbix server the acceptance tests run against when ZABBIX_SERVER_URL is not set
+var testAccFakeServer *fakeZabbixServer
+
+// TestMain starts the fake Zabbix server unless a real one is configured, so that the acceptance tests enabled with
+// TF_ACC run offline by default
+func TestMain(m *testing.M) {
+	if os.Getenv("ZABBIX_SERVER_URL") == "" {
+		serverVersion := os.Getenv("ZABBIX_FAKE_SERVER_VERSION")
+		if serverVersion == "" {
+			serverVersion = "6.4.0"
+		}
+		testAccFakeServer = newFakeZabbixServer(serverVersion)
+		os.Setenv("ZABBIX_SERVER_URL", testAccFakeServer.URL+"/api_jsonrpc.php")
+		os.Setenv("ZABBIX_USER", fakeZabbixUser)
+		os.Setenv("ZABBIX_PASSWORD", fakeZabbixPassword)
+	}
+
+	code := m.Run()
+	if testAccFakeServer != nil {
+		testAccFakeServer.Close()
+	}
+	os.Exit(code)
+}
+
 func TestProvider(t *testing.T) {
 	if err := Provider().InternalValidate(); err != nil {
 		t.Fatalf("err: %s", err)
@@ -45,3 +69,10 @@ func testAccPreCheck(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// testAccPreCheckRealServer skips the tests relying on API methods that the fake server does not implement
+func testAccPreCheckRealServer(t *testing.T) {
+	if testAccFakeServer != nil {
+		t.Skip("ZABBIX_SERVER_URL must be set for this acceptance test, the fake Zabbix server does not implement it")
+	}
+}
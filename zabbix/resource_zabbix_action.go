@@ -2,12 +2,15 @@ package zabbix
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"log"
-	"strings"
 
 	"github.com/claranet/go-zabbix-api"
+	"github.com/hashicorp/go-version"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/hashcode"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
@@ -40,6 +43,49 @@ var ActionEvaluationTypeStringMap = map[zabbix.ActionEvaluationType]string{
 	zabbix.Custom: "custom",
 }
 
+var StringActionOperationEvaluationTypeMap = map[string]string{
+	"and/or": "0",
+	"and":    "1",
+	"or":     "2",
+}
+
+var ActionOperationEvaluationTypeStringMap = map[string]string{
+	"0": "and/or",
+	"1": "and",
+	"2": "or",
+}
+
+var StringActionOperationConditionTypeMap = map[string]string{
+	"event_acknowledged": "14",
+}
+
+var ActionOperationConditionTypeStringMap = map[string]string{
+	"14": "event_acknowledged",
+}
+
+// The operators of the conditions of operations are the values they compare the events with
+var StringActionOperationConditionOperatorMap = map[string]string{
+	"yes": "1",
+	"no":  "0",
+}
+
+var ActionOperationConditionOperatorStringMap = map[string]string{
+	"1": "yes",
+	"0": "no",
+}
+
+// actionOperationConditions are the conditions of an operation, which the library does not support
+type actionOperationConditions struct {
+	EvaluationType string                     `json:"evaltype"`
+	Conditions     []actionOperationCondition `json:"opconditions"`
+}
+
+type actionOperationCondition struct {
+	ConditionType string `json:"conditiontype"`
+	Operator      string `json:"operator"`
+	Value         string `json:"value"`
+}
+
 var StringActionConditionTypeMap = map[string]zabbix.ActionConditionType{
 	"host_group":                  zabbix.HostGroupCondition,
 	"host":                        zabbix.HostCondition,
@@ -296,10 +342,10 @@ func OperationCommandHash(v interface{}) int {
 var actionOperationMessageSchema = &schema.Resource{
 	Schema: map[string]*schema.Schema{
 		"default_message": {
-			Type:     schema.TypeBool,
-			Optional: true,
-			// FIXME: default true on Zabbix 5.0 or later
-			Default: false,
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Computed:    true,
+			Description: "Send the message of the media type. Defaults to true on Zabbix 5.0 or later when the subject and message are empty, false otherwise.",
 		},
 		"media_type_id": {
 			Type:     schema.TypeString,
@@ -307,14 +353,16 @@ var actionOperationMessageSchema = &schema.Resource{
 			Default:  "0", // NOTE: ALL
 		},
 		"subject": {
-			Type:     schema.TypeString,
-			Optional: true,
-			Default:  "",
+			Type:             schema.TypeString,
+			Optional:         true,
+			Default:          "",
+			DiffSuppressFunc: suppressMediaTypeMessageDiff,
 		},
 		"message": {
-			Type:     schema.TypeString,
-			Optional: true,
-			Default:  "",
+			Type:             schema.TypeString,
+			Optional:         true,
+			Default:          "",
+			DiffSuppressFunc: suppressMediaTypeMessageDiff,
 		},
 		"target": {
 			Type:     schema.TypeSet,
@@ -344,10 +392,10 @@ var actionOperationMessageSchema = &schema.Resource{
 var actionRecoveryUpdateOperationMessageSchema = &schema.Resource{
 	Schema: map[string]*schema.Schema{
 		"default_message": {
-			Type:     schema.TypeBool,
-			Optional: true,
-			// FIXME: default true on Zabbix 5.0 or later
-			Default: false,
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Computed:    true,
+			Description: "Send the message of the media type. Defaults to true on Zabbix 5.0 or later when the subject and message are empty, false otherwise.",
 		},
 		"media_type_id": {
 			Type:     schema.TypeString,
@@ -355,14 +403,16 @@ var actionRecoveryUpdateOperationMessageSchema = &schema.Resource{
 			Default:  "0", // NOTE: ALL
 		},
 		"subject": {
-			Type:     schema.TypeString,
-			Optional: true,
-			Default:  "",
+			Type:             schema.TypeString,
+			Optional:         true,
+			Default:          "",
+			DiffSuppressFunc: suppressMediaTypeMessageDiff,
 		},
 		"message": {
-			Type:     schema.TypeString,
-			Optional: true,
-			Default:  "",
+			Type:             schema.TypeString,
+			Optional:         true,
+			Default:          "",
+			DiffSuppressFunc: suppressMediaTypeMessageDiff,
 		},
 		"target": {
 			Type:     schema.TypeSet,
@@ -400,14 +450,24 @@ func OperationMessageHash(v interface{}) int {
 
 func resourceZabbixAction() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceZabbixActionCreate,
-		Read:   resourceZabbixActionRead,
-		Exists: resourceZabbixActionExists,
-		Update: resourceZabbixActionUpdate,
-		Delete: resourceZabbixActionDelete,
+		CreateContext: resourceZabbixActionCreate,
+		ReadContext:   resourceZabbixActionRead,
+		UpdateContext: resourceZabbixActionUpdate,
+		DeleteContext: resourceZabbixActionDelete,
 		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
+			StateContext: schema.ImportStatePassthroughContext,
 		},
+		CustomizeDiff: customizeDiffVersion(
+			removedIn("5.0", "default_subject", ""),
+			removedIn("5.0", "default_message", ""),
+			removedIn("5.0", "recovery_subject", ""),
+			removedIn("5.0", "recovery_message", ""),
+			removedIn("5.0", "update_subject", ""),
+			removedIn("5.0", "update_message", ""),
+			valueRemovedIn("5.4", "condition.*.type", "application"),
+			durations("default_step_duration", "operation.*.step_duration"),
+			actionOperationConditionsCheck,
+		),
 		Schema: map[string]*schema.Schema{
 			"default_step_duration": {
 				Type:     schema.TypeString,
@@ -533,6 +593,10 @@ func resourceZabbixAction() *schema.Resource {
 						"value": {
 							Type:     schema.TypeString,
 							Required: true,
+							Description: "Value of the condition. Host groups, hosts, templates, proxies and discovery rules are " +
+								"referenced by name, triggers as <host>:<description> and discovery checks as " +
+								"<discovery rule>:<type of check>, or by ID except for host groups. Values made of digits " +
+								"are IDs, which must exist.",
 						},
 						"value2": {
 							Type:     schema.TypeString,
@@ -567,7 +631,6 @@ func resourceZabbixAction() *schema.Resource {
 					},
 				},
 			},
-			// FIXME: implement opconditions
 			"operation": {
 				Type:     schema.TypeList,
 				Optional: true,
@@ -642,6 +705,41 @@ func resourceZabbixAction() *schema.Resource {
 								false,
 							),
 						},
+						"calculation": {
+							Type:        schema.TypeString,
+							Optional:    true,
+							Default:     "and/or",
+							Description: "How the conditions of the operation are combined, only set with conditions.",
+							ValidateFunc: validation.StringInSlice(
+								[]string{"and/or", "and", "or"},
+								false,
+							),
+						},
+						"condition": {
+							Type:        schema.TypeList,
+							Optional:    true,
+							Description: "Conditions of the operation, for the actions of events supporting escalations.",
+							Elem: &schema.Resource{
+								Schema: map[string]*schema.Schema{
+									"type": {
+										Type:     schema.TypeString,
+										Required: true,
+										ValidateFunc: validation.StringInSlice(
+											[]string{"event_acknowledged"},
+											false,
+										),
+									},
+									"operator": {
+										Type:     schema.TypeString,
+										Required: true,
+										ValidateFunc: validation.StringInSlice(
+											[]string{"yes", "no"},
+											false,
+										),
+									},
+								},
+							},
+						},
 					},
 				},
 			},
@@ -721,28 +819,33 @@ func resourceZabbixAction() *schema.Resource {
 	}
 }
 
-func createActionObject(d *schema.ResourceData, api *zabbix.API) (*zabbix.Action, error) {
+func createActionObject(d *schema.ResourceData, api *zabbixClient) (*zabbix.Action, []actionOperationConditions, error) {
 	status := zabbix.Disabled
 	if d.Get("enabled").(bool) {
 		status = zabbix.Enabled
 	}
 
 	eventSource := StringEventTypeMap[d.Get("event_source").(string)]
-	supportEscalation := eventSource == zabbix.TriggerEvent || eventSource == zabbix.InternalEvent
+	supportEscalation := actionSupportsEscalation(eventSource)
 
-	ope, err := createActionOperationObject(supportEscalation, d.Get("operation").([]interface{}), api)
+	ope, err := createActionOperationObject(supportEscalation, d.Get("operation").([]interface{}), actionMessageConfigs(d, "operation"), api)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	recOpe, err := createActionRecoveryOperationObject(d.Get("recovery_operation").([]interface{}), api)
+	opeConditions, err := createActionOperationConditions(supportEscalation, d.Get("operation").([]interface{}))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	upOpe, err := createActionUpdateOperationObject(d.Get("update_operation").([]interface{}), api)
+	recOpe, err := createActionRecoveryOperationObject(d.Get("recovery_operation").([]interface{}), actionMessageConfigs(d, "recovery_operation"), api)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	upOpe, err := createActionUpdateOperationObject(d.Get("update_operation").([]interface{}), actionMessageConfigs(d, "update_operation"), api)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	var period string
@@ -752,7 +855,7 @@ func createActionObject(d *schema.ResourceData, api *zabbix.API) (*zabbix.Action
 
 	conditions, err := createActionConditionObject(d.Get("condition").([]interface{}), api)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	action := zabbix.Action{
@@ -786,34 +889,104 @@ func createActionObject(d *schema.ResourceData, api *zabbix.API) (*zabbix.Action
 		}
 	}
 
-	return &action, nil
+	return &action, opeConditions, nil
+}
+
+// actionSupportsEscalation is whether the actions of an event source have escalation steps and operation conditions
+func actionSupportsEscalation(eventSource zabbix.EventType) bool {
+	return eventSource == zabbix.TriggerEvent || eventSource == zabbix.InternalEvent
+}
+
+// actionOperationConditionsCheck rejects the operation conditions of actions without escalations, and the
+// calculation of operations without conditions, which Zabbix doesn't store
+func actionOperationConditionsCheck(d *schema.ResourceDiff, serverVersion *version.Version) error {
+	if !d.NewValueKnown("event_source") || !d.NewValueKnown("operation") {
+		return nil
+	}
+	supportEscalation := actionSupportsEscalation(StringEventTypeMap[d.Get("event_source").(string)])
+	for i, o := range d.Get("operation").([]interface{}) {
+		m, ok := o.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		conditions := m["condition"].([]interface{})
+		switch {
+		case len(conditions) > 0 && !supportEscalation:
+			return fmt.Errorf("operation %d: conditions are only supported by the actions of trigger and internal events", i+1)
+		case len(conditions) == 0 && m["calculation"] != "and/or":
+			return fmt.Errorf("operation %d: calculation %s requires conditions", i+1, m["calculation"])
+		}
+	}
+	return nil
+}
+
+// createActionOperationConditions returns the conditions of the operations, in the order of the operations
+func createActionOperationConditions(supportEscalation bool, lst []interface{}) ([]actionOperationConditions, error) {
+	items := make([]actionOperationConditions, len(lst))
+	for i, v := range lst {
+		m := v.(map[string]interface{})
+		conditions := m["condition"].([]interface{})
+		if len(conditions) > 0 && !supportEscalation {
+			return nil, fmt.Errorf("operation %d: conditions are only supported by the actions of trigger and internal events", i+1)
+		}
+
+		items[i].EvaluationType = StringActionOperationEvaluationTypeMap[m["calculation"].(string)]
+		for _, c := range conditions {
+			condition := c.(map[string]interface{})
+			items[i].Conditions = append(items[i].Conditions, actionOperationCondition{
+				ConditionType: StringActionOperationConditionTypeMap[condition["type"].(string)],
+				Operator:      "0",
+				Value:         StringActionOperationConditionOperatorMap[condition["operator"].(string)],
+			})
+		}
+	}
+	return items, nil
 }
 
-func createActionConditionObject(lst []interface{}, api *zabbix.API) (items zabbix.ActionFilterConditions, err error) {
-	for _, v := range lst {
+// actionRequest returns the parameters of a request creating or updating an action, with the conditions of its
+// operations that the library does not send
+func actionRequest(action *zabbix.Action, conditions []actionOperationConditions) (map[string]interface{}, error) {
+	body, err := json.Marshal(action)
+	if err != nil {
+		return nil, err
+	}
+	var params map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&params); err != nil {
+		return nil, err
+	}
+
+	operations, _ := params["operations"].([]interface{})
+	for i, c := range conditions {
+		if len(c.Conditions) == 0 || i >= len(operations) {
+			continue
+		}
+		operation := operations[i].(map[string]interface{})
+		operation["evaltype"] = c.EvaluationType
+		operation["opconditions"] = c.Conditions
+	}
+	return params, nil
+}
+
+func createActionConditionObject(lst []interface{}, api *zabbixClient) (items zabbix.ActionFilterConditions, err error) {
+	values, err := actionConditionIDs(lst, api)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, v := range lst {
 		m := v.(map[string]interface{})
 		conditionType := m["type"].(string)
-		value := m["value"].(string)
 
-		// Convert host group name to ID if condition type is host_group
-		if conditionType == "host_group" {
-			res, err := api.HostGroupsGet(zabbix.Params{
-				"output": []string{"groupid"},
-				"filter": map[string]interface{}{"name": value},
-			})
-			if err != nil {
-				return nil, err
-			}
-			if len(res) == 0 {
-				return nil, fmt.Errorf("host group not found: %s", value)
-			}
-			value = res[0].GroupID
+		if conditionType == "application" && api.ServerVersion.GreaterThanOrEqual(version.Must(version.NewVersion("5.4"))) {
+			return nil, fmt.Errorf("the application condition is not supported since Zabbix 5.4, which replaced applications with tags")
 		}
 
 		item := zabbix.ActionFilterCondition{
 			ConditionID:   m["condition_id"].(string),
 			ConditionType: StringActionConditionTypeMap[conditionType],
-			Value:         value,
+			Value:         values[i],
 			Value2:        m["value2"].(string),
 			FormulaID:     m["formula_id"].(string),
 			Operator:      StringActionFilterConditionOperatorMap[m["operator"].(string)],
@@ -824,8 +997,8 @@ func createActionConditionObject(lst []interface{}, api *zabbix.API) (items zabb
 	return
 }
 
-func createActionOperationObject(supportEscalation bool, lst []interface{}, api *zabbix.API) (items zabbix.ActionOperations, err error) {
-	for _, v := range lst {
+func createActionOperationObject(supportEscalation bool, lst []interface{}, messages []*actionMessageConfig, api *zabbixClient) (items zabbix.ActionOperations, err error) {
+	for i, v := range lst {
 		m := v.(map[string]interface{})
 
 		cmd, cmdHostGroups, cmdHosts, err := createActionOperationCommand(m["command"].([]interface{}), api)
@@ -839,7 +1012,7 @@ func createActionOperationObject(supportEscalation bool, lst []interface{}, api
 		}
 
 		opeType := StringActionOperationTypeMap[m["type"].(string)]
-		msg, msgUserGroups, msgUsers, err := createActionOperationMessage(m["message"].([]interface{}), opeType, api)
+		msg, msgUserGroups, msgUsers, err := createActionOperationMessage(m["message"].([]interface{}), actionMessageConfigAt(messages, i), opeType, api)
 		if err != nil {
 			return nil, err
 		}
@@ -885,8 +1058,8 @@ func createActionOperationObject(supportEscalation bool, lst []interface{}, api
 	return
 }
 
-func createActionRecoveryOperationObject(lst []interface{}, api *zabbix.API) (items zabbix.ActionRecoveryOperations, err error) {
-	for _, v := range lst {
+func createActionRecoveryOperationObject(lst []interface{}, messages []*actionMessageConfig, api *zabbixClient) (items zabbix.ActionRecoveryOperations, err error) {
+	for i, v := range lst {
 		m := v.(map[string]interface{})
 
 		cmd, cmdHostGroups, cmdHosts, err := createActionOperationCommand(m["command"].([]interface{}), api)
@@ -900,7 +1073,7 @@ func createActionRecoveryOperationObject(lst []interface{}, api *zabbix.API) (it
 		} else {
 			opeType = StringActionOperationTypeMap[t]
 		}
-		msg, msgUserGroups, msgUsers, err := createActionOperationMessage(m["message"].([]interface{}), opeType, api)
+		msg, msgUserGroups, msgUsers, err := createActionOperationMessage(m["message"].([]interface{}), actionMessageConfigAt(messages, i), opeType, api)
 		if err != nil {
 			return nil, err
 		}
@@ -920,8 +1093,8 @@ func createActionRecoveryOperationObject(lst []interface{}, api *zabbix.API) (it
 	return
 }
 
-func createActionUpdateOperationObject(lst []interface{}, api *zabbix.API) (items zabbix.ActionUpdateOperations, err error) {
-	for _, v := range lst {
+func createActionUpdateOperationObject(lst []interface{}, messages []*actionMessageConfig, api *zabbixClient) (items zabbix.ActionUpdateOperations, err error) {
+	for i, v := range lst {
 		m := v.(map[string]interface{})
 
 		cmd, cmdHostGroups, cmdHosts, err := createActionOperationCommand(m["command"].([]interface{}), api)
@@ -935,7 +1108,7 @@ func createActionUpdateOperationObject(lst []interface{}, api *zabbix.API) (item
 		} else {
 			opeType = StringActionOperationTypeMap[t]
 		}
-		msg, msgUserGroups, msgUsers, err := createActionOperationMessage(m["message"].([]interface{}), opeType, api)
+		msg, msgUserGroups, msgUsers, err := createActionOperationMessage(m["message"].([]interface{}), actionMessageConfigAt(messages, i), opeType, api)
 		if err != nil {
 			return nil, err
 		}
@@ -955,7 +1128,7 @@ func createActionUpdateOperationObject(lst []interface{}, api *zabbix.API) (item
 	return
 }
 
-func createActionOperationCommand(lst []interface{}, api *zabbix.API) (
+func createActionOperationCommand(lst []interface{}, api *zabbixClient) (
 	cmd *zabbix.ActionOperationCommand,
 	groups zabbix.ActionOperationCommandHostGroups,
 	hosts zabbix.ActionOperationCommandHosts,
@@ -992,40 +1165,14 @@ func createActionOperationCommand(lst []interface{}, api *zabbix.API) (
 		}
 	}
 
-	groupMap := map[string]string{}
-	if len(groupNames) > 0 {
-		params := zabbix.Params{
-			"output": []string{"name", "groupid"},
-			"filter": map[string]interface{}{
-				"name": groupNames,
-			},
-		}
-
-		res, err := api.HostGroupsGet(params)
-		if err != nil {
-			return nil, nil, nil, err
-		}
-		for _, g := range res {
-			groupMap[g.Name] = g.GroupID
-		}
+	groupMap, err := api.lookupIDs(lookupHostGroup, groupNames)
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
-	hostMap := map[string]string{}
-	if len(hostNames) > 0 {
-		params := zabbix.Params{
-			"output": []string{"host", "hostid"},
-			"filter": map[string]interface{}{
-				"host": hostNames,
-			},
-		}
-
-		res, err := api.HostsGet(params)
-		if err != nil {
-			return nil, nil, nil, err
-		}
-		for _, h := range res {
-			hostMap[h.Host] = h.HostID
-		}
+	hostMap, err := api.lookupIDs(lookupHost, hostNames)
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
 	for _, t := range targets {
@@ -1049,7 +1196,7 @@ func createActionOperationCommand(lst []interface{}, api *zabbix.API) (
 	return
 }
 
-func createActionOperationHostGroups(lst []interface{}, api *zabbix.API) (
+func createActionOperationHostGroups(lst []interface{}, api *zabbixClient) (
 	groups zabbix.ActionOperationHostGroups,
 	err error) {
 	if len(lst) == 0 {
@@ -1061,27 +1208,22 @@ func createActionOperationHostGroups(lst []interface{}, api *zabbix.API) (
 		groupNames = append(groupNames, g.(string))
 	}
 
-	params := zabbix.Params{
-		"output": []string{"groupid"},
-		"filter": map[string]interface{}{
-			"name": groupNames,
-		},
-	}
-
-	res, err := api.HostGroupsGet(params)
+	groupIDs, err := api.lookupIDs(lookupHostGroup, groupNames)
 	if err != nil {
 		return nil, err
 	}
-	for _, g := range res {
-		groups = append(groups, zabbix.ActionOperationHostGroup{
-			GroupID: g.GroupID,
-		})
+	for _, name := range groupNames {
+		if id, ok := groupIDs[name]; ok {
+			groups = append(groups, zabbix.ActionOperationHostGroup{
+				GroupID: id,
+			})
+		}
 	}
 
 	return
 }
 
-func createActionOperationMessage(lst []interface{}, operationType zabbix.ActionOperationType, api *zabbix.API) (
+func createActionOperationMessage(lst []interface{}, config *actionMessageConfig, operationType zabbix.ActionOperationType, api *zabbixClient) (
 	msg *zabbix.ActionOperationMessage,
 	groups zabbix.ActionOperationMessageUserGroups,
 	users zabbix.ActionOperationMessageUsers,
@@ -1093,9 +1235,18 @@ func createActionOperationMessage(lst []interface{}, operationType zabbix.Action
 
 	subject := m["subject"].(string)
 	message := m["message"].(string)
+	defaultMessage := m["default_message"].(bool)
+	if config != nil {
+		// The subject and message read from the media type are not sent back
+		subject, message = config.subject, config.message
+		if !config.defaultMessageSet {
+			// From Zabbix 5.0, media types template the messages of the operations that do not set their own
+			defaultMessage = versionAtLeast(api.ServerVersion, "5.0") && subject == "" && message == ""
+		}
+	}
 
 	var defMsg, mediaTypeID string
-	if m["default_message"].(bool) {
+	if defaultMessage {
 		// When using default message, subject and message must be empty
 		if subject != "" || message != "" {
 			err = fmt.Errorf("subject and message must be empty when default_message is true")
@@ -1132,41 +1283,26 @@ func createActionOperationMessage(lst []interface{}, operationType zabbix.Action
 		}
 	}
 
-	if len(groupNames) > 0 {
-		params := zabbix.Params{
-			"output": []string{"usrgrpid"},
-			"filter": map[string]interface{}{
-				"name": groupNames,
-			},
-		}
-
-		res, err := api.UserGroupsGet(params)
-		if err != nil {
-			return nil, nil, nil, err
-		}
-		for _, g := range res {
+	groupIDs, err := api.lookupIDs(lookupUserGroup, groupNames)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for _, name := range groupNames {
+		if id, ok := groupIDs[name]; ok {
 			groups = append(groups, zabbix.ActionOperationMessageUserGroup{
-				UserGroupID: g.GroupID,
+				UserGroupID: id,
 			})
 		}
 	}
 
-	if len(userNames) > 0 {
-		params := zabbix.Params{
-			"output": []string{"userid"},
-			"filter": map[string]interface{}{
-				"alias":    userNames,
-				"username": userNames,
-			},
-		}
-
-		res, err := api.UsersGet(params)
-		if err != nil {
-			return nil, nil, nil, err
-		}
-		for _, u := range res {
+	userIDs, err := api.lookupIDs(lookupUser, userNames)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for _, name := range userNames {
+		if id, ok := userIDs[name]; ok {
 			users = append(users, zabbix.ActionOperationMessageUser{
-				UserID: u.UserID,
+				UserID: id,
 			})
 		}
 	}
@@ -1174,7 +1310,7 @@ func createActionOperationMessage(lst []interface{}, operationType zabbix.Action
 	return
 }
 
-func createActionOperationTemplates(lst []interface{}, api *zabbix.API) (
+func createActionOperationTemplates(lst []interface{}, api *zabbixClient) (
 	templates zabbix.ActionOperationTemplates,
 	err error) {
 	if len(lst) == 0 {
@@ -1186,54 +1322,75 @@ func createActionOperationTemplates(lst []interface{}, api *zabbix.API) (
 		templateNames = append(templateNames, g.(string))
 	}
 
-	params := zabbix.Params{
-		"output": []string{"templateid"},
-		"filter": map[string]interface{}{
-			"host": templateNames,
-		},
-	}
-
-	res, err := api.TemplatesGet(params)
+	templateIDs, err := api.lookupIDs(lookupTemplate, templateNames)
 	if err != nil {
 		return nil, err
 	}
-	for _, t := range res {
-		templates = append(templates, zabbix.ActionOperationTemplate{
-			TemplateID: t.TemplateID,
-		})
+	for _, name := range templateNames {
+		if id, ok := templateIDs[name]; ok {
+			templates = append(templates, zabbix.ActionOperationTemplate{
+				TemplateID: id,
+			})
+		}
 	}
 	return
 }
 
-func resourceZabbixActionCreate(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixActionCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
 
-	action, err := createActionObject(d, api)
+	action, opeConditions, err := createActionObject(d, api)
 
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 
-	actions := zabbix.Actions{*action}
+	params, err := actionRequest(action, opeConditions)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
-	err = api.ActionsCreate(actions)
+	response, err := api.CallWithError("action.create", []interface{}{params})
 
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 
-	id := actions[0].ActionID
+	id, err := idFromResponse(response, "actionids")
+	if err != nil {
+		return diag.FromErr(err)
+	}
 	d.SetId(id)
 
-	return resourceZabbixActionRead(d, meta)
+	return resourceZabbixActionRead(ctx, d, meta)
 }
 
-func resourceZabbixActionRead(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixActionRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
+
+	// ActionGetByID fails the same way whether the action is missing or not, so it is looked up first, with the
+	// conditions of the operations that the library does not read
+	var actions []struct {
+		Operations []struct {
+			OperationID string `json:"operationid"`
+			actionOperationConditions
+		} `json:"operations"`
+	}
+	err := api.CallWithErrorParse("action.get", zabbix.Params{
+		"output":           []string{"actionid"},
+		"actionids":        d.Id(),
+		"selectOperations": "extend",
+	}, &actions)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err := expectOne("action", d.Id(), len(actions)); err != nil {
+		return readError(d, err)
+	}
 
 	action, err := api.ActionGetByID(d.Id())
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 
 	d.Set("default_step_duration", action.Period)
@@ -1250,27 +1407,33 @@ func resourceZabbixActionRead(d *schema.ResourceData, meta interface{}) error {
 	d.Set("calculation", ActionEvaluationTypeStringMap[action.Filter.EvaluationType])
 	d.Set("formula", action.Filter.Formula)
 
-	conditions, err := readActionConditions(action.Filter.Conditions, action.Filter.EvaluationType, api)
+	conditions, err := readActionConditions(action.Filter.Conditions, action.Filter.EvaluationType, d.Get("condition").([]interface{}), api)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 	d.Set("condition", conditions)
 
-	operations, err := readActionOperations(action.Operations, api)
+	opeConditions := map[string]actionOperationConditions{}
+	for _, operation := range actions[0].Operations {
+		opeConditions[operation.OperationID] = operation.actionOperationConditions
+	}
+
+	templates := newMediaTypeMessageTemplates(api, EventTypeStringMap[action.EventSource])
+	operations, err := readActionOperations(action.Operations, opeConditions, templates, api)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 	d.Set("operation", operations)
 
-	recOpe, err := readActionRecoveryOperations(action.RecoveryOperations, api)
+	recOpe, err := readActionRecoveryOperations(action.RecoveryOperations, templates, api)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 	d.Set("recovery_operation", recOpe)
 
-	upOpe, err := readActionUpdateOperations(action.UpdateOperations, api)
+	upOpe, err := readActionUpdateOperations(action.UpdateOperations, templates, api)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 	d.Set("update_operation", upOpe)
 
@@ -1278,24 +1441,17 @@ func resourceZabbixActionRead(d *schema.ResourceData, meta interface{}) error {
 	return nil
 }
 
-func readActionConditions(cds zabbix.ActionFilterConditions, evaluationType zabbix.ActionEvaluationType, api *zabbix.API) (lst []interface{}, err error) {
-	for _, v := range cds {
+func readActionConditions(cds zabbix.ActionFilterConditions, evaluationType zabbix.ActionEvaluationType, state []interface{}, api *zabbixClient) (lst []interface{}, err error) {
+	values, err := actionConditionNames(cds, state, api)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, v := range cds {
 		m := map[string]interface{}{}
 		m["condition_id"] = v.ConditionID
-		conditionType := ActionConditionTypeStringMap[v.ConditionType]
-		m["type"] = conditionType
-
-		value := v.Value
-		// Convert host group ID to name if condition type is host_group
-		if conditionType == "host_group" {
-			group, err := api.HostGroupGetByID(value)
-			if err != nil {
-				return nil, err
-			}
-			value = group.Name
-		}
-
-		m["value"] = value
+		m["type"] = ActionConditionTypeStringMap[v.ConditionType]
+		m["value"] = values[i]
 		m["value2"] = v.Value2
 		// Only save formula_id when calculation is "custom"
 		if evaluationType == zabbix.Custom {
@@ -1308,7 +1464,7 @@ func readActionConditions(cds zabbix.ActionFilterConditions, evaluationType zabb
 	return
 }
 
-func readActionOperations(ops zabbix.ActionOperations, api *zabbix.API) (lst []interface{}, err error) {
+func readActionOperations(ops zabbix.ActionOperations, conditions map[string]actionOperationConditions, templates *mediaTypeMessageTemplates, api *zabbixClient) (lst []interface{}, err error) {
 	for _, v := range ops {
 		m := map[string]interface{}{}
 		m["operation_id"] = v.OperationID
@@ -1329,7 +1485,7 @@ func readActionOperations(ops zabbix.ActionOperations, api *zabbix.API) (lst []i
 		}
 		m["host_groups"] = hostGroups
 
-		messages, err := readActionOperationMessages(v.Message, v.MessageUserGroups, v.MessageUsers, api)
+		messages, err := readActionOperationMessages(v.Message, v.MessageUserGroups, v.MessageUsers, templates, messageTemplateOperation, api)
 		if err != nil {
 			return nil, err
 		}
@@ -1345,13 +1501,26 @@ func readActionOperations(ops zabbix.ActionOperations, api *zabbix.API) (lst []i
 			m["inventory_mode"] = ActionOperationInventoryModeStringMap[v.Inventory.InventoryMode]
 		}
 
+		m["calculation"] = "and/or"
+		if calculation, ok := ActionOperationEvaluationTypeStringMap[conditions[v.OperationID].EvaluationType]; ok {
+			m["calculation"] = calculation
+		}
+		var opConditions []interface{}
+		for _, c := range conditions[v.OperationID].Conditions {
+			opConditions = append(opConditions, map[string]interface{}{
+				"type":     ActionOperationConditionTypeStringMap[c.ConditionType],
+				"operator": ActionOperationConditionOperatorStringMap[c.Value],
+			})
+		}
+		m["condition"] = opConditions
+
 		lst = append(lst, m)
 	}
 
 	return
 }
 
-func readActionRecoveryOperations(ops zabbix.ActionRecoveryOperations, api *zabbix.API) (lst []interface{}, err error) {
+func readActionRecoveryOperations(ops zabbix.ActionRecoveryOperations, templates *mediaTypeMessageTemplates, api *zabbixClient) (lst []interface{}, err error) {
 	for _, v := range ops {
 		m := map[string]interface{}{}
 		m["operation_id"] = v.OperationID
@@ -1363,7 +1532,7 @@ func readActionRecoveryOperations(ops zabbix.ActionRecoveryOperations, api *zabb
 		}
 		m["command"] = commands
 
-		messages, err := readActionOperationMessages(v.Message, v.MessageUserGroups, v.MessageUsers, api)
+		messages, err := readActionOperationMessages(v.Message, v.MessageUserGroups, v.MessageUsers, templates, messageTemplateRecoveryOperation, api)
 		if err != nil {
 			return nil, err
 		}
@@ -1375,7 +1544,7 @@ func readActionRecoveryOperations(ops zabbix.ActionRecoveryOperations, api *zabb
 	return
 }
 
-func readActionUpdateOperations(ops zabbix.ActionUpdateOperations, api *zabbix.API) (lst []interface{}, err error) {
+func readActionUpdateOperations(ops zabbix.ActionUpdateOperations, templates *mediaTypeMessageTemplates, api *zabbixClient) (lst []interface{}, err error) {
 	for _, v := range ops {
 		m := map[string]interface{}{}
 		m["operation_id"] = v.OperationID
@@ -1387,7 +1556,7 @@ func readActionUpdateOperations(ops zabbix.ActionUpdateOperations, api *zabbix.A
 		}
 		m["command"] = commands
 
-		messages, err := readActionOperationMessages(v.Message, v.MessageUserGroups, v.MessageUsers, api)
+		messages, err := readActionOperationMessages(v.Message, v.MessageUserGroups, v.MessageUsers, templates, messageTemplateUpdateOperation, api)
 		if err != nil {
 			return nil, err
 		}
@@ -1403,7 +1572,7 @@ func readActionOperationCommands(
 	cmd *zabbix.ActionOperationCommand,
 	groups zabbix.ActionOperationCommandHostGroups,
 	hosts zabbix.ActionOperationCommandHosts,
-	api *zabbix.API) (lst []interface{}, err error) {
+	api *zabbixClient) (lst []interface{}, err error) {
 	if cmd == nil {
 		return
 	}
@@ -1433,24 +1602,16 @@ func readActionOperationCommands(
 func readActionOperationCommandTargets(
 	groups zabbix.ActionOperationCommandHostGroups,
 	hosts zabbix.ActionOperationCommandHosts,
-	api *zabbix.API) (lst []interface{}, err error) {
+	api *zabbixClient) (lst []interface{}, err error) {
 	if len(groups) > 0 {
 		var groupIds []string
 		for _, g := range groups {
 			groupIds = append(groupIds, g.GroupID)
 		}
-		params := zabbix.Params{
-			"groupids": groupIds,
-			"output":   []string{"name", "groupid"},
-		}
-		res, err := api.HostGroupsGet(params)
+		groupMap, err := api.lookupNames(lookupHostGroup, groupIds)
 		if err != nil {
 			return nil, err
 		}
-		groupMap := map[string]string{}
-		for _, g := range res {
-			groupMap[g.GroupID] = g.Name
-		}
 		for _, g := range groups {
 			m := map[string]interface{}{}
 			m["type"] = "host_group"
@@ -1467,18 +1628,10 @@ func readActionOperationCommandTargets(
 			}
 			hostIds = append(hostIds, h.HostID)
 		}
-		params := zabbix.Params{
-			"hostids": hostIds,
-			"output":  []string{"host", "hostid"},
-		}
-		res, err := api.HostsGet(params)
+		hostMap, err := api.lookupNames(lookupHost, hostIds)
 		if err != nil {
 			return nil, err
 		}
-		hostMap := map[string]string{}
-		for _, h := range res {
-			hostMap[h.HostID] = h.Host
-		}
 		for _, h := range hosts {
 			m := map[string]interface{}{}
 			if h.HostID == "0" {
@@ -1495,7 +1648,7 @@ func readActionOperationCommandTargets(
 	return
 }
 
-func readActionOperationHostGroups(grps zabbix.ActionOperationHostGroups, api *zabbix.API) (
+func readActionOperationHostGroups(grps zabbix.ActionOperationHostGroups, api *zabbixClient) (
 	lst []interface{},
 	err error) {
 	if len(grps) == 0 {
@@ -1507,18 +1660,15 @@ func readActionOperationHostGroups(grps zabbix.ActionOperationHostGroups, api *z
 		groupIds = append(groupIds, g.GroupID)
 	}
 
-	params := zabbix.Params{
-		"groupids": groupIds,
-		"output":   []string{"name"},
-	}
-
-	res, err := api.HostGroupsGet(params)
+	names, err := api.lookupNames(lookupHostGroup, groupIds)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, g := range res {
-		lst = append(lst, g.Name)
+	for _, id := range groupIds {
+		if name, ok := names[id]; ok {
+			lst = append(lst, name)
+		}
 	}
 
 	return
@@ -1528,7 +1678,9 @@ func readActionOperationMessages(
 	msg *zabbix.ActionOperationMessage,
 	groups zabbix.ActionOperationMessageUserGroups,
 	users zabbix.ActionOperationMessageUsers,
-	api *zabbix.API) (lst []interface{}, err error) {
+	templates *mediaTypeMessageTemplates,
+	recovery string,
+	api *zabbixClient) (lst []interface{}, err error) {
 	if msg == nil {
 		return
 	}
@@ -1538,8 +1690,17 @@ func readActionOperationMessages(
 	m["default_message"] = useDefaultMessage
 	m["media_type_id"] = msg.MediaTypeID
 
-	// When using default message, don't set subject and message to let Terraform use schema defaults
-	if !useDefaultMessage {
+	if useDefaultMessage {
+		// The default message is the template of the media type from Zabbix 5.0, the schema defaults before
+		subject, message, err := templates.message(msg.MediaTypeID, recovery)
+		if err != nil {
+			return nil, err
+		}
+		if subject != "" || message != "" {
+			m["subject"] = subject
+			m["message"] = message
+		}
+	} else {
 		m["subject"] = msg.Subject
 		m["message"] = msg.Message
 	}
@@ -1557,25 +1718,23 @@ func readActionOperationMessages(
 func readActionOperationMessageTargets(
 	groups zabbix.ActionOperationMessageUserGroups,
 	users zabbix.ActionOperationMessageUsers,
-	api *zabbix.API) (lst []interface{}, err error) {
+	api *zabbixClient) (lst []interface{}, err error) {
 	if len(groups) > 0 {
 		var groupIds []string
 		for _, g := range groups {
 			groupIds = append(groupIds, g.UserGroupID)
 		}
-		params := zabbix.Params{
-			"usrgrpids": groupIds,
-			"output":    []string{"name"},
-		}
-		res, err := api.UserGroupsGet(params)
+		names, err := api.lookupNames(lookupUserGroup, groupIds)
 		if err != nil {
 			return nil, err
 		}
-		for _, g := range res {
-			m := map[string]interface{}{}
-			m["type"] = "user_group"
-			m["value"] = g.Name
-			lst = append(lst, m)
+		for _, id := range groupIds {
+			if name, ok := names[id]; ok {
+				m := map[string]interface{}{}
+				m["type"] = "user_group"
+				m["value"] = name
+				lst = append(lst, m)
+			}
 		}
 	}
 
@@ -1584,30 +1743,24 @@ func readActionOperationMessageTargets(
 		for _, u := range users {
 			userIds = append(userIds, u.UserID)
 		}
-		params := zabbix.Params{
-			"userids": userIds,
-			"output":  []string{"alias", "username"},
-		}
-		res, err := api.UsersGet(params)
+		names, err := api.lookupNames(lookupUser, userIds)
 		if err != nil {
 			return nil, err
 		}
-		for _, u := range res {
-			m := map[string]interface{}{}
-			m["type"] = "user"
-			if u.Alias != "" {
-				m["value"] = u.Alias
-			} else {
-				m["value"] = u.Username
+		for _, id := range userIds {
+			if name, ok := names[id]; ok {
+				m := map[string]interface{}{}
+				m["type"] = "user"
+				m["value"] = name
+				lst = append(lst, m)
 			}
-			lst = append(lst, m)
 		}
 	}
 
 	return
 }
 
-func readActionOperationTemplates(templates zabbix.ActionOperationTemplates, api *zabbix.API) (
+func readActionOperationTemplates(templates zabbix.ActionOperationTemplates, api *zabbixClient) (
 	lst []interface{},
 	err error) {
 
@@ -1620,67 +1773,53 @@ func readActionOperationTemplates(templates zabbix.ActionOperationTemplates, api
 		templateIds = append(templateIds, t.TemplateID)
 	}
 
-	params := zabbix.Params{
-		"templateids": templateIds,
-		"output":      []string{"host"},
-	}
-
-	res, err := api.TemplatesGet(params)
+	names, err := api.lookupNames(lookupTemplate, templateIds)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, t := range res {
-		lst = append(lst, t.Host)
+	for _, id := range templateIds {
+		if name, ok := names[id]; ok {
+			lst = append(lst, name)
+		}
 	}
 
 	return
 }
 
-func resourceZabbixActionExists(d *schema.ResourceData, meta interface{}) (bool, error) {
-	api := meta.(*zabbix.API)
-
-	_, err := api.ActionGetByID(d.Id())
-	if err != nil {
-		if strings.Contains(err.Error(), "Expected exactly one result") {
-			log.Printf("[DEBUG] Action with id %s doesn't exist", d.Id())
-			return false, nil
-		}
-		return false, err
-	}
-	return true, nil
-}
-
-func resourceZabbixActionUpdate(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixActionUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
 
-	action, err := createActionObject(d, api)
+	action, opeConditions, err := createActionObject(d, api)
 
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 
 	// NOTE: EventSource can't be updated
 	action.EventSource = ""
 
-	actions := zabbix.Actions{*action}
+	params, err := actionRequest(action, opeConditions)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
-	err = api.ActionsUpdate(actions)
+	_, err = api.CallWithError("action.update", []interface{}{params})
 
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 
-	return resourceZabbixActionRead(d, meta)
+	return resourceZabbixActionRead(ctx, d, meta)
 }
 
-func resourceZabbixActionDelete(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixActionDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
 
 	err := api.ActionsDeleteByIds([]string{d.Id()})
 
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 
 	return nil
@@ -68,7 +68,7 @@ func TestAccZabbixAction_Update(t *testing.T) {
 }
 
 func testAccCheckZabbixActionDestroy(s *terraform.State) error {
-	api := testAccProvider.Meta().(*zabbix.API)
+	api := testAccProvider.Meta().(*zabbixClient)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "zabbix_action" {
@@ -155,7 +155,7 @@ func testAccCheckZabbixActionExists(resource string, action *zabbix.Action) reso
 			return fmt.Errorf("No record ID set")
 		}
 
-		api := testAccProvider.Meta().(*zabbix.API)
+		api := testAccProvider.Meta().(*zabbixClient)
 		act, err := api.ActionGetByID(rs.Primary.ID)
 		if err != nil {
 			return err
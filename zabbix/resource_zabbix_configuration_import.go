@@ -0,0 +1,537 @@
+package zabbix
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/claranet/go-zabbix-api"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/id"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"gopkg.in/yaml.v3"
+)
+
+// configurationImportRule describes how an object type of the import rules is sent to the API
+type configurationImportRule struct {
+	name  string
+	flags []string
+}
+
+var configurationImportRules = map[string]configurationImportRule{
+	"discovery_rules":     {"discoveryRules", []string{"create_missing", "update_existing", "delete_missing"}},
+	"graphs":              {"graphs", []string{"create_missing", "update_existing", "delete_missing"}},
+	"host_groups":         {"host_groups", []string{"create_missing", "update_existing"}},
+	"template_groups":     {"template_groups", []string{"create_missing", "update_existing"}},
+	"hosts":               {"hosts", []string{"create_missing", "update_existing"}},
+	"httptests":           {"httptests", []string{"create_missing", "update_existing", "delete_missing"}},
+	"images":              {"images", []string{"create_missing", "update_existing"}},
+	"items":               {"items", []string{"create_missing", "update_existing", "delete_missing"}},
+	"maps":                {"maps", []string{"create_missing", "update_existing"}},
+	"media_types":         {"mediaTypes", []string{"create_missing", "update_existing"}},
+	"template_linkage":    {"templateLinkage", []string{"create_missing", "delete_missing"}},
+	"templates":           {"templates", []string{"create_missing", "update_existing"}},
+	"template_dashboards": {"templateDashboards", []string{"create_missing", "update_existing", "delete_missing"}},
+	"triggers":            {"triggers", []string{"create_missing", "update_existing", "delete_missing"}},
+	"value_maps":          {"valueMaps", []string{"create_missing", "update_existing", "delete_missing"}},
+}
+
+var StringConfigurationImportFlagMap = map[string]string{
+	"create_missing":  "createMissing",
+	"update_existing": "updateExisting",
+	"delete_missing":  "deleteMissing",
+}
+
+func resourceZabbixConfigurationImport() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceZabbixConfigurationImportCreate,
+		ReadContext:   resourceZabbixConfigurationImportRead,
+		UpdateContext: resourceZabbixConfigurationImportUpdate,
+		DeleteContext: resourceZabbixConfigurationImportDelete,
+		CustomizeDiff: customizeDiffVersion(
+			valueRequiresVersion("5.2", "format", "yaml"),
+		),
+		Schema: map[string]*schema.Schema{
+			"format": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "yaml",
+				ValidateFunc: validation.StringInSlice(
+					[]string{"yaml", "xml", "json"},
+					false,
+				),
+				Description: "Format of the source. yaml requires Zabbix 5.2 or later.",
+			},
+			"source": &schema.Schema{
+				Type:             schema.TypeString,
+				Required:         true,
+				DiffSuppressFunc: suppressEquivalentConfiguration,
+				Description:      "Serialized configuration to import, usually read with file().",
+			},
+			"rules": &schema.Schema{
+				Type:        schema.TypeList,
+				Required:    true,
+				MaxItems:    1,
+				Elem:        schemaConfigurationImportRules(),
+				Description: "Rules on how to import the objects of the source.",
+			},
+			"template_ids": &schema.Schema{
+				Type:        schema.TypeSet,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Computed:    true,
+				Description: "IDs of the templates of the source.",
+			},
+			"created_template_ids": &schema.Schema{
+				Type:        schema.TypeSet,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Computed:    true,
+				Description: "IDs of the templates of the source created by the imports, deleted with the resource.",
+			},
+		},
+	}
+}
+
+func schemaConfigurationImportRules() *schema.Resource {
+	rules := map[string]*schema.Schema{}
+	for objectType, rule := range configurationImportRules {
+		flags := map[string]*schema.Schema{}
+		for _, flag := range rule.flags {
+			flags[flag] = &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			}
+		}
+		rules[objectType] = &schema.Schema{
+			Type:     schema.TypeList,
+			Optional: true,
+			MaxItems: 1,
+			Elem: &schema.Resource{
+				Schema: flags,
+			},
+		}
+	}
+	return &schema.Resource{
+		Schema: rules,
+	}
+}
+
+func createConfigurationImportRules(d *schema.ResourceData, api *zabbixClient) (map[string]interface{}, error) {
+	groups := api.ServerVersion.GreaterThanOrEqual(version.Must(version.NewVersion("6.2")))
+	dashboards := api.ServerVersion.GreaterThanOrEqual(version.Must(version.NewVersion("5.2")))
+
+	rules := map[string]interface{}{}
+	terraformRules := d.Get("rules").([]interface{})
+	if len(terraformRules) == 0 || terraformRules[0] == nil {
+		return rules, nil
+	}
+
+	for objectType, r := range terraformRules[0].(map[string]interface{}) {
+		terraformRule := r.([]interface{})
+		if len(terraformRule) == 0 || terraformRule[0] == nil {
+			continue
+		}
+
+		name := configurationImportRules[objectType].name
+		switch {
+		case objectType == "host_groups" && !groups:
+			name = "groups"
+		case objectType == "template_groups" && !groups:
+			return nil, fmt.Errorf("Import rules for template_groups require Zabbix 6.2 or later")
+		case objectType == "template_dashboards" && !dashboards:
+			name = "templateScreens"
+		}
+
+		// Flags are false by default, only the enabled ones are sent so that older servers accept the rules
+		rule := map[string]bool{}
+		for flag, enabled := range terraformRule[0].(map[string]interface{}) {
+			if enabled.(bool) {
+				rule[StringConfigurationImportFlagMap[flag]] = true
+			}
+		}
+		if len(rule) > 0 {
+			rules[name] = rule
+		}
+	}
+	return rules, nil
+}
+
+func resourceZabbixConfigurationImportCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if err := importConfiguration(d, meta.(*zabbixClient)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(id.UniqueId())
+	return resourceZabbixConfigurationImportRead(ctx, d, meta)
+}
+
+func resourceZabbixConfigurationImportUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	if err := importConfiguration(d, meta.(*zabbixClient)); err != nil {
+		return diag.FromErr(err)
+	}
+	return resourceZabbixConfigurationImportRead(ctx, d, meta)
+}
+
+// importConfiguration imports the source and records the templates it created, the ones found before the import
+// being left out
+func importConfiguration(d *schema.ResourceData, api *zabbixClient) error {
+	format := d.Get("format").(string)
+	if format == "yaml" && api.ServerVersion.LessThan(version.Must(version.NewVersion("5.2"))) {
+		return fmt.Errorf("Importing yaml requires Zabbix 5.2 or later")
+	}
+	source := d.Get("source").(string)
+
+	rules, err := createConfigurationImportRules(d, api)
+	if err != nil {
+		return err
+	}
+
+	existing, _, err := getConfigurationTemplateIDs(api, format, source)
+	if err != nil {
+		return err
+	}
+
+	_, err = api.CallWithError("configuration.import", zabbix.Params{
+		"format": format,
+		"source": source,
+		"rules":  rules,
+	})
+	if err != nil {
+		return err
+	}
+
+	templateIDs, _, err := getConfigurationTemplateIDs(api, format, source)
+	if err != nil {
+		return err
+	}
+	found := make(map[string]bool, len(existing))
+	for _, templateID := range existing {
+		found[templateID] = true
+	}
+	created := d.Get("created_template_ids").(*schema.Set)
+	for _, templateID := range templateIDs {
+		if !found[templateID] {
+			created.Add(templateID)
+		}
+	}
+	d.Set("created_template_ids", created)
+	return nil
+}
+
+func resourceZabbixConfigurationImportRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
+
+	format := d.Get("format").(string)
+	source := d.Get("source").(string)
+
+	templateIDs, expected, err := getConfigurationTemplateIDs(api, format, source)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if expected > 0 && len(templateIDs) == 0 {
+		log.Printf("[DEBUG] No template of the configuration import %s exists anymore", d.Id())
+		d.SetId("")
+		return nil
+	}
+	d.Set("template_ids", templateIDs)
+	d.Set("created_template_ids", createdTemplateIDs(d, templateIDs))
+
+	// Only templates are compared, a source without templates can't drift
+	if len(templateIDs) == 0 {
+		return nil
+	}
+
+	export, err := exportConfiguration(api, format, zabbix.Params{"templates": templateIDs})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	equivalent, err := equivalentConfiguration(format, source, export)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	// Storing the export makes the drift show up as a change of the source
+	if !equivalent {
+		log.Printf("[DEBUG] Templates of the configuration import %s drifted from the source", d.Id())
+		d.Set("source", export)
+	}
+	return nil
+}
+
+func resourceZabbixConfigurationImportDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
+
+	templateIDs, _, err := getConfigurationTemplateIDs(api, d.Get("format").(string), d.Get("source").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	// The templates found before the first import were only updated by it, they are left to their owner
+	created := createdTemplateIDs(d, templateIDs)
+	if len(created) == 0 {
+		return nil
+	}
+
+	return diag.FromErr(api.TemplatesDeleteByIds(created))
+}
+
+// createdTemplateIDs returns the IDs of the templates of the source created by the imports
+func createdTemplateIDs(d *schema.ResourceData, templateIDs []string) []string {
+	created := d.Get("created_template_ids").(*schema.Set)
+	ids := []string{}
+	for _, templateID := range templateIDs {
+		if created.Contains(templateID) {
+			ids = append(ids, templateID)
+		}
+	}
+	return ids
+}
+
+// getConfigurationTemplateIDs returns the IDs of the existing templates of the source and the number of templates it lists
+func getConfigurationTemplateIDs(api *zabbixClient, format, source string) ([]string, int, error) {
+	config, err := parseConfiguration(format, source)
+	if err != nil {
+		return nil, 0, err
+	}
+	names := configurationTemplateNames(format, config)
+	if len(names) == 0 {
+		return []string{}, 0, nil
+	}
+
+	templates, err := api.TemplatesGet(zabbix.Params{
+		"output": []string{"templateid"},
+		"filter": map[string]interface{}{
+			"host": names,
+		},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	templateIDs := make([]string, len(templates))
+	for i, template := range templates {
+		templateIDs[i] = template.TemplateID
+	}
+	sort.Strings(templateIDs)
+	return templateIDs, len(names), nil
+}
+
+func exportConfiguration(api *zabbixClient, format string, options zabbix.Params) (string, error) {
+	response, err := api.CallWithError("configuration.export", zabbix.Params{
+		"format":  format,
+		"options": options,
+	})
+	if err != nil {
+		return "", err
+	}
+	export, ok := response.Result.(string)
+	if !ok {
+		return "", fmt.Errorf("Unexpected response for configuration export: %#v", response.Result)
+	}
+	return export, nil
+}
+
+// suppressEquivalentConfiguration ignores the formatting and order changes of the source
+func suppressEquivalentConfiguration(k, old, new string, d *schema.ResourceData) bool {
+	if old == "" || new == "" {
+		return false
+	}
+	format := d.Get("format").(string)
+	for _, pair := range [][2]string{{new, old}, {old, new}} {
+		equivalent, err := equivalentConfiguration(format, pair[0], pair[1])
+		if err != nil {
+			log.Printf("[DEBUG] Failed to compare configurations: %s", err)
+			return false
+		}
+		if !equivalent {
+			return false
+		}
+	}
+	return true
+}
+
+// equivalentConfiguration checks that every value of the source is found in the export, ignoring the export
+// date and version, the formatting and the order of lists
+func equivalentConfiguration(format, source, export string) (bool, error) {
+	sourceConfig, err := parseConfiguration(format, source)
+	if err != nil {
+		return false, err
+	}
+	exportConfig, err := parseConfiguration(format, export)
+	if err != nil {
+		return false, err
+	}
+
+	for section, sourceSection := range sourceConfig {
+		if section == "version" || section == "date" {
+			continue
+		}
+		if !configurationContains(sourceSection, exportConfig[section]) {
+			log.Printf("[DEBUG] Configuration section %s differs", section)
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// configurationContains compares a parsed source with a parsed export. Scalars missing from the export
+// are accepted as Zabbix doesn't export default values, lists must have the same elements in any order.
+func configurationContains(source, export interface{}) bool {
+	switch s := source.(type) {
+	case map[string]interface{}:
+		e, ok := export.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for key, value := range s {
+			exportValue, ok := e[key]
+			if !ok {
+				switch value.(type) {
+				case map[string]interface{}, []interface{}:
+					return false
+				}
+				continue
+			}
+			if !configurationContains(value, exportValue) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		e, ok := export.([]interface{})
+		if !ok || len(s) != len(e) {
+			return false
+		}
+		matched := make([]bool, len(e))
+		for _, value := range s {
+			found := false
+			for i, exportValue := range e {
+				if !matched[i] && configurationContains(value, exportValue) {
+					matched[i] = true
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	case nil:
+		return export == nil || fmt.Sprint(export) == ""
+	default:
+		return export != nil && fmt.Sprint(s) == fmt.Sprint(export)
+	}
+}
+
+// parseConfiguration returns the content of the zabbix_export root element
+func parseConfiguration(format, source string) (map[string]interface{}, error) {
+	var document map[string]interface{}
+	var err error
+
+	switch format {
+	case "json":
+		err = json.Unmarshal([]byte(source), &document)
+	case "yaml":
+		err = yaml.Unmarshal([]byte(source), &document)
+	case "xml":
+		document, err = parseXMLConfiguration(source)
+	default:
+		return nil, fmt.Errorf("Unsupported configuration format %s", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse %s configuration: %s", format, err)
+	}
+
+	root, ok := document["zabbix_export"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Expected a zabbix_export root in the %s configuration", format)
+	}
+	return root, nil
+}
+
+// parseXMLConfiguration maps every element to its text, or to a map of its children grouped by name
+func parseXMLConfiguration(source string) (map[string]interface{}, error) {
+	decoder := xml.NewDecoder(strings.NewReader(source))
+
+	type element struct {
+		name     string
+		children map[string]interface{}
+		text     strings.Builder
+	}
+	root := &element{children: map[string]interface{}{}}
+	stack := []*element{root}
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			stack = append(stack, &element{name: t.Name.Local, children: map[string]interface{}{}})
+		case xml.CharData:
+			stack[len(stack)-1].text.Write(t)
+		case xml.EndElement:
+			current := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			parent := stack[len(stack)-1]
+
+			var value interface{} = strings.TrimSpace(current.text.String())
+			if len(current.children) > 0 {
+				value = current.children
+			}
+			siblings, _ := parent.children[current.name].([]interface{})
+			parent.children[current.name] = append(siblings, value)
+		}
+	}
+
+	document := map[string]interface{}{}
+	for name, values := range root.children {
+		if list := values.([]interface{}); len(list) == 1 {
+			document[name] = list[0]
+		}
+	}
+	return document, nil
+}
+
+// configurationTemplateNames lists the technical names of the templates of a parsed configuration
+func configurationTemplateNames(format string, config map[string]interface{}) []string {
+	var templates []interface{}
+	if format == "xml" {
+		if sections, ok := config["templates"].([]interface{}); ok && len(sections) == 1 {
+			if section, ok := sections[0].(map[string]interface{}); ok {
+				templates, _ = section["template"].([]interface{})
+			}
+		}
+	} else {
+		templates, _ = config["templates"].([]interface{})
+	}
+
+	var names []string
+	for _, t := range templates {
+		template, ok := t.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch name := template["template"].(type) {
+		case string:
+			names = append(names, name)
+		case []interface{}:
+			if len(name) == 1 {
+				names = append(names, fmt.Sprint(name[0]))
+			}
+		}
+	}
+	return names
+}
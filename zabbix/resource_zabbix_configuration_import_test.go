@@ -0,0 +1,221 @@
+package zabbix
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/claranet/go-zabbix-api"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccZabbixConfigurationImport_Basic(t *testing.T) {
+	resourceName := "zabbix_configuration_import.import_test"
+	strID := acctest.RandString(5)
+	uuids := make([]string, 3)
+	for i := range uuids {
+		uuids[i] = acctest.RandStringFromCharSet(32, "0123456789abcdef")
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t); testAccPreCheckRealServer(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckZabbixConfigurationImportDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccZabbixConfigurationImportConfig(strID, uuids, "item"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "format", "json"),
+					resource.TestCheckResourceAttr(resourceName, "template_ids.#", "1"),
+					resource.TestCheckResourceAttrPair(resourceName, "created_template_ids.0", resourceName, "template_ids.0"),
+				),
+			},
+			{
+				Config: testAccZabbixConfigurationImportConfig(strID, uuids, "update_item"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "template_ids.#", "1"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccZabbixConfigurationImport_ExistingTemplate(t *testing.T) {
+	resourceName := "zabbix_configuration_import.import_test"
+	strID := acctest.RandString(5)
+
+	// Destroying the template fails if the import deleted it before
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t); testAccPreCheckRealServer(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccZabbixConfigurationImportExistingTemplateConfig(strID),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrPair(resourceName, "template_ids.0", "zabbix_template.template_test", "id"),
+					resource.TestCheckResourceAttr(resourceName, "created_template_ids.#", "0"),
+				),
+			},
+		},
+	})
+}
+
+func TestConfigurationEquivalent(t *testing.T) {
+	cases := []struct {
+		format     string
+		source     string
+		export     string
+		equivalent bool
+	}{
+		{
+			format:     "json",
+			source:     `{"zabbix_export":{"version":"6.0","templates":[{"template":"a","items":[{"key":"k1"},{"key":"k2"}]}]}}`,
+			export:     `{"zabbix_export":{"version":"6.4","templates":[{"template":"a","name":"a","items":[{"key":"k2","delay":"5m"},{"key":"k1"}]}]}}`,
+			equivalent: true,
+		},
+		{
+			format:     "json",
+			source:     `{"zabbix_export":{"templates":[{"template":"a","items":[{"key":"k1"},{"key":"k2"}]}]}}`,
+			export:     `{"zabbix_export":{"templates":[{"template":"a","items":[{"key":"k1"}]}]}}`,
+			equivalent: false,
+		},
+		{
+			format:     "yaml",
+			source:     "zabbix_export:\n  templates:\n    - template: a\n      items:\n        - key: k1\n          delay: 1m\n",
+			export:     "zabbix_export:\n  version: '6.4'\n  templates:\n    - template: a\n      items:\n        - key: k1\n          delay: 5m\n",
+			equivalent: false,
+		},
+		{
+			format:     "xml",
+			source:     `<zabbix_export><templates><template><template>a</template><items><item><key>k1</key></item></items></template></templates></zabbix_export>`,
+			export:     "<?xml version=\"1.0\"?>\n<zabbix_export>\n  <version>6.4</version>\n  <templates>\n    <template>\n      <template>a</template>\n      <items>\n        <item>\n          <key>k1</key>\n        </item>\n      </items>\n    </template>\n  </templates>\n</zabbix_export>\n",
+			equivalent: true,
+		},
+	}
+
+	for i, c := range cases {
+		equivalent, err := equivalentConfiguration(c.format, c.source, c.export)
+		if err != nil {
+			t.Fatalf("case %d: %s", i, err)
+		}
+		if equivalent != c.equivalent {
+			t.Fatalf("case %d: expected equivalent to be %t", i, c.equivalent)
+		}
+	}
+}
+
+func TestConfigurationTemplateNames(t *testing.T) {
+	sources := map[string]string{
+		"json": `{"zabbix_export":{"templates":[{"template":"a"},{"template":"b"}]}}`,
+		"yaml": "zabbix_export:\n  templates:\n    - template: a\n    - template: b\n",
+		"xml":  `<zabbix_export><templates><template><template>a</template></template><template><template>b</template></template></templates></zabbix_export>`,
+	}
+
+	for format, source := range sources {
+		config, err := parseConfiguration(format, source)
+		if err != nil {
+			t.Fatalf("%s: %s", format, err)
+		}
+		names := configurationTemplateNames(format, config)
+		if fmt.Sprint(names) != "[a b]" {
+			t.Fatalf("%s: expected templates a and b and got %v", format, names)
+		}
+	}
+}
+
+func testAccCheckZabbixConfigurationImportDestroy(s *terraform.State) error {
+	api := testAccProvider.Meta().(*zabbixClient)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "zabbix_configuration_import" {
+			continue
+		}
+
+		templateIDs, _, err := getConfigurationTemplateIDs(api, rs.Primary.Attributes["format"], rs.Primary.Attributes["source"])
+		if err != nil {
+			return err
+		}
+		if len(templateIDs) != 0 {
+			return fmt.Errorf("Templates of configuration import %s still exist", rs.Primary.ID)
+		}
+	}
+	return nil
+}
+
+func testAccZabbixConfigurationImportConfig(strID string, uuids []string, itemName string) string {
+	return fmt.Sprintf(`
+		resource "zabbix_configuration_import" "import_test" {
+			format = "json"
+			source = jsonencode({
+				zabbix_export = {
+					version = "6.2"
+					template_groups = [{
+						uuid = "%[2]s"
+						name = "template_group_%[1]s"
+					}]
+					templates = [{
+						uuid     = "%[3]s"
+						template = "template_%[1]s"
+						name     = "template_%[1]s"
+						groups   = [{ name = "template_group_%[1]s" }]
+						items = [{
+							uuid = "%[4]s"
+							name = "%[5]s_%[1]s"
+							key  = "%[5]s_%[1]s"
+						}]
+					}]
+				}
+			})
+
+			rules {
+				template_groups {
+					create_missing = true
+				}
+				templates {
+					create_missing  = true
+					update_existing = true
+				}
+				items {
+					create_missing  = true
+					update_existing = true
+					delete_missing  = true
+				}
+			}
+		}
+	`, strID, uuids[0], uuids[1], uuids[2], itemName)
+}
+
+func testAccZabbixConfigurationImportExistingTemplateConfig(strID string) string {
+	return fmt.Sprintf(`
+		resource "zabbix_template_group" "template_group_test" {
+			name = "template_group_%[1]s"
+		}
+
+		resource "zabbix_template" "template_test" {
+			host   = "template_%[1]s"
+			groups = [zabbix_template_group.template_group_test.name]
+		}
+
+		resource "zabbix_configuration_import" "import_test" {
+			format = "json"
+			source = jsonencode({
+				zabbix_export = {
+					version = "6.2"
+					template_groups = [{ name = "template_group_%[1]s" }]
+					templates = [{
+						template    = zabbix_template.template_test.host
+						name        = "template_%[1]s"
+						groups      = [{ name = "template_group_%[1]s" }]
+					}]
+				}
+			})
+
+			rules {
+				templates {
+					update_existing = true
+				}
+			}
+		}
+	`, strID)
+}
@@ -0,0 +1,91 @@
+package zabbix
+
+import (
+	"context"
+	"log"
+
+	"github.com/claranet/go-zabbix-api"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceZabbixGlobalMacro() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceZabbixGlobalMacroCreate,
+		ReadContext:   resourceZabbixGlobalMacroRead,
+		UpdateContext: resourceZabbixGlobalMacroUpdate,
+		DeleteContext: resourceZabbixGlobalMacroDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		CustomizeDiff: customizeDiffVersion(
+			requiresVersion("4.4", "description", ""),
+			requiresVersion("5.0", "type", "text"),
+		),
+		Schema: schemaUserMacro(),
+	}
+}
+
+func resourceZabbixGlobalMacroCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
+
+	params, err := createUserMacroParams(d, api)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	response, err := api.CallWithError("usermacro.createglobal", params)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	id, err := idFromResponse(response, "globalmacroids")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	log.Printf("[DEBUG] Created global macro, id is %s", id)
+
+	d.SetId(id)
+	return resourceZabbixGlobalMacroRead(ctx, d, meta)
+}
+
+func resourceZabbixGlobalMacroRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
+
+	macros, err := getUserMacros(api, zabbix.Params{
+		"output":         "extend",
+		"globalmacro":    true,
+		"globalmacroids": d.Id(),
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err := expectOne("global macro", d.Id(), len(macros)); err != nil {
+		return readError(d, err)
+	}
+
+	return diag.FromErr(setTerraformUserMacro(d, macros[0]))
+}
+
+func resourceZabbixGlobalMacroUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
+
+	params, err := createUserMacroParams(d, api)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	params["globalmacroid"] = d.Id()
+
+	_, err = api.CallWithError("usermacro.updateglobal", params)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	return resourceZabbixGlobalMacroRead(ctx, d, meta)
+}
+
+func resourceZabbixGlobalMacroDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
+
+	_, err := api.CallWithError("usermacro.deleteglobal", []string{d.Id()})
+	return diag.FromErr(err)
+}
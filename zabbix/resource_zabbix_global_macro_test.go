@@ -0,0 +1,79 @@
+package zabbix
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/claranet/go-zabbix-api"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccZabbixGlobalMacro_Basic(t *testing.T) {
+	macroName := fmt.Sprintf("GLOBAL_%s", acctest.RandStringFromCharSet(5, acctest.CharSetAlpha))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckZabbixGlobalMacroDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccZabbixGlobalMacroConfig(macroName, "text", "public"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("zabbix_global_macro.macro_test", "name", macroName),
+					resource.TestCheckResourceAttr("zabbix_global_macro.macro_test", "value", "public"),
+					resource.TestCheckResourceAttr("zabbix_global_macro.macro_test", "type", "text"),
+					resource.TestCheckResourceAttr("zabbix_global_macro.macro_test", "description", "macro managed by terraform"),
+				),
+			},
+			{
+				Config: testAccZabbixGlobalMacroConfig(macroName, "secret", "s3cr3t"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("zabbix_global_macro.macro_test", "name", macroName),
+					resource.TestCheckResourceAttr("zabbix_global_macro.macro_test", "value", "s3cr3t"),
+					resource.TestCheckResourceAttr("zabbix_global_macro.macro_test", "type", "secret"),
+				),
+			},
+			{
+				ResourceName:            "zabbix_global_macro.macro_test",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"value"},
+			},
+		},
+	})
+}
+
+func testAccCheckZabbixGlobalMacroDestroy(s *terraform.State) error {
+	api := testAccProvider.Meta().(*zabbixClient)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "zabbix_global_macro" {
+			continue
+		}
+
+		macros, err := getUserMacros(api, zabbix.Params{
+			"globalmacro":    true,
+			"globalmacroids": rs.Primary.ID,
+		})
+		if err != nil {
+			return err
+		}
+		if len(macros) != 0 {
+			return fmt.Errorf("Global macro still exist %s", rs.Primary.ID)
+		}
+	}
+	return nil
+}
+
+func testAccZabbixGlobalMacroConfig(macroName, macroType, value string) string {
+	return fmt.Sprintf(`
+		resource "zabbix_global_macro" "macro_test" {
+			name = "%s"
+			value = "%s"
+			type = "%s"
+			description = "macro managed by terraform"
+		}
+	`, macroName, value, macroType)
+}
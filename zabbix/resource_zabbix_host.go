@@ -1,13 +1,18 @@
 package zabbix
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
-	"strings"
+	"strconv"
 
 	"github.com/claranet/go-zabbix-api"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 // HostInterfaceTypes zabbix different interface type
@@ -25,6 +30,56 @@ var HostInterfaceTypeStrings = map[zabbix.InterfaceType]string{
 	zabbix.JMX:   "jmx",
 }
 
+var StringSNMPSecurityLevelMap = map[string]string{
+	"noauthnopriv": "0",
+	"authnopriv":   "1",
+	"authpriv":     "2",
+}
+
+var SNMPSecurityLevelStringMap = map[string]string{
+	"0": "noauthnopriv",
+	"1": "authnopriv",
+	"2": "authpriv",
+}
+
+// The authentication protocols other than md5 and sha1, and the privacy protocols other than des and aes128,
+// require Zabbix 5.4
+var StringSNMPAuthProtocolMap = map[string]string{
+	"md5":    "0",
+	"sha1":   "1",
+	"sha224": "2",
+	"sha256": "3",
+	"sha384": "4",
+	"sha512": "5",
+}
+
+var SNMPAuthProtocolStringMap = map[string]string{
+	"0": "md5",
+	"1": "sha1",
+	"2": "sha224",
+	"3": "sha256",
+	"4": "sha384",
+	"5": "sha512",
+}
+
+var StringSNMPPrivProtocolMap = map[string]string{
+	"des":     "0",
+	"aes128":  "1",
+	"aes192":  "2",
+	"aes256":  "3",
+	"aes192c": "4",
+	"aes256c": "5",
+}
+
+var SNMPPrivProtocolStringMap = map[string]string{
+	"0": "des",
+	"1": "aes128",
+	"2": "aes192",
+	"3": "aes256",
+	"4": "aes192c",
+	"5": "aes256c",
+}
+
 var interfaceSchema *schema.Resource = &schema.Resource{
 	Schema: map[string]*schema.Schema{
 		"dns": &schema.Schema{
@@ -53,70 +108,190 @@ var interfaceSchema *schema.Resource = &schema.Resource{
 			Type:     schema.TypeString,
 			Computed: true,
 		},
+		"bulk": &schema.Schema{
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     true,
+			Description: "Whether SNMP interfaces use bulk requests.",
+		},
+		"snmp_version": &schema.Schema{
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      2,
+			ValidateFunc: validation.IntBetween(1, 3),
+			Description:  "SNMP version of SNMP interfaces, on Zabbix 5.0 or later.",
+		},
+		"community": &schema.Schema{
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "SNMP community of SNMPv1 and SNMPv2 interfaces, on Zabbix 5.0 or later.",
+		},
+		"security_name": &schema.Schema{
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+		"security_level": &schema.Schema{
+			Type:     schema.TypeString,
+			Optional: true,
+			Default:  "noauthnopriv",
+			ValidateFunc: validation.StringInSlice(
+				[]string{"noauthnopriv", "authnopriv", "authpriv"},
+				false,
+			),
+		},
+		"auth_protocol": &schema.Schema{
+			Type:     schema.TypeString,
+			Optional: true,
+			Default:  "md5",
+			ValidateFunc: validation.StringInSlice(
+				[]string{"md5", "sha1", "sha224", "sha256", "sha384", "sha512"},
+				false,
+			),
+		},
+		"auth_passphrase": &schema.Schema{
+			Type:      schema.TypeString,
+			Optional:  true,
+			Sensitive: true,
+		},
+		"priv_protocol": &schema.Schema{
+			Type:     schema.TypeString,
+			Optional: true,
+			Default:  "des",
+			ValidateFunc: validation.StringInSlice(
+				[]string{"des", "aes128", "aes192", "aes256", "aes192c", "aes256c"},
+				false,
+			),
+		},
+		"priv_passphrase": &schema.Schema{
+			Type:      schema.TypeString,
+			Optional:  true,
+			Sensitive: true,
+		},
+		"context_name": &schema.Schema{
+			Type:     schema.TypeString,
+			Optional: true,
+		},
 	},
 }
 
+// hostInterface carries the SNMP properties that zabbix.HostInterface does not know about. Zabbix 5.0 replaced
+// bulk by the details of the interface.
+type hostInterface struct {
+	zabbix.HostInterface
+	Bulk    string                `json:"bulk,omitempty"`
+	Details *hostInterfaceDetails `json:"details,omitempty"`
+}
+
+type hostInterfaceDetails struct {
+	Version        string `json:"version"`
+	Bulk           string `json:"bulk,omitempty"`
+	Community      string `json:"community,omitempty"`
+	SecurityName   string `json:"securityname,omitempty"`
+	SecurityLevel  string `json:"securitylevel,omitempty"`
+	AuthProtocol   string `json:"authprotocol,omitempty"`
+	AuthPassphrase string `json:"authpassphrase,omitempty"`
+	PrivProtocol   string `json:"privprotocol,omitempty"`
+	PrivPassphrase string `json:"privpassphrase,omitempty"`
+	ContextName    string `json:"contextname,omitempty"`
+}
+
+// UnmarshalJSON reads the empty list Zabbix returns as the details of the interfaces other than SNMP
+func (details *hostInterfaceDetails) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		return nil
+	}
+	type plain hostInterfaceDetails
+	return json.Unmarshal(data, (*plain)(details))
+}
+
+// zabbixHost carries the macro properties that zabbix.Host does not know about
+type zabbixHost struct {
+	zabbix.Host
+	Interfaces []hostInterface `json:"interfaces,omitempty"`
+	UserMacros *[]hostMacro    `json:"macros,omitempty"`
+}
+
 func resourceZabbixHost() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceZabbixHostCreate,
-		Read:   resourceZabbixHostRead,
-		Update: resourceZabbixHostUpdate,
-		Delete: resourceZabbixHostDelete,
+		CreateContext: resourceZabbixHostCreate,
+		ReadContext:   resourceZabbixHostRead,
+		UpdateContext: resourceZabbixHostUpdate,
+		DeleteContext: resourceZabbixHostDelete,
 		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
+			StateContext: importStateByName("host", getHostIDs, "name", "host"),
 		},
-		Schema: map[string]*schema.Schema{
-			"host": &schema.Schema{
-				Type:        schema.TypeString,
-				Required:    true,
-				Description: "Technical name of the host.",
-			},
-			"host_id": &schema.Schema{
-				Type:        schema.TypeString,
-				Computed:    true,
-				Description: "(readonly) ID of the host",
-			},
-			"name": &schema.Schema{
-				Type:        schema.TypeString,
-				Required:    false,
-				Optional:    true,
-				Computed:    true,
-				Description: "Visible name of the host.",
-			},
-			"monitored": &schema.Schema{
-				Type:     schema.TypeBool,
-				Default:  true,
-				Optional: true,
-			},
-			"interfaces": &schema.Schema{
-				Type:     schema.TypeList,
-				Elem:     interfaceSchema,
-				Required: true,
-			},
-			"groups": &schema.Schema{
-				Type:     schema.TypeSet,
-				Elem:     &schema.Schema{Type: schema.TypeString},
-				Required: true,
-			},
-			"templates": &schema.Schema{
-				Type:     schema.TypeSet,
-				Elem:     &schema.Schema{Type: schema.TypeString},
-				Optional: true,
-			},
-			"macro": &schema.Schema{
-				Type:        schema.TypeMap,
-				Elem:        &schema.Schema{Type: schema.TypeString},
-				Optional:    true,
-				Description: "User macros for the host.",
+		CustomizeDiff: customizeDiffVersion(
+			requiresVersion("4.4", "macro.*.description", ""),
+			requiresVersion("5.0", "macro.*.type", "text"),
+		),
+		SchemaVersion: 1,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Type:    resourceZabbixHostV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceZabbixMacroStateUpgradeV0,
+				Version: 0,
 			},
 		},
+		Schema: schemaHost(),
 	}
 }
 
-func getInterfaces(d *schema.ResourceData) (zabbix.HostInterfaces, error) {
+// resourceZabbixHostV0 is the schema used while macros were a map of names to values
+func resourceZabbixHostV0() *schema.Resource {
+	hostSchema := schemaHost()
+	hostSchema["macro"] = schemaMacrosV0()
+	return &schema.Resource{
+		Schema: hostSchema,
+	}
+}
+
+func schemaHost() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"host": &schema.Schema{
+			Type:        schema.TypeString,
+			Required:    true,
+			Description: "Technical name of the host.",
+		},
+		"host_id": &schema.Schema{
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "(readonly) ID of the host",
+		},
+		"name": &schema.Schema{
+			Type:        schema.TypeString,
+			Required:    false,
+			Optional:    true,
+			Computed:    true,
+			Description: "Visible name of the host.",
+		},
+		"monitored": &schema.Schema{
+			Type:     schema.TypeBool,
+			Default:  true,
+			Optional: true,
+		},
+		"interfaces": &schema.Schema{
+			Type:     schema.TypeList,
+			Elem:     interfaceSchema,
+			Required: true,
+		},
+		"groups": &schema.Schema{
+			Type:     schema.TypeSet,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+			Required: true,
+		},
+		"templates": &schema.Schema{
+			Type:     schema.TypeSet,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+			Optional: true,
+		},
+		"macro": schemaMacros("User macros for the host. Macros are left untouched when omitted, so that they can be managed with zabbix_user_macro, the two being exclusive."),
+	}
+}
+
+func getInterfaces(d *schema.ResourceData, api *zabbixClient) ([]hostInterface, error) {
 	interfaceCount := d.Get("interfaces.#").(int)
 
-	interfaces := make(zabbix.HostInterfaces, interfaceCount)
+	interfaces := make([]hostInterface, interfaceCount)
 
 	for i := 0; i < interfaceCount; i++ {
 		prefix := fmt.Sprintf("interfaces.%d.", i)
@@ -149,151 +324,125 @@ func getInterfaces(d *schema.ResourceData) (zabbix.HostInterfaces, error) {
 			main = 0
 		}
 
-		interfaces[i] = zabbix.HostInterface{
-			InterfaceID: interfaceId,
-			DNS:         dns,
-			IP:          ip,
-			Main:        main,
-			Port:        d.Get(prefix + "port").(string),
-			Type:        typeID,
-			UseIP:       useip,
+		interfaces[i] = hostInterface{
+			HostInterface: zabbix.HostInterface{
+				InterfaceID: interfaceId,
+				DNS:         dns,
+				IP:          ip,
+				Main:        main,
+				Port:        d.Get(prefix + "port").(string),
+				Type:        typeID,
+				UseIP:       useip,
+			},
+		}
+
+		if typeID != zabbix.SNMP {
+			continue
+		}
+		interfaces[i].Bulk = "0"
+		if d.Get(prefix + "bulk").(bool) {
+			interfaces[i].Bulk = "1"
+		}
+		if versionAtLeast(api.ServerVersion, "5.0") {
+			interfaces[i].Details = getInterfaceDetails(d, prefix)
 		}
 	}
 
 	return interfaces, nil
 }
 
-func getHostGroups(d *schema.ResourceData, api *zabbix.API) (zabbix.HostGroupIDs, error) {
-	configGroups := d.Get("groups").(*schema.Set)
-	setHostGroups := make([]string, configGroups.Len())
+// getInterfaceDetails returns the SNMP settings of an interface, bulk being sent with the interface
+func getInterfaceDetails(d *schema.ResourceData, prefix string) *hostInterfaceDetails {
+	snmpVersion := d.Get(prefix + "snmp_version").(int)
+	details := hostInterfaceDetails{Version: strconv.Itoa(snmpVersion)}
 
-	for i, g := range configGroups.List() {
-		setHostGroups[i] = g.(string)
+	if snmpVersion < 3 {
+		details.Community = d.Get(prefix + "community").(string)
+		return &details
 	}
 
-	log.Printf("[DEBUG] Groups %v\n", setHostGroups)
+	details.SecurityName = d.Get(prefix + "security_name").(string)
+	details.SecurityLevel = StringSNMPSecurityLevelMap[d.Get(prefix+"security_level").(string)]
+	details.AuthProtocol = StringSNMPAuthProtocolMap[d.Get(prefix+"auth_protocol").(string)]
+	details.AuthPassphrase = d.Get(prefix + "auth_passphrase").(string)
+	details.PrivProtocol = StringSNMPPrivProtocolMap[d.Get(prefix+"priv_protocol").(string)]
+	details.PrivPassphrase = d.Get(prefix + "priv_passphrase").(string)
+	details.ContextName = d.Get(prefix + "context_name").(string)
+	return &details
+}
 
-	groupParams := zabbix.Params{
-		"output": "extend",
-		"filter": map[string]interface{}{
-			"name": setHostGroups,
-		},
+func getHostGroups(d *schema.ResourceData, api *zabbixClient) (zabbix.HostGroupIDs, error) {
+	configGroups := d.Get("groups").(*schema.Set)
+	names := make([]string, configGroups.Len())
+
+	for i, g := range configGroups.List() {
+		names[i] = g.(string)
 	}
 
-	groups, err := api.HostGroupsGet(groupParams)
+	log.Printf("[DEBUG] Groups %v\n", names)
 
+	ids, err := api.lookupIDs(lookupHostGroup, names)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(groups) < configGroups.Len() {
-		log.Printf("[DEBUG] Not all of the specified groups were found on zabbix server")
+	hostGroups := make(zabbix.HostGroupIDs, len(names))
 
-		for _, n := range configGroups.List() {
-			found := false
-
-			for _, g := range groups {
-				if n == g.Name {
-					found = true
-					break
-				}
-			}
-
-			if !found {
-				return nil, fmt.Errorf("Host group %s doesnt exist in zabbix server", n)
-			}
-			log.Printf("[DEBUG] %s exists on zabbix server", n)
+	for i, n := range names {
+		id, ok := ids[n]
+		if !ok {
+			return nil, fmt.Errorf("Host group %s doesnt exist in zabbix server", n)
 		}
-	}
-
-	hostGroups := make(zabbix.HostGroupIDs, len(groups))
-
-	for i, g := range groups {
 		hostGroups[i] = zabbix.HostGroupID{
-			GroupID: g.GroupID,
+			GroupID: id,
 		}
 	}
 
 	return hostGroups, nil
 }
 
-func getTemplates(d *schema.ResourceData, api *zabbix.API) (zabbix.TemplateIDs, error) {
+func getTemplates(d *schema.ResourceData, api *zabbixClient) (zabbix.TemplateIDs, error) {
 	configTemplates := d.Get("templates").(*schema.Set)
-	templateNames := make([]string, configTemplates.Len())
 
 	if configTemplates.Len() == 0 {
 		return nil, nil
 	}
 
-	for i, g := range configTemplates.List() {
-		templateNames[i] = g.(string)
-	}
-
-	log.Printf("[DEBUG] Templates %v\n", templateNames)
+	names := make([]string, configTemplates.Len())
 
-	groupParams := zabbix.Params{
-		"output": "extend",
-		"filter": map[string]interface{}{
-			"host": templateNames,
-		},
+	for i, g := range configTemplates.List() {
+		names[i] = g.(string)
 	}
 
-	templates, err := api.TemplatesGet(groupParams)
+	log.Printf("[DEBUG] Templates %v\n", names)
 
+	ids, err := api.lookupIDs(lookupTemplate, names)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(templates) < configTemplates.Len() {
-		log.Printf("[DEBUG] Not all of the specified templates were found on zabbix server")
+	templates := make(zabbix.TemplateIDs, len(names))
 
-		for _, n := range configTemplates.List() {
-			found := false
-
-			for _, g := range templates {
-				if n == g.Name {
-					found = true
-					break
-				}
-			}
-
-			if !found {
-				return nil, fmt.Errorf("Template %s doesnt exist in zabbix server", n)
-			}
-			log.Printf("[DEBUG] Template %s exists on zabbix server", n)
+	for i, n := range names {
+		id, ok := ids[n]
+		if !ok {
+			return nil, fmt.Errorf("Template %s doesnt exist in zabbix server", n)
 		}
-	}
-
-	hostTemplates := make(zabbix.TemplateIDs, len(templates))
-
-	for i, t := range templates {
-		hostTemplates[i] = zabbix.TemplateID{
-			TemplateID: t.TemplateID,
+		templates[i] = zabbix.TemplateID{
+			TemplateID: id,
 		}
 	}
 
-	return hostTemplates, nil
+	return templates, nil
 }
 
-func getHostMacro(d *schema.ResourceData) zabbix.Macros {
-	var macros zabbix.Macros
-
-	terraformMacros := d.Get("macro").(map[string]interface{})
-	for i, terraformMacro := range terraformMacros {
-		macro := zabbix.Macro{
-			MacroName: fmt.Sprintf("{$%s}", i),
-			Value:     terraformMacro.(string),
-		}
-		macros = append(macros, macro)
-	}
-	return macros
-}
-
-func createHostObj(d *schema.ResourceData, api *zabbix.API) (*zabbix.Host, error) {
-	host := zabbix.Host{
-		Host:   d.Get("host").(string),
-		Name:   d.Get("name").(string),
-		Status: 0,
+func createHostObj(d *schema.ResourceData, api *zabbixClient) (*zabbixHost, error) {
+	host := zabbixHost{
+		Host: zabbix.Host{
+			Host:   d.Get("host").(string),
+			Name:   d.Get("name").(string),
+			Status: 0,
+		},
 	}
 
 	//0 is monitored, 1 - unmonitored host
@@ -309,7 +458,7 @@ func createHostObj(d *schema.ResourceData, api *zabbix.API) (*zabbix.Host, error
 
 	host.GroupIds = hostGroups
 
-	interfaces, err := getInterfaces(d)
+	interfaces, err := getInterfaces(d, api)
 
 	if err != nil {
 		return nil, err
@@ -325,53 +474,63 @@ func createHostObj(d *schema.ResourceData, api *zabbix.API) (*zabbix.Host, error
 
 	host.TemplateIDs = templates
 
-	host.UserMacros = getHostMacro(d)
+	macros, err := createZabbixMacros(d, api)
+
+	if err != nil {
+		return nil, err
+	}
+
+	host.UserMacros = macros
 
 	return &host, nil
 }
 
-func resourceZabbixHostCreate(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixHostCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
 
 	host, err := createHostObj(d, api)
 
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 
-	hosts := zabbix.Hosts{*host}
+	response, err := api.CallWithError("host.create", []zabbixHost{*host})
 
-	err = api.HostsCreate(hosts)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	hostID, err := idFromResponse(response, "hostids")
 
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 
-	log.Printf("[DEBUG] Created host id is %s", hosts[0].HostID)
+	log.Printf("[DEBUG] Created host id is %s", hostID)
 
-	d.SetId(hosts[0].HostID)
+	d.SetId(hostID)
 
-	return resourceZabbixHostRead(d, meta)
+	return resourceZabbixHostRead(ctx, d, meta)
 }
 
-func resourceZabbixHostRead(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixHostRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
 
 	log.Printf("[DEBUG] Will read host with id %s", d.Id())
 
-	hosts, err := api.HostsGet(zabbix.Params{
+	var hosts []zabbixHost
+	err := api.CallWithErrorParse("host.get", zabbix.Params{
 		"hostids":               d.Id(),
 		"selectInterfaces":      "extend",
 		"selectParentTemplates": []string{"name"},
-		"selectMacros":          "extend",
-	})
+	}, &hosts)
 
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 
-	if len(hosts) != 1 {
-		return fmt.Errorf("Expected one host with id %s and got %d hosts", d.Id(), len(hosts))
+	if err := expectOne("host", d.Id(), len(hosts)); err != nil {
+		return readError(d, err)
 	}
 	host := hosts[0]
 	log.Printf("[DEBUG] Host name is %s", host.Name)
@@ -385,6 +544,9 @@ func resourceZabbixHostRead(d *schema.ResourceData, meta interface{}) error {
 	interfaces := make([]map[string]interface{}, len(host.Interfaces))
 
 	for i, ifa := range host.Interfaces {
+		prefix := fmt.Sprintf("interfaces.%d.", i)
+		bulk := ifa.Bulk
+
 		interfaces[i] = map[string]interface{}{
 			"interface_id": ifa.InterfaceID,
 			"dns":          ifa.DNS,
@@ -392,7 +554,31 @@ func resourceZabbixHostRead(d *schema.ResourceData, meta interface{}) error {
 			"main":         ifa.Main == 1,
 			"port":         ifa.Port,
 			"type":         HostInterfaceTypeStrings[ifa.Type],
+			// Zabbix keeps the SNMP settings in the items before 5.0, and does not return the passphrases
+			"snmp_version":    d.Get(prefix + "snmp_version"),
+			"community":       d.Get(prefix + "community"),
+			"security_name":   d.Get(prefix + "security_name"),
+			"security_level":  d.Get(prefix + "security_level"),
+			"auth_protocol":   d.Get(prefix + "auth_protocol"),
+			"auth_passphrase": d.Get(prefix + "auth_passphrase"),
+			"priv_protocol":   d.Get(prefix + "priv_protocol"),
+			"priv_passphrase": d.Get(prefix + "priv_passphrase"),
+			"context_name":    d.Get(prefix + "context_name"),
+		}
+
+		if details := ifa.Details; details != nil && details.Version != "" {
+			bulk = details.Bulk
+			interfaces[i]["snmp_version"], _ = strconv.Atoi(details.Version)
+			interfaces[i]["community"] = details.Community
+			if details.Version == "3" {
+				interfaces[i]["security_name"] = details.SecurityName
+				interfaces[i]["security_level"] = SNMPSecurityLevelStringMap[details.SecurityLevel]
+				interfaces[i]["auth_protocol"] = SNMPAuthProtocolStringMap[details.AuthProtocol]
+				interfaces[i]["priv_protocol"] = SNMPPrivProtocolStringMap[details.PrivProtocol]
+				interfaces[i]["context_name"] = details.ContextName
+			}
 		}
+		interfaces[i]["bulk"] = bulk != "0"
 	}
 
 	d.Set("interfaces", interfaces)
@@ -405,24 +591,22 @@ func resourceZabbixHostRead(d *schema.ResourceData, meta interface{}) error {
 
 	d.Set("templates", templateNames)
 
-	macros := make(map[string]interface{}, len(host.UserMacros))
+	macros, err := getUserMacros(api, zabbix.Params{
+		"output":  "extend",
+		"hostids": d.Id(),
+	})
 
-	for _, macro := range host.UserMacros {
-		var name string
-		if noPrefix := strings.Split(macro.MacroName, "{$"); len(noPrefix) == 2 {
-			name = noPrefix[1]
-		} else {
-			return fmt.Errorf("Invalid macro name \"%s\"", macro.MacroName)
-		}
-		if noSuffix := strings.Split(name, "}"); len(noSuffix) == 2 {
-			name = noSuffix[0]
-		} else {
-			return fmt.Errorf("Invalid macro name \"%s\"", macro.MacroName)
-		}
-		macros[name] = macro.Value
+	if err != nil {
+		return diag.FromErr(err)
 	}
 
-	d.Set("macro", macros)
+	terraformMacros, err := createTerraformMacros(d, macros)
+
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.Set("macro", terraformMacros)
 
 	params := zabbix.Params{
 		"output": []string{"name"},
@@ -434,7 +618,7 @@ func resourceZabbixHostRead(d *schema.ResourceData, meta interface{}) error {
 	groups, err := api.HostGroupsGet(params)
 
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 
 	groupNames := make([]string, len(groups))
@@ -448,32 +632,45 @@ func resourceZabbixHostRead(d *schema.ResourceData, meta interface{}) error {
 	return nil
 }
 
-func resourceZabbixHostUpdate(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixHostUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
 
 	host, err := createHostObj(d, api)
 
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 
 	host.HostID = d.Id()
 
-	hosts := zabbix.Hosts{*host}
-
-	err = api.HostsUpdate(hosts)
+	_, err = api.CallWithError("host.update", []zabbixHost{*host})
 
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 
-	log.Printf("[DEBUG] Created host id is %s", hosts[0].HostID)
+	log.Printf("[DEBUG] Updated host id is %s", host.HostID)
 
-	return resourceZabbixHostRead(d, meta)
+	return resourceZabbixHostRead(ctx, d, meta)
 }
 
-func resourceZabbixHostDelete(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixHostDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
 
-	return api.HostsDeleteByIds([]string{d.Id()})
+	return diag.FromErr(api.HostsDeleteByIds([]string{d.Id()}))
+}
+
+func getHostIDs(api *zabbixClient, field, value string) ([]string, error) {
+	hosts, err := api.HostsGet(zabbix.Params{
+		"output": []string{"hostid"},
+		"filter": map[string]interface{}{field: value},
+	})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(hosts))
+	for i, host := range hosts {
+		ids[i] = host.HostID
+	}
+	return ids, nil
 }
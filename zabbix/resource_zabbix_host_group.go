@@ -1,22 +1,22 @@
 package zabbix
 
 import (
+	"context"
 	"log"
-	"strings"
 
 	"github.com/claranet/go-zabbix-api"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 func resourceZabbixHostGroup() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceZabbixHostGroupCreate,
-		Read:   resourceZabbixHostGroupRead,
-		Exists: resourceZabbixHostGroupExists,
-		Update: resourceZabbixHostGroupUpdate,
-		Delete: resourceZabbixHostGroupDelete,
+		CreateContext: resourceZabbixHostGroupCreate,
+		ReadContext:   resourceZabbixHostGroupRead,
+		UpdateContext: resourceZabbixHostGroupUpdate,
+		DeleteContext: resourceZabbixHostGroupDelete,
 		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
+			StateContext: importStateByName("host group", getHostGroupIDs, "name"),
 		},
 		Schema: map[string]*schema.Schema{
 			"name": &schema.Schema{
@@ -33,8 +33,8 @@ func resourceZabbixHostGroup() *schema.Resource {
 	}
 }
 
-func resourceZabbixHostGroupCreate(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixHostGroupCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
 
 	hostGroup := zabbix.HostGroup{
 		Name: d.Get("name").(string),
@@ -43,7 +43,7 @@ func resourceZabbixHostGroupCreate(d *schema.ResourceData, meta interface{}) err
 
 	err := api.HostGroupsCreate(groups)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 
 	groupID := groups[0].GroupID
@@ -56,16 +56,21 @@ func resourceZabbixHostGroupCreate(d *schema.ResourceData, meta interface{}) err
 	return nil
 }
 
-func resourceZabbixHostGroupRead(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixHostGroupRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
 
 	log.Printf("[DEBUG] Will read host group with id %s", d.Id())
 
-	group, err := api.HostGroupGetByID(d.Id())
-
+	groups, err := api.HostGroupsGet(zabbix.Params{
+		"groupids": d.Id(),
+	})
 	if err != nil {
-		return err
+		return diag.FromErr(err)
+	}
+	if err := expectOne("host group", d.Id(), len(groups)); err != nil {
+		return readError(d, err)
 	}
+	group := groups[0]
 
 	d.Set("name", group.Name)
 	d.Set("group_id", group.GroupID)
@@ -73,33 +78,34 @@ func resourceZabbixHostGroupRead(d *schema.ResourceData, meta interface{}) error
 	return nil
 }
 
-func resourceZabbixHostGroupExists(d *schema.ResourceData, meta interface{}) (bool, error) {
-	api := meta.(*zabbix.API)
-
-	_, err := api.HostGroupGetByID(d.Id())
-	if err != nil {
-		if strings.Contains(err.Error(), "Expected exactly one result") {
-			log.Printf("[DEBUG] Host group with id %s doesn't exist", d.Id())
-			return false, nil
-		}
-		return false, err
-	}
-	return true, nil
-}
-
-func resourceZabbixHostGroupUpdate(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixHostGroupUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
 
 	hostGroup := zabbix.HostGroup{
 		Name:    d.Get("name").(string),
 		GroupID: d.Id(),
 	}
 
-	return api.HostGroupsUpdate(zabbix.HostGroups{hostGroup})
+	return diag.FromErr(api.HostGroupsUpdate(zabbix.HostGroups{hostGroup}))
 }
 
-func resourceZabbixHostGroupDelete(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixHostGroupDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
+
+	return diag.FromErr(api.HostGroupsDeleteByIds([]string{d.Id()}))
+}
 
-	return api.HostGroupsDeleteByIds([]string{d.Id()})
+func getHostGroupIDs(api *zabbixClient, field, value string) ([]string, error) {
+	groups, err := api.HostGroupsGet(zabbix.Params{
+		"output": []string{"groupid"},
+		"filter": map[string]interface{}{field: value},
+	})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(groups))
+	for i, group := range groups {
+		ids[i] = group.GroupID
+	}
+	return ids, nil
 }
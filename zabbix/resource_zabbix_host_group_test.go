@@ -28,12 +28,18 @@ func TestAccZabbixHostGroup_Basic(t *testing.T) {
 					resource.TestCheckResourceAttr("zabbix_host_group.zabbix", "name", groupName),
 				),
 			},
+			{
+				ResourceName:      "zabbix_host_group.zabbix",
+				ImportState:       true,
+				ImportStateId:     fmt.Sprintf("name:%s", groupName),
+				ImportStateVerify: true,
+			},
 		},
 	})
 }
 
 func testAccCheckZabbixHostGroupDestroy(s *terraform.State) error {
-	api := testAccProvider.Meta().(*zabbix.API)
+	api := testAccProvider.Meta().(*zabbixClient)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "zabbix_host_group" {
@@ -70,7 +76,7 @@ func testAccCheckZabbixHostGroupExists(resource string, hostGroup *zabbix.HostGr
 			return fmt.Errorf("No record ID set")
 		}
 
-		api := testAccProvider.Meta().(*zabbix.API)
+		api := testAccProvider.Meta().(*zabbixClient)
 		group, err := api.HostGroupGetByID(rs.Primary.ID)
 		if err != nil {
 			return err
@@ -1,6 +1,7 @@
 package zabbix
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -48,6 +49,18 @@ func TestAccZabbixHost_Basic(t *testing.T) {
 					testAccCheckZabbixHostAttributes(&getHost, expectedHost2, []string{hostGroup}),
 				),
 			},
+			{
+				ResourceName:      "zabbix_host.zabbix1",
+				ImportState:       true,
+				ImportStateId:     fmt.Sprintf("host:%s", host),
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:      "zabbix_host.zabbix1",
+				ImportState:       true,
+				ImportStateId:     fmt.Sprintf("name:%s", name),
+				ImportStateVerify: true,
+			},
 		},
 	})
 }
@@ -84,8 +97,88 @@ func TestAccZabbixHost_Templates(t *testing.T) {
 	})
 }
 
+func TestHostSNMPInterface(t *testing.T) {
+	config := map[string]interface{}{
+		"host":   "snmp",
+		"groups": []interface{}{"Linux servers"},
+		"interfaces": []interface{}{map[string]interface{}{
+			"ip":        "127.0.0.1",
+			"main":      true,
+			"port":      "161",
+			"type":      "snmp",
+			"bulk":      false,
+			"community": "public",
+		}},
+	}
+
+	for _, serverVersion := range []string{"4.0.0", "5.0.0", "6.4.0"} {
+		s, api := newFakeZabbixClient(t, serverVersion)
+
+		start := s.requestCount()
+		host := testApplyResource(t, serverVersion, resourceZabbixHost(), api, nil, config)
+
+		// The SNMP settings are only sent as details from Zabbix 5.0, and bulk moved to them
+		expected := fakeObject{"bulk": "0", "details": nil}
+		if s.atLeast("5.0") {
+			expected = fakeObject{"bulk": nil, "details": fakeObject{"version": "2", "bulk": "0", "community": "public"}}
+		}
+		testAssertRequests(t, serverVersion, s.requestsFrom(start), []fakePayload{
+			{"host.create", []interface{}{fakeObject{"interfaces": []interface{}{expected}}}},
+		})
+
+		// The details changed outside of Terraform are read back
+		if s.atLeast("5.0") {
+			_, stored := s.find(host.ID, "host")
+			details := stored["interfaces"].([]interface{})[0].(fakeObject)["details"].(fakeObject)
+			details["community"] = "private"
+			details["bulk"] = "1"
+			state, diags := resourceZabbixHost().RefreshWithoutUpgrade(context.Background(), host, api)
+			if diags.HasError() {
+				t.Fatal(diags)
+			}
+			if got := state.Attributes["interfaces.0.community"]; got != "private" {
+				t.Errorf("%s: expected the community to be read back, got %q", serverVersion, got)
+			}
+			if got := state.Attributes["interfaces.0.bulk"]; got != "true" {
+				t.Errorf("%s: expected bulk to be read back, got %q", serverVersion, got)
+			}
+		}
+	}
+}
+
+func TestHostMacrosUntouched(t *testing.T) {
+	config := map[string]interface{}{
+		"host":   "macros",
+		"groups": []interface{}{"Linux servers"},
+	}
+
+	s, api := newFakeZabbixClient(t, "6.4.0")
+
+	host := testApplyResource(t, "host", resourceZabbixHost(), api, nil, config)
+	macro := testApplyResource(t, "user macro", resourceZabbixUserMacro(), api, nil, map[string]interface{}{
+		"host_id": host.ID,
+		"name":    "MANAGED",
+		"value":   "elsewhere",
+	})
+	host, diags := resourceZabbixHost().RefreshWithoutUpgrade(context.Background(), host, api)
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	// The macros read from the host are not sent back when the macro blocks are omitted
+	config["name"] = "renamed"
+	start := s.requestCount()
+	testApplyResource(t, "host", resourceZabbixHost(), api, host, config)
+	testAssertRequests(t, "host", s.requestsFrom(start), []fakePayload{
+		{"host.update", []interface{}{fakeObject{"name": "renamed", "macros": nil}}},
+	})
+	if _, stored := s.find(macro.ID, "usermacro"); stored == nil {
+		t.Errorf("expected the macro managed by zabbix_user_macro to be kept")
+	}
+}
+
 func testAccCheckZabbixHostDestroy(s *terraform.State) error {
-	api := testAccProvider.Meta().(*zabbix.API)
+	api := testAccProvider.Meta().(*zabbixClient)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "zabbix_host" {
@@ -151,8 +244,9 @@ func testAccZabbixHostTemplatesConfig(host string, name string, hostGroup string
 			}
 			groups    = ["${zabbix_host_group.zabbix.name}"]
 			templates = ["${zabbix_template.zabbix.host}"]
-			macro = {
-			  MACRO1 = "value3"
+			macro {
+			  name  = "MACRO1"
+			  value = "value3"
 			}
 	  	}
 
@@ -168,9 +262,13 @@ func testAccZabbixHostTemplatesConfig(host string, name string, hostGroup string
 			host = "%s"
 			groups = ["${zabbix_template_group.zabbix.name}"]
 			description = "test_template_description"
-			macro = {
-			  MACRO1 = "value1"
-			  MACRO2 = "value2"
+			macro {
+			  name  = "MACRO1"
+			  value = "value1"
+			}
+			macro {
+			  name  = "MACRO2"
+			  value = "value2"
 			}
 		}`, host, name, hostGroup, templateGroup, parentTemplate,
 	)
@@ -186,7 +284,7 @@ func testAccCheckZabbixHostExists(resource string, host *zabbix.Host) resource.T
 			return fmt.Errorf("No record ID id set")
 		}
 
-		api := testAccProvider.Meta().(*zabbix.API)
+		api := testAccProvider.Meta().(*zabbixClient)
 		hosts, err := api.HostsGet(zabbix.Params{
 			"hostids":               rs.Primary.ID,
 			"selectInterfaces":      "extend",
@@ -204,7 +302,7 @@ func testAccCheckZabbixHostExists(resource string, host *zabbix.Host) resource.T
 
 func testAccCheckZabbixHostAttributes(host *zabbix.Host, want zabbix.Host, groupNames []string) resource.TestCheckFunc {
 	return func(state *terraform.State) error {
-		api := testAccProvider.Meta().(*zabbix.API)
+		api := testAccProvider.Meta().(*zabbixClient)
 
 		if host.Host != want.Host {
 			return fmt.Errorf("Got host name: %q, expected: %q", host.Host, want.Host)
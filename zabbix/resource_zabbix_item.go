@@ -1,24 +1,42 @@
 package zabbix
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/claranet/go-zabbix-api"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
+// zabbixItem carries the item properties that zabbix.Item does not know about
+type zabbixItem struct {
+	zabbix.Item
+	ValueMapID string `json:"valuemapid,omitempty"`
+}
+
 func resourceZabbixItem() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceZabbixItemCreate,
-		Read:   resourceZabbixItemRead,
-		Exists: resourceZabbixItemExists,
-		Update: resourceZabbixItemUpdate,
-		Delete: resourceZabbixItemDelete,
+		CreateContext: resourceZabbixItemCreate,
+		ReadContext:   resourceZabbixItemRead,
+		UpdateContext: resourceZabbixItemUpdate,
+		DeleteContext: resourceZabbixItemDelete,
 		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
+			StateContext: resourceZabbixItemImportState,
 		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(time.Minute),
+			Update: schema.DefaultTimeout(time.Minute),
+			Delete: schema.DefaultTimeout(time.Minute),
+		},
+		CustomizeDiff: customizeDiffVersion(
+			removedIn("3.4", "data_type", 0),
+			removedIn("3.4", "delta", 0),
+			durations("delay", "history", "trends"),
+		),
 		Schema: map[string]*schema.Schema{
 			"delay": &schema.Schema{
 				Type:     schema.TypeString,
@@ -101,27 +119,38 @@ func resourceZabbixItem() *schema.Resource {
 				Default:     "",
 			},
 			"history": &schema.Schema{
-				Type:        schema.TypeString,
-				Computed:    true,
-				Optional:    true,
-				Description: "Number of days to keep item's history data. From 3.4 version, string is required instead of integer. Default: 90 (90d for 3.4+).",
+				Type:             schema.TypeString,
+				Computed:         true,
+				Optional:         true,
+				Description:      "Number of days to keep item's history data. From 3.4 version, string is required instead of integer. Default: 90 (90d for 3.4+).",
+				ValidateDiagFunc: validateStoragePeriod,
 			},
 			"trends": &schema.Schema{
-				Type:        schema.TypeString,
-				Computed:    true,
-				Optional:    true,
-				Description: "Number of days to keep item's trends data. From 3.4 version, string is required instead of interger. Default: 365 (365d for 3.4+).",
+				Type:             schema.TypeString,
+				Computed:         true,
+				Optional:         true,
+				Description:      "Number of days to keep item's trends data. From 3.4 version, string is required instead of interger. Default: 365 (365d for 3.4+).",
+				ValidateDiagFunc: validateStoragePeriod,
 			},
 			"trapper_host": &schema.Schema{
 				Type:        schema.TypeString,
 				Optional:    true,
 				Description: "Allowed hosts. Used only by trapper items.",
 			},
+			"valuemap": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "ID or name of the value map applied to the item.",
+			},
 		},
 	}
 }
 
-func createItemObject(d *schema.ResourceData) *zabbix.Item {
+func createItemObject(d *schema.ResourceData, api *zabbixClient) (*zabbixItem, error) {
+	valueMapID, err := getValueMapID(d, api)
+	if err != nil {
+		return nil, err
+	}
 
 	item := zabbix.Item{
 		Delay:        d.Get("delay").(string),
@@ -139,21 +168,39 @@ func createItemObject(d *schema.ResourceData) *zabbix.Item {
 		TrapperHosts: d.Get("trapper_host").(string),
 	}
 
-	return &item
+	return &zabbixItem{Item: item, ValueMapID: valueMapID}, nil
 }
 
-func resourceZabbixItemCreate(d *schema.ResourceData, meta interface{}) error {
-	item := createItemObject(d)
+func resourceZabbixItemCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
 
-	return createRetry(d, meta, createItem, *item, resourceZabbixItemRead)
+	item, err := createItemObject(d, api)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	return createRetry(ctx, d, meta, createItem, *item, resourceZabbixItemRead, d.Timeout(schema.TimeoutCreate))
 }
 
-func resourceZabbixItemRead(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixItemRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
+
+	var items []zabbixItem
+	err := api.CallWithErrorParse("item.get", zabbix.Params{
+		"itemids": d.Id(),
+		"output":  "extend",
+	}, &items)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err := expectOne("item", d.Id(), len(items)); err != nil {
+		return readError(d, err)
+	}
+	item := items[0]
 
-	item, err := api.ItemGetByID(d.Id())
+	valueMap, err := getValueMapRef(api, d.Get("valuemap").(string), item.ValueMapID)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 
 	d.Set("delay", item.Delay)
@@ -169,42 +216,65 @@ func resourceZabbixItemRead(d *schema.ResourceData, meta interface{}) error {
 	d.Set("history", item.History)
 	d.Set("trends", item.Trends)
 	d.Set("trapper_host", item.TrapperHosts)
+	d.Set("valuemap", valueMap)
 
 	log.Printf("[DEBUG] Item name is %s\n", item.Name)
 	return nil
 }
 
-func resourceZabbixItemExists(d *schema.ResourceData, meta interface{}) (bool, error) {
-	api := meta.(*zabbix.API)
+// resourceZabbixItemImportState accepts the ID of the item or <host>:<key>, host being the technical name
+// of the host or template
+func resourceZabbixItemImportState(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	api := meta.(*zabbixClient)
+
+	parts := strings.SplitN(d.Id(), ":", 2)
+	if len(parts) != 2 {
+		return []*schema.ResourceData{d}, nil
+	}
+
+	items, err := api.ItemsGet(zabbix.Params{
+		"output": []string{"itemid"},
+		"host":   parts[0],
+		"filter": map[string]interface{}{"key_": parts[1]},
+	})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ItemID
+	}
 
-	_, err := api.ItemGetByID(d.Id())
+	id, err := singleImportID("item", fmt.Sprintf("key %q on host %q", parts[1], parts[0]), ids)
 	if err != nil {
-		if strings.Contains(err.Error(), "Expected exactly one result") {
-			log.Printf("[DEBUG] Item with id %s doesn't exist", d.Id())
-			return false, nil
-		}
-		return false, err
+		return nil, err
 	}
-	return true, nil
+	d.SetId(id)
+	return []*schema.ResourceData{d}, nil
 }
 
-func resourceZabbixItemUpdate(d *schema.ResourceData, meta interface{}) error {
-	item := createItemObject(d)
+func resourceZabbixItemUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
+
+	item, err := createItemObject(d, api)
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
 	item.ItemID = d.Id()
 	// Read-only when updated
 	item.HostID = ""
-	return createRetry(d, meta, updateItem, *item, resourceZabbixItemRead)
+	return createRetry(ctx, d, meta, updateItem, *item, resourceZabbixItemRead, d.Timeout(schema.TimeoutUpdate))
 
 }
 
-func resourceZabbixItemDelete(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixItemDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
 
-	return deleteRetry(d.Id(), getItemParentID, api.ItemsDeleteIDs, api)
+	return deleteRetry(ctx, d.Id(), getItemParentID, api.ItemsDeleteIDs, api, d.Timeout(schema.TimeoutDelete))
 }
 
-func getItemParentID(api *zabbix.API, id string) (string, error) {
+func getItemParentID(api *zabbixClient, id string) (string, error) {
 	items, err := api.ItemsGet(zabbix.Params{
 		"output":      "extend",
 		"selectHosts": "extend",
@@ -222,24 +292,18 @@ func getItemParentID(api *zabbix.API, id string) (string, error) {
 	return items[0].ItemParent[0].HostID, nil
 }
 
-func createItem(item interface{}, api *zabbix.API) (id string, err error) {
-	items := zabbix.Items{item.(zabbix.Item)}
-
-	err = api.ItemsCreate(items)
+func createItem(item interface{}, api *zabbixClient) (id string, err error) {
+	response, err := api.CallWithError("item.create", []zabbixItem{item.(zabbixItem)})
 	if err != nil {
 		return
 	}
-	id = items[0].ItemID
-	return
+	return idFromResponse(response, "itemids")
 }
 
-func updateItem(item interface{}, api *zabbix.API) (id string, err error) {
-	items := zabbix.Items{item.(zabbix.Item)}
-
-	err = api.ItemsUpdate(items)
+func updateItem(item interface{}, api *zabbixClient) (id string, err error) {
+	response, err := api.CallWithError("item.update", []zabbixItem{item.(zabbixItem)})
 	if err != nil {
 		return
 	}
-	id = items[0].ItemID
-	return
+	return idFromResponse(response, "itemids")
 }
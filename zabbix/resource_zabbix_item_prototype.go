@@ -1,24 +1,41 @@
 package zabbix
 
 import (
+	"context"
 	"fmt"
 	"log"
-	"strings"
+	"time"
 
 	"github.com/claranet/go-zabbix-api"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
+// zabbixItemPrototype carries the item prototype properties that zabbix.ItemPrototype does not know about
+type zabbixItemPrototype struct {
+	zabbix.ItemPrototype
+	ValueMapID string `json:"valuemapid,omitempty"`
+}
+
 func resourceZabbixItemPrototype() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceZabbixItemPrototypeCreate,
-		Read:   resourceZabbixItemPrototypeRead,
-		Exists: resourceZabbixItemPrototypeExist,
-		Update: resourceZabbixItemPrototypeUpdate,
-		Delete: resourceZabbixItemPrototypeDelete,
+		CreateContext: resourceZabbixItemPrototypeCreate,
+		ReadContext:   resourceZabbixItemPrototypeRead,
+		UpdateContext: resourceZabbixItemPrototypeUpdate,
+		DeleteContext: resourceZabbixItemPrototypeDelete,
 		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
+			StateContext: resourceZabbixItemPrototypeImportState,
 		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(time.Minute),
+			Update: schema.DefaultTimeout(time.Minute),
+			Delete: schema.DefaultTimeout(time.Minute),
+		},
+		CustomizeDiff: customizeDiffVersion(
+			removedIn("3.4", "data_type", 0),
+			removedIn("3.4", "delta", 0),
+			durations("delay", "history", "trends"),
+		),
 		Schema: map[string]*schema.Schema{
 			"delay": &schema.Schema{
 				Type:     schema.TypeString,
@@ -105,16 +122,18 @@ func resourceZabbixItemPrototype() *schema.Resource {
 				Default:     "",
 			},
 			"history": &schema.Schema{
-				Type:        schema.TypeString,
-				Computed:    true,
-				Optional:    true,
-				Description: "Number of days to keep item's history data. Default: 90.",
+				Type:             schema.TypeString,
+				Computed:         true,
+				Optional:         true,
+				Description:      "Number of days to keep item's history data. Default: 90.",
+				ValidateDiagFunc: validateStoragePeriod,
 			},
 			"trends": &schema.Schema{
-				Type:        schema.TypeString,
-				Computed:    true,
-				Optional:    true,
-				Description: "Number of days to keep item's trends data. Default: 365.",
+				Type:             schema.TypeString,
+				Computed:         true,
+				Optional:         true,
+				Description:      "Number of days to keep item's trends data. Default: 365.",
+				ValidateDiagFunc: validateStoragePeriod,
 			},
 			"trapper_host": &schema.Schema{
 				Type:        schema.TypeString,
@@ -127,11 +146,20 @@ func resourceZabbixItemPrototype() *schema.Resource {
 				Default:     "0",
 				Description: "Status of the item.",
 			},
+			"valuemap": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "ID or name of the value map applied to the item prototype.",
+			},
 		},
 	}
 }
 
-func createItemPrototypeObject(d *schema.ResourceData, api *zabbix.API) (*zabbix.ItemPrototype, error) {
+func createItemPrototypeObject(d *schema.ResourceData, api *zabbixClient) (*zabbixItemPrototype, error) {
+	valueMapID, err := getValueMapID(d, api)
+	if err != nil {
+		return nil, err
+	}
 
 	item := zabbix.ItemPrototype{
 		Delay:        d.Get("delay").(string),
@@ -150,36 +178,42 @@ func createItemPrototypeObject(d *schema.ResourceData, api *zabbix.API) (*zabbix
 		TrapperHosts: d.Get("trapper_host").(string),
 		Status:       d.Get("status").(int),
 	}
-	return &item, nil
+	return &zabbixItemPrototype{ItemPrototype: item, ValueMapID: valueMapID}, nil
 }
 
-func resourceZabbixItemPrototypeCreate(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixItemPrototypeCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
 
 	item, err := createItemPrototypeObject(d, api)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 
-	return createRetry(d, meta, createItemPrototype, *item, resourceZabbixItemPrototypeRead)
+	return createRetry(ctx, d, meta, createItemPrototype, *item, resourceZabbixItemPrototypeRead, d.Timeout(schema.TimeoutCreate))
 }
 
-func resourceZabbixItemPrototypeRead(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixItemPrototypeRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
 
-	items, err := api.ItemPrototypesGet(zabbix.Params{
+	var items []zabbixItemPrototype
+	err := api.CallWithErrorParse("itemprototype.get", zabbix.Params{
 		"itemids":             d.Id(),
 		"output":              "extend",
 		"selectDiscoveryRule": "extend",
-	})
+	}, &items)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
-	if len(items) != 1 {
-		return fmt.Errorf("Expected one item prototype and got : %d ", len(items))
+	if err := expectOne("item prototype", d.Id(), len(items)); err != nil {
+		return readError(d, err)
 	}
 	item := items[0]
 
+	valueMap, err := getValueMapRef(api, d.Get("valuemap").(string), item.ValueMapID)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
 	d.Set("delay", item.Delay)
 	d.Set("host_id", item.HostID)
 	d.Set("interface_id", item.InterfaceID)
@@ -195,31 +229,55 @@ func resourceZabbixItemPrototypeRead(d *schema.ResourceData, meta interface{}) e
 	d.Set("trends", item.Trends)
 	d.Set("trapper_host", item.TrapperHosts)
 	d.Set("status", item.Status)
+	d.Set("valuemap", valueMap)
 
 	log.Printf("[DEBUG] Item prototype name is %s\n", item.Name)
 	return nil
 }
 
-func resourceZabbixItemPrototypeExist(d *schema.ResourceData, meta interface{}) (bool, error) {
-	api := meta.(*zabbix.API)
+// resourceZabbixItemPrototypeImportState accepts the ID of the item prototype or <host>/<lld rule key>/<key>
+func resourceZabbixItemPrototypeImportState(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	api := meta.(*zabbixClient)
+
+	parts := splitImportID(d.Id(), 3)
+	if len(parts) != 3 {
+		return []*schema.ResourceData{d}, nil
+	}
 
-	_, err := api.ItemPrototypeGetByID(d.Id())
+	ruleID, hostID, err := getLLDRuleByKey(api, parts[0], parts[1])
 	if err != nil {
-		if strings.Contains(err.Error(), "Expected exactly one result") {
-			log.Printf("[DEBUG] Item prototype with id %s doesn't exist", d.Id())
-			return false, nil
-		}
-		return false, err
+		return nil, err
 	}
-	return true, nil
+
+	items, err := api.ItemPrototypesGet(zabbix.Params{
+		"output":       []string{"itemid"},
+		"discoveryids": ruleID,
+		"filter":       map[string]interface{}{"key_": parts[2]},
+	})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ItemID
+	}
+	id, err := singleImportID("item prototype", fmt.Sprintf("key %q in rule %q of host %q", parts[2], parts[1], parts[0]), ids)
+	if err != nil {
+		return nil, err
+	}
+
+	d.SetId(id)
+	d.Set("host_id", hostID)
+	d.Set("rule_id", ruleID)
+	return []*schema.ResourceData{d}, nil
 }
 
-func resourceZabbixItemPrototypeUpdate(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixItemPrototypeUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
 
 	item, err := createItemPrototypeObject(d, api)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 
 	item.ItemID = d.Id()
@@ -227,16 +285,16 @@ func resourceZabbixItemPrototypeUpdate(d *schema.ResourceData, meta interface{})
 	item.HostID = ""
 	item.RuleID = ""
 	log.Printf("[DEBUG] Update item prototype %#v", item)
-	return createRetry(d, meta, updateItemPrototype, *item, resourceZabbixItemPrototypeRead)
+	return createRetry(ctx, d, meta, updateItemPrototype, *item, resourceZabbixItemPrototypeRead, d.Timeout(schema.TimeoutUpdate))
 }
 
-func resourceZabbixItemPrototypeDelete(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixItemPrototypeDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
 
-	return deleteRetry(d.Id(), getItemPrototypeParentID, api.ItemPrototypesDeleteIDs, api)
+	return deleteRetry(ctx, d.Id(), getItemPrototypeParentID, api.ItemPrototypesDeleteIDs, api, d.Timeout(schema.TimeoutDelete))
 }
 
-func getItemPrototypeParentID(api *zabbix.API, id string) (string, error) {
+func getItemPrototypeParentID(api *zabbixClient, id string) (string, error) {
 	items, err := api.ItemPrototypesGet(zabbix.Params{
 		"output":      "extend",
 		"selectHosts": "extend",
@@ -245,8 +303,8 @@ func getItemPrototypeParentID(api *zabbix.API, id string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("%s, with item %s", err.Error(), id)
 	}
-	if len(items) != 1 {
-		return "", fmt.Errorf("Expected one item and got %d items", len(items))
+	if err := expectOne("item prototype", id, len(items)); err != nil {
+		return "", err
 	}
 	if len(items[0].Hosts) != 1 {
 		return "", fmt.Errorf("Expected one parent for item %s and got %d", id, len(items[0].Hosts))
@@ -254,24 +312,18 @@ func getItemPrototypeParentID(api *zabbix.API, id string) (string, error) {
 	return items[0].Hosts[0].HostID, nil
 }
 
-func createItemPrototype(item interface{}, api *zabbix.API) (id string, err error) {
-	items := zabbix.ItemPrototypes{item.(zabbix.ItemPrototype)}
-
-	err = api.ItemPrototypesCreate(items)
+func createItemPrototype(item interface{}, api *zabbixClient) (id string, err error) {
+	response, err := api.CallWithError("itemprototype.create", []zabbixItemPrototype{item.(zabbixItemPrototype)})
 	if err != nil {
 		return
 	}
-	id = items[0].ItemID
-	return
+	return idFromResponse(response, "itemids")
 }
 
-func updateItemPrototype(item interface{}, api *zabbix.API) (id string, err error) {
-	items := zabbix.ItemPrototypes{item.(zabbix.ItemPrototype)}
-
-	err = api.ItemPrototypesUpdate(items)
+func updateItemPrototype(item interface{}, api *zabbixClient) (id string, err error) {
+	response, err := api.CallWithError("itemprototype.update", []zabbixItemPrototype{item.(zabbixItemPrototype)})
 	if err != nil {
 		return
 	}
-	id = items[0].ItemID
-	return
+	return idFromResponse(response, "itemids")
 }
@@ -31,6 +31,12 @@ func TestAccZabbixItemPrototype_Basic(t *testing.T) {
 					resource.TestCheckResourceAttr("zabbix_item_prototype.item_prototype_test", "status", "0"),
 				),
 			},
+			{
+				ResourceName:      "zabbix_item_prototype.item_prototype_test",
+				ImportState:       true,
+				ImportStateId:     fmt.Sprintf("%s/key.lolo/test.key[{#TESTMACRO}]", templateName),
+				ImportStateVerify: true,
+			},
 			{
 				Config: testAccZabbixItemPrototypeUpdateConfig(groupName, templateName),
 				Check: resource.ComposeAggregateTestCheckFunc(
@@ -47,7 +53,7 @@ func TestAccZabbixItemPrototype_Basic(t *testing.T) {
 }
 
 func testAccCheckZabbixItemPrototypeDestroy(s *terraform.State) error {
-	api := testAccProvider.Meta().(*zabbix.API)
+	api := testAccProvider.Meta().(*zabbixClient)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "zabbix_item_prototype" {
@@ -91,7 +97,7 @@ func testAccZabbixItemPrototypeConfig(groupName, templateName string) string {
 					macro = "{#TESTMACRO}"
 					value = "^lo$"
 				}
-				eval_type = 0
+				eval_type = "and/or"
 			}
 		}
 
@@ -132,7 +138,7 @@ func testAccZabbixItemPrototypeUpdateConfig(groupName, templateName string) stri
 					macro = "{#TESTMACRO}"
 					value = "^lo$"
 				}
-				eval_type = 0
+				eval_type = "and/or"
 			}
 		}
 
@@ -53,6 +53,12 @@ func TestAccZabbixItem_Basic(t *testing.T) {
 					}),
 				),
 			},
+			{
+				ResourceName:      "zabbix_item.my_item1",
+				ImportState:       true,
+				ImportStateId:     fmt.Sprintf("%s:update.bilou.bilou", templateName),
+				ImportStateVerify: true,
+			},
 		},
 	})
 }
@@ -125,7 +131,7 @@ func testAccZabbixItemExists(resource string) resource.TestCheckFunc {
 }
 
 func testAccCheckZabbixItemDestroy(s *terraform.State) error {
-	api := testAccProvider.Meta().(*zabbix.API)
+	api := testAccProvider.Meta().(*zabbixClient)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "zabbix_item" {
@@ -1,53 +1,464 @@
 package zabbix
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
-	"log"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/claranet/go-zabbix-api"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
+// discoveryRule carries the discovery rule properties that zabbix.LLDRule does not know about
+type discoveryRule struct {
+	zabbix.LLDRule
+	Description   string                  `json:"description"`
+	Status        string                  `json:"status,omitempty"`
+	Lifetime      string                  `json:"lifetime,omitempty"`
+	MasterItemID  string                  `json:"master_itemid,omitempty"`
+	Params        string                  `json:"params,omitempty"`
+	LLDMacroPaths *[]lldRuleMacroPath     `json:"lld_macro_paths,omitempty"`
+	Preprocessing *[]lldRulePreprocessing `json:"preprocessing,omitempty"`
+	Overrides     *[]lldRuleOverride      `json:"overrides,omitempty"`
+	Filter        *lldRuleFilter          `json:"filter,omitempty"`
+}
+
+type lldRuleFilter struct {
+	EvalType   string                   `json:"evaltype"`
+	Formula    string                   `json:"formula"`
+	Conditions []lldRuleFilterCondition `json:"conditions"`
+}
+
+type lldRuleFilterCondition struct {
+	LLDMacro  string `json:"macro"`
+	Value     string `json:"value"`
+	Operator  string `json:"operator"`
+	FormulaID string `json:"formulaid,omitempty"`
+}
+
+type lldRuleMacroPath struct {
+	LLDMacro string `json:"lld_macro"`
+	Path     string `json:"path"`
+}
+
+type lldRulePreprocessing struct {
+	Type               string `json:"type"`
+	Params             string `json:"params"`
+	ErrorHandler       string `json:"error_handler"`
+	ErrorHandlerParams string `json:"error_handler_params"`
+}
+
+type lldRuleOverride struct {
+	Name       string                   `json:"name"`
+	Step       string                   `json:"step"`
+	Stop       string                   `json:"stop"`
+	Filter     *lldRuleFilter           `json:"filter,omitempty"`
+	Operations []map[string]interface{} `json:"operations"`
+}
+
+var StringLLDRuleStatusMap = map[string]string{
+	"enabled":  "0",
+	"disabled": "1",
+}
+
+var LLDRuleStatusStringMap = map[string]string{
+	"0": "enabled",
+	"1": "disabled",
+}
+
+var StringLLDRuleEvaluationTypeMap = map[string]string{
+	"and/or": "0",
+	"and":    "1",
+	"or":     "2",
+	"custom": "3",
+}
+
+var LLDRuleEvaluationTypeStringMap = map[string]string{
+	"0": "and/or",
+	"1": "and",
+	"2": "or",
+	"3": "custom",
+}
+
+var StringLLDRuleFilterOperatorMap = map[string]string{
+	"matches":     "8",
+	"not_matches": "9",
+	"exists":      "12",
+	"not_exists":  "13",
+}
+
+var LLDRuleFilterOperatorStringMap = map[string]string{
+	"8":  "matches",
+	"9":  "not_matches",
+	"12": "exists",
+	"13": "not_exists",
+}
+
+var StringLLDOverrideObjectMap = map[string]string{
+	"item_prototype":    "0",
+	"trigger_prototype": "1",
+	"graph_prototype":   "2",
+	"host_prototype":    "3",
+}
+
+var LLDOverrideObjectStringMap = map[string]string{
+	"0": "item_prototype",
+	"1": "trigger_prototype",
+	"2": "graph_prototype",
+	"3": "host_prototype",
+}
+
+var StringLLDOverrideOperatorMap = map[string]string{
+	"equals":       "0",
+	"not_equals":   "1",
+	"contains":     "2",
+	"not_contains": "3",
+	"matches":      "8",
+	"not_matches":  "9",
+}
+
+var LLDOverrideOperatorStringMap = map[string]string{
+	"0": "equals",
+	"1": "not_equals",
+	"2": "contains",
+	"3": "not_contains",
+	"8": "matches",
+	"9": "not_matches",
+}
+
+var StringLLDOverrideSeverityMap = map[string]string{
+	"not_classified": "0",
+	"information":    "1",
+	"warning":        "2",
+	"average":        "3",
+	"high":           "4",
+	"disaster":       "5",
+}
+
+var LLDOverrideSeverityStringMap = map[string]string{
+	"0": "not_classified",
+	"1": "information",
+	"2": "warning",
+	"3": "average",
+	"4": "high",
+	"5": "disaster",
+}
+
+var StringLLDOverrideInventoryModeMap = map[string]string{
+	"disabled":  "-1",
+	"manual":    "0",
+	"automatic": "1",
+}
+
+var LLDOverrideInventoryModeStringMap = map[string]string{
+	"-1": "disabled",
+	"0":  "manual",
+	"1":  "automatic",
+}
+
 func resourceZabbixLLDRule() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceZabbixLLDRuleCreate,
-		Read:   resourceZabbixLLDRuleRead,
-		Exists: resourceZabbixLLDRuleExists,
-		Update: resourceZabbixLLDRuleUpdate,
-		Delete: resourceZabbixLLDRuleDelete,
+		CreateContext: resourceZabbixLLDRuleCreate,
+		ReadContext:   resourceZabbixLLDRuleRead,
+		UpdateContext: resourceZabbixLLDRuleUpdate,
+		DeleteContext: resourceZabbixLLDRuleDelete,
 		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
+			StateContext: resourceZabbixLLDRuleImportState,
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(time.Minute),
+			Update: schema.DefaultTimeout(time.Minute),
 		},
+		CustomizeDiff: customizeDiffVersion(
+			requiresVersion("4.2", "lld_macro_paths", nil),
+			requiresVersion("4.2", "preprocessing", nil),
+			requiresVersion("5.0", "override", nil),
+			durations("delay", "lifetime"),
+			lldRuleFilterFormulas("filter", "override.*.filter"),
+		),
+		SchemaVersion: 2,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Type:    resourceZabbixLLDRuleV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceZabbixLLDRuleStateUpgradeV0,
+				Version: 0,
+			},
+			{
+				Type:    resourceZabbixLLDRuleV1().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceZabbixLLDRuleStateUpgradeV1,
+				Version: 1,
+			},
+		},
+		Schema: schemaLLDRule(schemaLLDRuleFilter(), schemaLLDRuleStatus()),
+	}
+}
+
+// resourceZabbixLLDRuleV0 is the schema used before filter operators and evaluation types were named
+func resourceZabbixLLDRuleV0() *schema.Resource {
+	return &schema.Resource{
+		Schema: schemaLLDRule(schemaLLDRuleFilterV0(), schemaLLDRuleStatusV1()),
+	}
+}
+
+// resourceZabbixLLDRuleV1 is the schema used before the status of the rule was named
+func resourceZabbixLLDRuleV1() *schema.Resource {
+	return &schema.Resource{
+		Schema: schemaLLDRule(schemaLLDRuleFilter(), schemaLLDRuleStatusV1()),
+	}
+}
+
+func schemaLLDRuleStatus() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeString,
+		Optional:    true,
+		Default:     "enabled",
+		Description: "Status of the LLD rule.",
+		ValidateFunc: validation.StringInSlice(
+			[]string{"enabled", "disabled"},
+			false,
+		),
+	}
+}
+
+func schemaLLDRuleStatusV1() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeInt,
+		Optional: true,
+		Default:  0,
+	}
+}
+
+func schemaLLDRule(filter *schema.Resource, status *schema.Schema) map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"delay": &schema.Schema{
+			Type:     schema.TypeString,
+			Required: true,
+		},
+		"host_id": &schema.Schema{
+			Type:     schema.TypeString,
+			Required: true,
+		},
+		"interface_id": &schema.Schema{
+			Type:     schema.TypeString,
+			Required: true,
+		},
+		"key": &schema.Schema{Type: schema.TypeString,
+			Required: true,
+		},
+		"name": &schema.Schema{
+			Type:     schema.TypeString,
+			Required: true,
+		},
+		"type": &schema.Schema{
+			Type:     schema.TypeInt,
+			Required: true,
+		},
+		"filter": &schema.Schema{
+			Type:     schema.TypeSet,
+			MaxItems: 1,
+			Elem:     filter,
+			Optional: true,
+		},
+		"description": &schema.Schema{
+			Type:        schema.TypeString,
+			Optional:    true,
+			Default:     "",
+			Description: "Description of the LLD rule.",
+		},
+		"status": status,
+		"lifetime": &schema.Schema{
+			Type:        schema.TypeString,
+			Optional:    true,
+			Computed:    true,
+			Description: "Time period after which items that are no longer discovered will be deleted.",
+		},
+		"master_itemid": &schema.Schema{
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Master item ID. Required by dependent LLD rules.",
+		},
+		"params": &schema.Schema{
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Additional parameters depending on the type of the LLD rule, such as the script of script rules.",
+		},
+		"lld_macro_paths": &schema.Schema{
+			Type:        schema.TypeMap,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Optional:    true,
+			Description: "LLD macros mapped to the JSONPath used to extract their value.",
+		},
+		"preprocessing": &schema.Schema{
+			Type:     schema.TypeList,
+			Elem:     schemaLLDRulePreprocessing(),
+			Optional: true,
+		},
+		"override": &schema.Schema{
+			Type:     schema.TypeList,
+			Elem:     schemaLLDRuleOverride(filter),
+			Optional: true,
+		},
+	}
+}
+
+func schemaLLDRulePreprocessing() *schema.Resource {
+	return &schema.Resource{
 		Schema: map[string]*schema.Schema{
-			"delay": &schema.Schema{
-				Type:     schema.TypeString,
+			"type": &schema.Schema{
+				Type:     schema.TypeInt,
 				Required: true,
 			},
-			"host_id": &schema.Schema{
-				Type:     schema.TypeString,
-				Required: true,
+			"params": &schema.Schema{
+				Type:     schema.TypeList,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Optional: true,
 			},
-			"interface_id": &schema.Schema{
-				Type:     schema.TypeString,
-				Required: true,
+			"error_handler": &schema.Schema{
+				Type:     schema.TypeInt,
+				Optional: true,
+				Default:  0,
+				ValidateFunc: func(val interface{}, key string) (warns []string, errs []error) {
+					v := val.(int)
+					if v < 0 || v > 3 {
+						errs = append(errs, fmt.Errorf("%q, must be between 0 and 3 inclusive, got %d", key, v))
+					}
+					return
+				},
 			},
-			"key": &schema.Schema{Type: schema.TypeString,
-				Required: true,
+			"error_handler_params": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "",
 			},
+		},
+	}
+}
+
+func schemaLLDRuleOverride(filter *schema.Resource) *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
 			"name": &schema.Schema{
 				Type:     schema.TypeString,
 				Required: true,
 			},
-			"type": &schema.Schema{
-				Type:     schema.TypeInt,
-				Required: true,
+			"stop": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Stop processing next overrides if matches.",
 			},
 			"filter": &schema.Schema{
-				Type:     schema.TypeSet,
+				Type:     schema.TypeList,
 				MaxItems: 1,
-				Elem:     schemaLLDRuleFilter(),
+				Elem:     filter,
+				Optional: true,
+			},
+			"operation": &schema.Schema{
+				Type:     schema.TypeList,
+				Elem:     schemaLLDRuleOverrideOperation(),
+				Optional: true,
+			},
+		},
+	}
+}
+
+func schemaLLDRuleOverrideOperation() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"object": &schema.Schema{
+				Type:     schema.TypeString,
 				Required: true,
+				ValidateFunc: validation.StringInSlice(
+					[]string{"item_prototype", "trigger_prototype", "graph_prototype", "host_prototype"},
+					false,
+				),
+			},
+			"operator": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "equals",
+				ValidateFunc: validation.StringInSlice(
+					[]string{"equals", "not_equals", "contains", "not_contains", "matches", "not_matches"},
+					false,
+				),
+			},
+			"value": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "",
+			},
+			"status": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ValidateFunc: validation.StringInSlice(
+					[]string{"enabled", "disabled"},
+					false,
+				),
+			},
+			"discover": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ValidateFunc: validation.StringInSlice(
+					[]string{"yes", "no"},
+					false,
+				),
+			},
+			"delay": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"history": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"trends": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"severity": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ValidateFunc: validation.StringInSlice(
+					[]string{"not_classified", "information", "warning", "average", "high", "disaster"},
+					false,
+				),
+			},
+			"tag": &schema.Schema{
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"tag": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"value": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+							Default:  "",
+						},
+					},
+				},
+			},
+			"template_ids": &schema.Schema{
+				Type:     schema.TypeSet,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Optional: true,
+			},
+			"inventory_mode": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				ValidateFunc: validation.StringInSlice(
+					[]string{"disabled", "manual", "automatic"},
+					false,
+				),
 			},
 		},
 	}
@@ -62,8 +473,13 @@ func schemaLLDRuleFilter() *schema.Resource {
 				Required: true,
 			},
 			"eval_type": &schema.Schema{
-				Type:     schema.TypeInt,
-				Required: true,
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "and/or",
+				ValidateFunc: validation.StringInSlice(
+					[]string{"and/or", "and", "or", "custom"},
+					false,
+				),
 			},
 			"formula": &schema.Schema{
 				Type:     schema.TypeString,
@@ -83,38 +499,202 @@ func schemaLLDRuleFilterCondition() *schema.Resource {
 			},
 			"value": &schema.Schema{
 				Type:     schema.TypeString,
-				Required: true,
+				Optional: true,
+				Default:  "",
 			},
 			"operator": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "matches",
+				ValidateFunc: validation.StringInSlice(
+					[]string{"matches", "not_matches", "exists", "not_exists"},
+					false,
+				),
+			},
+			"formulaid": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "",
+				Description: "ID referencing the condition in the formula of custom filters, e.g. A.",
+			},
+		},
+	}
+}
+
+// lldRuleFilterFormulas rejects the custom filters without a formula, or with conditions it cannot reference, and
+// the formulas of the other filters, as Zabbix generates them
+func lldRuleFilterFormulas(attributes ...string) versionCheck {
+	return func(d *schema.ResourceDiff, serverVersion *version.Version) error {
+		for _, attribute := range attributes {
+			for _, value := range diffValues(d, attribute) {
+				var filters []interface{}
+				switch v := value.(type) {
+				case []interface{}:
+					filters = v
+				case *schema.Set:
+					filters = v.List()
+				}
+				for _, f := range filters {
+					filter, ok := f.(map[string]interface{})
+					if !ok {
+						continue
+					}
+					custom := filter["eval_type"] == "custom"
+					switch {
+					case custom && filter["formula"] == "":
+						return fmt.Errorf("%s: a formula is required by custom filters", attribute)
+					case !custom && filter["formula"] != "":
+						return fmt.Errorf("%s: a formula can only be set on custom filters", attribute)
+					}
+					for _, condition := range filter["condition"].(*schema.Set).List() {
+						condition := condition.(map[string]interface{})
+						switch {
+						case custom && condition["formulaid"] == "":
+							return fmt.Errorf("%s: the condition on %s needs a formulaid to be referenced by the formula", attribute, condition["macro"])
+						case !custom && condition["formulaid"] != "":
+							return fmt.Errorf("%s: the condition on %s can only have a formulaid in custom filters", attribute, condition["macro"])
+						}
+					}
+				}
+			}
+		}
+		return nil
+	}
+}
+
+func schemaLLDRuleFilterV0() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"condition": &schema.Schema{
+				Type: schema.TypeSet,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"macro": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"value": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"operator": &schema.Schema{
+							Type:     schema.TypeInt,
+							Optional: true,
+						},
+					},
+				},
+				Required: true,
+			},
+			"eval_type": &schema.Schema{
 				Type:     schema.TypeInt,
+				Required: true,
+			},
+			"formula": &schema.Schema{
+				Type:     schema.TypeString,
 				Optional: true,
-				Default:  "8",
 			},
 		},
 	}
 }
 
-func resourceZabbixLLDRuleCreate(d *schema.ResourceData, meta interface{}) error {
+func resourceZabbixLLDRuleStateUpgradeV0(ctx context.Context, rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	upgradeLLDRuleFilterStateV0(rawState["filter"])
+
+	if overrides, ok := rawState["override"].([]interface{}); ok {
+		for _, o := range overrides {
+			if override, ok := o.(map[string]interface{}); ok {
+				upgradeLLDRuleFilterStateV0(override["filter"])
+			}
+		}
+	}
+	return rawState, nil
+}
+
+// resourceZabbixLLDRuleStateUpgradeV1 names the status of the rule, 0 being enabled and 1 disabled
+func resourceZabbixLLDRuleStateUpgradeV1(ctx context.Context, rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	if status, ok := LLDRuleStatusStringMap[lldRuleStateNumber(rawState["status"])]; ok {
+		rawState["status"] = status
+	} else {
+		rawState["status"] = "enabled"
+	}
+	return rawState, nil
+}
+
+func upgradeLLDRuleFilterStateV0(rawFilters interface{}) {
+	filters, ok := rawFilters.([]interface{})
+	if !ok {
+		return
+	}
+	for _, f := range filters {
+		filter, ok := f.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if evalType, ok := LLDRuleEvaluationTypeStringMap[lldRuleStateNumber(filter["eval_type"])]; ok {
+			filter["eval_type"] = evalType
+		}
+
+		conditions, ok := filter["condition"].([]interface{})
+		if !ok {
+			continue
+		}
+		for _, c := range conditions {
+			condition, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if operator, ok := LLDRuleFilterOperatorStringMap[lldRuleStateNumber(condition["operator"])]; ok {
+				condition["operator"] = operator
+			} else {
+				condition["operator"] = "matches"
+			}
+		}
+	}
+}
+
+func lldRuleStateNumber(v interface{}) string {
+	switch n := v.(type) {
+	case float64:
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(n)
+	case json.Number:
+		return n.String()
+	case string:
+		return n
+	}
+	return ""
+}
+
+func resourceZabbixLLDRuleCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	rule := createLLDRuleObject(d)
 
-	return createRetry(d, meta, createLLDRule, rule, resourceZabbixLLDRuleRead)
+	return createRetry(ctx, d, meta, createLLDRule, rule, resourceZabbixLLDRuleRead, d.Timeout(schema.TimeoutCreate))
 }
 
-func resourceZabbixLLDRuleRead(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixLLDRuleRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
 	params := zabbix.Params{
 		"itemids":      d.Id(),
 		"output":       "extend",
 		"selectFilter": "extend",
 		"inherited":    false,
 	}
+	if api.ServerVersion.GreaterThanOrEqual(version.Must(version.NewVersion("4.2"))) {
+		params["selectLLDMacroPaths"] = "extend"
+		params["selectPreprocessing"] = "extend"
+	}
+	if api.ServerVersion.GreaterThanOrEqual(version.Must(version.NewVersion("5.0"))) {
+		params["selectOverrides"] = "extend"
+	}
 
-	lldRules, err := api.DiscoveryRulesGet(params)
+	var lldRules []discoveryRule
+	err := api.CallWithErrorParse("discoveryrule.get", params, &lldRules)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
-	if len(lldRules) != 1 {
-		return fmt.Errorf("Expected one low level discovery rule with id %s and got %d rules", d.Id(), len(lldRules))
+	if err := expectOne("LLD rule", d.Id(), len(lldRules)); err != nil {
+		return readError(d, err)
 	}
 	lldRule := lldRules[0]
 
@@ -124,104 +704,467 @@ func resourceZabbixLLDRuleRead(d *schema.ResourceData, meta interface{}) error {
 	d.Set("key", lldRule.Key)
 	d.Set("name", lldRule.Name)
 	d.Set("type", lldRule.Type)
+	d.Set("description", lldRule.Description)
+	d.Set("lifetime", lldRule.Lifetime)
+	if lldRule.MasterItemID != "0" {
+		d.Set("master_itemid", lldRule.MasterItemID)
+	}
+	d.Set("params", lldRule.Params)
+
+	status, ok := LLDRuleStatusStringMap[lldRule.Status]
+	if !ok {
+		return diag.Errorf("Invalid status \"%s\" for low level discovery rule %s", lldRule.Status, d.Id())
+	}
+	d.Set("status", status)
+
+	if lldRule.Filter != nil && len(lldRule.Filter.Conditions) > 0 {
+		d.Set("filter", []interface{}{createTerraformLLDRuleFilter(*lldRule.Filter)})
+	} else {
+		d.Set("filter", []interface{}{})
+	}
+
+	if lldRule.LLDMacroPaths != nil {
+		macroPaths := make(map[string]interface{}, len(*lldRule.LLDMacroPaths))
+		for _, macroPath := range *lldRule.LLDMacroPaths {
+			macroPaths[macroPath.LLDMacro] = macroPath.Path
+		}
+		d.Set("lld_macro_paths", macroPaths)
+	}
 
+	if lldRule.Preprocessing != nil {
+		d.Set("preprocessing", createTerraformLLDRulePreprocessing(*lldRule.Preprocessing))
+	}
+
+	if lldRule.Overrides != nil {
+		d.Set("override", createTerraformLLDRuleOverrides(*lldRule.Overrides))
+	}
+	return nil
+}
+
+func createTerraformLLDRuleFilter(ruleFilter lldRuleFilter) map[string]interface{} {
 	var terraformConditions []interface{}
-	for _, condition := range lldRule.Filter.Conditions {
+	for _, condition := range ruleFilter.Conditions {
 		terraformCondition := map[string]interface{}{}
 
 		terraformCondition["macro"] = condition.LLDMacro
 		terraformCondition["value"] = condition.Value
-		terraformCondition["operator"] = condition.Operator
+		terraformCondition["operator"] = LLDRuleFilterOperatorStringMap[condition.Operator]
+		// The API returns the generated formula and formula IDs for every evaluation type, only custom ones are
+		// configured
+		if ruleFilter.EvalType == StringLLDRuleEvaluationTypeMap["custom"] {
+			terraformCondition["formulaid"] = condition.FormulaID
+		}
 		terraformConditions = append(terraformConditions, terraformCondition)
 	}
 
 	filter := map[string]interface{}{}
 	filter["condition"] = terraformConditions
-	filter["eval_type"] = lldRule.Filter.EvalType
-	filter["formula"] = lldRule.Filter.Formula
+	filter["eval_type"] = LLDRuleEvaluationTypeStringMap[ruleFilter.EvalType]
+	if ruleFilter.EvalType == StringLLDRuleEvaluationTypeMap["custom"] {
+		filter["formula"] = ruleFilter.Formula
+	}
+	return filter
+}
 
-	d.Set("filter", []interface{}{filter})
-	return nil
+func createTerraformLLDRulePreprocessing(steps []lldRulePreprocessing) []interface{} {
+	terraformSteps := make([]interface{}, len(steps))
+	for i, step := range steps {
+		stepType, _ := strconv.Atoi(step.Type)
+		errorHandler, _ := strconv.Atoi(step.ErrorHandler)
+
+		var params []string
+		if step.Params != "" {
+			params = strings.Split(step.Params, "\n")
+		}
+
+		terraformSteps[i] = map[string]interface{}{
+			"type":                 stepType,
+			"params":               params,
+			"error_handler":        errorHandler,
+			"error_handler_params": step.ErrorHandlerParams,
+		}
+	}
+	return terraformSteps
 }
 
-func resourceZabbixLLDRuleExists(d *schema.ResourceData, meta interface{}) (bool, error) {
-	api := meta.(*zabbix.API)
+func createTerraformLLDRuleOverrides(overrides []lldRuleOverride) []interface{} {
+	sort.SliceStable(overrides, func(i, j int) bool {
+		stepI, _ := strconv.Atoi(overrides[i].Step)
+		stepJ, _ := strconv.Atoi(overrides[j].Step)
+		return stepI < stepJ
+	})
 
-	_, err := api.DiscoveryRulesGetByID(d.Id())
-	if err != nil {
-		if strings.Contains(err.Error(), "Expected exactly one result") {
-			log.Printf("[DEBUG] LLD rule with id %s doesn't exist", d.Id())
-			return false, nil
+	terraformOverrides := make([]interface{}, len(overrides))
+	for i, override := range overrides {
+		terraformOverride := map[string]interface{}{
+			"name": override.Name,
+			"stop": override.Stop == "1",
+		}
+		if override.Filter != nil && len(override.Filter.Conditions) > 0 {
+			terraformOverride["filter"] = []interface{}{createTerraformLLDRuleFilter(*override.Filter)}
+		}
+
+		operations := make([]interface{}, len(override.Operations))
+		for j, operation := range override.Operations {
+			operations[j] = createTerraformLLDRuleOverrideOperation(operation)
+		}
+		terraformOverride["operation"] = operations
+
+		terraformOverrides[i] = terraformOverride
+	}
+	return terraformOverrides
+}
+
+func createTerraformLLDRuleOverrideOperation(operation map[string]interface{}) map[string]interface{} {
+	terraformOperation := map[string]interface{}{
+		"object":   LLDOverrideObjectStringMap[lldRuleOverrideValue(operation, "operationobject")],
+		"operator": LLDOverrideOperatorStringMap[lldRuleOverrideValue(operation, "operator")],
+		"value":    lldRuleOverrideValue(operation, "value"),
+	}
+
+	if status, ok := lldRuleOverrideField(operation, "opstatus", "status"); ok {
+		if status == "0" {
+			terraformOperation["status"] = "enabled"
+		} else {
+			terraformOperation["status"] = "disabled"
+		}
+	}
+	if discover, ok := lldRuleOverrideField(operation, "opdiscover", "discover"); ok {
+		if discover == "0" {
+			terraformOperation["discover"] = "yes"
+		} else {
+			terraformOperation["discover"] = "no"
+		}
+	}
+	if delay, ok := lldRuleOverrideField(operation, "opperiod", "delay"); ok {
+		terraformOperation["delay"] = delay
+	}
+	if history, ok := lldRuleOverrideField(operation, "ophistory", "history"); ok {
+		terraformOperation["history"] = history
+	}
+	if trends, ok := lldRuleOverrideField(operation, "optrends", "trends"); ok {
+		terraformOperation["trends"] = trends
+	}
+	if severity, ok := lldRuleOverrideField(operation, "opseverity", "severity"); ok {
+		terraformOperation["severity"] = LLDOverrideSeverityStringMap[severity]
+	}
+	if mode, ok := lldRuleOverrideField(operation, "opinventory", "inventory_mode"); ok {
+		terraformOperation["inventory_mode"] = LLDOverrideInventoryModeStringMap[mode]
+	}
+
+	var tags []interface{}
+	if opTags, ok := operation["optag"].([]interface{}); ok {
+		for _, t := range opTags {
+			if tag, ok := t.(map[string]interface{}); ok {
+				tags = append(tags, map[string]interface{}{
+					"tag":   lldRuleOverrideValue(tag, "tag"),
+					"value": lldRuleOverrideValue(tag, "value"),
+				})
+			}
 		}
-		return false, err
 	}
-	return true, nil
+	terraformOperation["tag"] = tags
+
+	var templateIDs []string
+	if opTemplates, ok := operation["optemplate"].([]interface{}); ok {
+		for _, t := range opTemplates {
+			if template, ok := t.(map[string]interface{}); ok {
+				templateIDs = append(templateIDs, lldRuleOverrideValue(template, "templateid"))
+			}
+		}
+	}
+	terraformOperation["template_ids"] = templateIDs
+
+	return terraformOperation
+}
+
+// lldRuleOverrideValue returns the string value of key, the API returns every scalar as a string
+func lldRuleOverrideValue(m map[string]interface{}, key string) string {
+	switch v := m[key].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+// lldRuleOverrideField returns the value of field inside the operation object named object, if set
+func lldRuleOverrideField(operation map[string]interface{}, object, field string) (string, bool) {
+	m, ok := operation[object].(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	if _, ok := m[field]; !ok {
+		return "", false
+	}
+	return lldRuleOverrideValue(m, field), true
+}
+
+// resourceZabbixLLDRuleImportState accepts the ID of the rule or <host>/<key>, host being the technical name
+// of the host or template
+func resourceZabbixLLDRuleImportState(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts := splitImportID(d.Id(), 2)
+	if len(parts) != 2 {
+		return []*schema.ResourceData{d}, nil
+	}
+
+	ruleID, hostID, err := getLLDRuleByKey(meta.(*zabbixClient), parts[0], parts[1])
+	if err != nil {
+		return nil, err
+	}
+	d.SetId(ruleID)
+	d.Set("host_id", hostID)
+	return []*schema.ResourceData{d}, nil
+}
+
+// getLLDRuleByKey returns the IDs of the low level discovery rule and of its host
+func getLLDRuleByKey(api *zabbixClient, host, key string) (string, string, error) {
+	var lldRules []struct {
+		ItemID string `json:"itemid"`
+		HostID string `json:"hostid"`
+	}
+	err := api.CallWithErrorParse("discoveryrule.get", zabbix.Params{
+		"output": []string{"itemid", "hostid"},
+		"host":   host,
+		"filter": map[string]interface{}{"key_": key},
+	}, &lldRules)
+	if err != nil {
+		return "", "", err
+	}
+
+	ids := make([]string, len(lldRules))
+	for i, lldRule := range lldRules {
+		ids[i] = lldRule.ItemID
+	}
+	id, err := singleImportID("low level discovery rule", fmt.Sprintf("key %q on host %q", key, host), ids)
+	if err != nil {
+		return "", "", err
+	}
+	return id, lldRules[0].HostID, nil
 }
 
-func resourceZabbixLLDRuleUpdate(d *schema.ResourceData, meta interface{}) error {
+func resourceZabbixLLDRuleUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	rule := createLLDRuleObject(d)
 
 	rule.ItemID = d.Id()
-	return createRetry(d, meta, updateLLDRule, rule, resourceZabbixLLDRuleRead)
+	return createRetry(ctx, d, meta, updateLLDRule, rule, resourceZabbixLLDRuleRead, d.Timeout(schema.TimeoutUpdate))
 }
 
-func resourceZabbixLLDRuleDelete(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixLLDRuleDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
 
 	err := api.DiscoveryRulesDeletesByIDs([]string{d.Id()})
-	return err
+	return diag.FromErr(err)
 }
 
-func createLLDRuleObject(d *schema.ResourceData) zabbix.LLDRule {
-	return zabbix.LLDRule{
-		Delay:       d.Get("delay").(string),
-		HostID:      d.Get("host_id").(string),
-		InterfaceID: d.Get("interface_id").(string),
-		Key:         d.Get("key").(string),
-		Name:        d.Get("name").(string),
-		Type:        zabbix.ItemType(d.Get("type").(int)),
-		Filter:      createLLDRuleConditionObject(d),
+func createLLDRuleObject(d *schema.ResourceData) discoveryRule {
+	rule := discoveryRule{
+		LLDRule: zabbix.LLDRule{
+			Delay:       d.Get("delay").(string),
+			HostID:      d.Get("host_id").(string),
+			InterfaceID: d.Get("interface_id").(string),
+			Key:         d.Get("key").(string),
+			Name:        d.Get("name").(string),
+			Type:        zabbix.ItemType(d.Get("type").(int)),
+		},
+		Description:  d.Get("description").(string),
+		Status:       StringLLDRuleStatusMap[d.Get("status").(string)],
+		Lifetime:     d.Get("lifetime").(string),
+		MasterItemID: d.Get("master_itemid").(string),
+		Params:       d.Get("params").(string),
+		Filter:       createLLDRuleConditionObject(d),
+	}
+
+	// Only send the properties which are in use so that older servers do not reject unknown parameters
+	if _, ok := d.GetOk("lld_macro_paths"); ok || d.HasChange("lld_macro_paths") {
+		macroPaths := createLLDRuleMacroPathsObject(d)
+		rule.LLDMacroPaths = &macroPaths
 	}
+	if _, ok := d.GetOk("preprocessing"); ok || d.HasChange("preprocessing") {
+		preprocessing := createLLDRulePreprocessingObject(d)
+		rule.Preprocessing = &preprocessing
+	}
+	if _, ok := d.GetOk("override"); ok || d.HasChange("override") {
+		overrides := createLLDRuleOverridesObject(d)
+		rule.Overrides = &overrides
+	}
+	return rule
 }
 
-func createLLDRuleConditionObject(d *schema.ResourceData) zabbix.LLDRuleFilter {
+func createLLDRuleConditionObject(d *schema.ResourceData) *lldRuleFilter {
 	filters := d.Get("filter").(*schema.Set)
-	filter := filters.List()[0].(map[string]interface{})
+	if filters.Len() == 0 {
+		// An empty filter is only sent to remove a previously configured one
+		if d.HasChange("filter") {
+			return &lldRuleFilter{
+				EvalType:   StringLLDRuleEvaluationTypeMap["and/or"],
+				Conditions: []lldRuleFilterCondition{},
+			}
+		}
+		return nil
+	}
+	filter := createLLDRuleFilterObject(filters.List()[0].(map[string]interface{}))
+	return &filter
+}
+
+func createLLDRuleFilterObject(filter map[string]interface{}) lldRuleFilter {
 	conditions := filter["condition"].(*schema.Set)
-	var filterObject zabbix.LLDRuleFilter
+	filterObject := lldRuleFilter{
+		EvalType:   StringLLDRuleEvaluationTypeMap[filter["eval_type"].(string)],
+		Formula:    filter["formula"].(string),
+		Conditions: []lldRuleFilterCondition{},
+	}
 
-	filterObject.EvalType = filter["eval_type"].(int)
-	filterObject.Formula = filter["formula"].(string)
 	for _, condition := range conditions.List() {
 		value := condition.(map[string]interface{})
-		cond := zabbix.LLDRulesFilterCondition{
-			LLDMacro: value["macro"].(string),
-			Value:    value["value"].(string),
-			Operator: value["operator"].(int),
+		cond := lldRuleFilterCondition{
+			LLDMacro:  value["macro"].(string),
+			Value:     value["value"].(string),
+			Operator:  StringLLDRuleFilterOperatorMap[value["operator"].(string)],
+			FormulaID: value["formulaid"].(string),
 		}
 		filterObject.Conditions = append(filterObject.Conditions, cond)
 	}
 	return filterObject
 }
 
-func createLLDRule(rule interface{}, api *zabbix.API) (id string, err error) {
-	rules := zabbix.LLDRules{rule.(zabbix.LLDRule)}
+func createLLDRuleMacroPathsObject(d *schema.ResourceData) []lldRuleMacroPath {
+	macroPaths := []lldRuleMacroPath{}
+
+	terraformMacroPaths := d.Get("lld_macro_paths").(map[string]interface{})
+	for macro, path := range terraformMacroPaths {
+		macroPaths = append(macroPaths, lldRuleMacroPath{
+			LLDMacro: macro,
+			Path:     path.(string),
+		})
+	}
+	sort.Slice(macroPaths, func(i, j int) bool {
+		return macroPaths[i].LLDMacro < macroPaths[j].LLDMacro
+	})
+	return macroPaths
+}
+
+func createLLDRulePreprocessingObject(d *schema.ResourceData) []lldRulePreprocessing {
+	steps := []lldRulePreprocessing{}
+
+	for _, s := range d.Get("preprocessing").([]interface{}) {
+		step := s.(map[string]interface{})
+
+		var params []string
+		for _, p := range step["params"].([]interface{}) {
+			params = append(params, p.(string))
+		}
+
+		steps = append(steps, lldRulePreprocessing{
+			Type:               strconv.Itoa(step["type"].(int)),
+			Params:             strings.Join(params, "\n"),
+			ErrorHandler:       strconv.Itoa(step["error_handler"].(int)),
+			ErrorHandlerParams: step["error_handler_params"].(string),
+		})
+	}
+	return steps
+}
+
+func createLLDRuleOverridesObject(d *schema.ResourceData) []lldRuleOverride {
+	overrides := []lldRuleOverride{}
+
+	for i, o := range d.Get("override").([]interface{}) {
+		terraformOverride := o.(map[string]interface{})
+
+		override := lldRuleOverride{
+			Name:       terraformOverride["name"].(string),
+			Step:       strconv.Itoa(i + 1),
+			Stop:       "0",
+			Operations: []map[string]interface{}{},
+		}
+		if terraformOverride["stop"].(bool) {
+			override.Stop = "1"
+		}
+		if filters := terraformOverride["filter"].([]interface{}); len(filters) > 0 && filters[0] != nil {
+			filter := createLLDRuleFilterObject(filters[0].(map[string]interface{}))
+			override.Filter = &filter
+		}
+		for _, operation := range terraformOverride["operation"].([]interface{}) {
+			override.Operations = append(override.Operations, createLLDRuleOverrideOperationObject(operation.(map[string]interface{})))
+		}
+		overrides = append(overrides, override)
+	}
+	return overrides
+}
+
+func createLLDRuleOverrideOperationObject(terraformOperation map[string]interface{}) map[string]interface{} {
+	operation := map[string]interface{}{
+		"operationobject": StringLLDOverrideObjectMap[terraformOperation["object"].(string)],
+		"operator":        StringLLDOverrideOperatorMap[terraformOperation["operator"].(string)],
+		"value":           terraformOperation["value"].(string),
+	}
+
+	switch terraformOperation["status"].(string) {
+	case "enabled":
+		operation["opstatus"] = map[string]interface{}{"status": "0"}
+	case "disabled":
+		operation["opstatus"] = map[string]interface{}{"status": "1"}
+	}
+	switch terraformOperation["discover"].(string) {
+	case "yes":
+		operation["opdiscover"] = map[string]interface{}{"discover": "0"}
+	case "no":
+		operation["opdiscover"] = map[string]interface{}{"discover": "1"}
+	}
+	if delay := terraformOperation["delay"].(string); delay != "" {
+		operation["opperiod"] = map[string]interface{}{"delay": delay}
+	}
+	if history := terraformOperation["history"].(string); history != "" {
+		operation["ophistory"] = map[string]interface{}{"history": history}
+	}
+	if trends := terraformOperation["trends"].(string); trends != "" {
+		operation["optrends"] = map[string]interface{}{"trends": trends}
+	}
+	if severity := terraformOperation["severity"].(string); severity != "" {
+		operation["opseverity"] = map[string]interface{}{"severity": StringLLDOverrideSeverityMap[severity]}
+	}
+	if mode := terraformOperation["inventory_mode"].(string); mode != "" {
+		operation["opinventory"] = map[string]interface{}{"inventory_mode": StringLLDOverrideInventoryModeMap[mode]}
+	}
+
+	if terraformTags := terraformOperation["tag"].([]interface{}); len(terraformTags) > 0 {
+		var tags []map[string]interface{}
+		for _, t := range terraformTags {
+			tag := t.(map[string]interface{})
+			tags = append(tags, map[string]interface{}{
+				"tag":   tag["tag"].(string),
+				"value": tag["value"].(string),
+			})
+		}
+		operation["optag"] = tags
+	}
+
+	if terraformTemplates := terraformOperation["template_ids"].(*schema.Set); terraformTemplates.Len() > 0 {
+		var templates []map[string]interface{}
+		for _, templateID := range terraformTemplates.List() {
+			templates = append(templates, map[string]interface{}{
+				"templateid": templateID.(string),
+			})
+		}
+		operation["optemplate"] = templates
+	}
 
-	err = api.DiscoveryRulesCreate(rules)
+	return operation
+}
+
+func createLLDRule(rule interface{}, api *zabbixClient) (id string, err error) {
+	response, err := api.CallWithError("discoveryrule.create", []discoveryRule{rule.(discoveryRule)})
 	if err != nil {
 		return
 	}
-	id = rules[0].ItemID
-	return
+	return idFromResponse(response, "itemids")
 }
 
-func updateLLDRule(rule interface{}, api *zabbix.API) (id string, err error) {
-	rules := zabbix.LLDRules{rule.(zabbix.LLDRule)}
-
-	err = api.DiscoveryRulesUpdate(rules)
+func updateLLDRule(rule interface{}, api *zabbixClient) (id string, err error) {
+	response, err := api.CallWithError("discoveryrule.update", []discoveryRule{rule.(discoveryRule)})
 	if err != nil {
 		return
 	}
-	id = rules[0].ItemID
-	return
+	return idFromResponse(response, "itemids")
 }
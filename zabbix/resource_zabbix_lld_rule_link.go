@@ -1,26 +1,32 @@
 package zabbix
 
 import (
+	"context"
 	"log"
+	"time"
 
 	"github.com/claranet/go-zabbix-api"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 func resourceZabbixLLDRuleLink() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceZabbixLLDRuleLinkCreate,
-		Read:   resourceZabbixLLDRuleLinkRead,
-		Exists: resourceZabbixLLDRuleLinkExists,
-		Update: resourceZabbixLLDRuleLinkUpdate,
-		Delete: resourceZabbixLLDRuleLinkDelete,
+		CreateContext: resourceZabbixLLDRuleLinkCreate,
+		ReadContext:   resourceZabbixLLDRuleLinkRead,
+		UpdateContext: resourceZabbixLLDRuleLinkUpdate,
+		DeleteContext: resourceZabbixLLDRuleLinkDelete,
 		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
+			StateContext: resourceZabbixLLDRuleLinkImportState,
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Delete: schema.DefaultTimeout(time.Minute),
 		},
 		Schema: map[string]*schema.Schema{
 			"lld_rule_id": &schema.Schema{
 				Type:     schema.TypeString,
 				Required: true,
+				ForceNew: true,
 			},
 			"item_prototype": &schema.Schema{
 				Type:     schema.TypeSet,
@@ -66,53 +72,83 @@ func schemaTemplateTriggerPrototype() *schema.Resource {
 	}
 }
 
-func resourceZabbixLLDRuleLinkCreate(d *schema.ResourceData, meta interface{}) error {
-	return resourceZabbixLLDRuleLinkRead(d, meta)
+func resourceZabbixLLDRuleLinkCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return resourceZabbixLLDRuleLinkRead(ctx, d, meta)
 }
 
-func resourceZabbixLLDRuleLinkRead(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixLLDRuleLinkRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
+
+	// The link is identified by its LLD rule, so an imported link only has its ID set
+	if d.Id() == "" {
+		d.SetId(d.Get("lld_rule_id").(string))
+	}
+	d.Set("lld_rule_id", d.Id())
 
 	itemsTerraform, err := getTerraformTemplateItemPrototypes(d, api)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 	d.Set("item_prototype", itemsTerraform)
 
 	triggersTerraform, err := getTerraformTemplateTriggerPrototypes(d, api)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 	d.Set("trigger_prototype", triggersTerraform)
-
-	d.SetId(d.Get("lld_rule_id").(string))
 	return nil
 }
 
-func resourceZabbixLLDRuleLinkExists(d *schema.ResourceData, meta interface{}) (bool, error) {
-	return true, nil
+// resourceZabbixLLDRuleLinkImportState accepts the ID of the rule or <host>/<key>
+func resourceZabbixLLDRuleLinkImportState(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	parts := splitImportID(d.Id(), 2)
+	if len(parts) == 2 {
+		ruleID, _, err := getLLDRuleByKey(meta.(*zabbixClient), parts[0], parts[1])
+		if err != nil {
+			return nil, err
+		}
+		d.SetId(ruleID)
+	}
+	d.Set("lld_rule_id", d.Id())
+	return []*schema.ResourceData{d}, nil
 }
 
-func resourceZabbixLLDRuleLinkUpdate(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixLLDRuleLinkUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
 
 	err := updateZabbixTemplateItemPrototypes(d, api)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 
 	err = updateZabbixTemplateTriggerPrototypes(d, api)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
-	return resourceZabbixLLDRuleLinkRead(d, meta)
+	return resourceZabbixLLDRuleLinkRead(ctx, d, meta)
 }
 
-func resourceZabbixLLDRuleLinkDelete(d *schema.ResourceData, meta interface{}) error {
+// resourceZabbixLLDRuleLinkDelete deletes the prototypes of the LLD rule, the trigger prototypes first as deleting an
+// item prototype deletes the trigger prototypes using it
+func resourceZabbixLLDRuleLinkDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
+
+	for _, trigger := range d.Get("trigger_prototype").(*schema.Set).List() {
+		id := trigger.(map[string]interface{})["trigger_id"].(string)
+		if diags := deleteRetry(ctx, id, getTriggerPrototypeParentID, api.TriggerPrototypesDeleteIDs, api, d.Timeout(schema.TimeoutDelete)); diags.HasError() {
+			return diags
+		}
+	}
+	for _, item := range d.Get("item_prototype").(*schema.Set).List() {
+		id := item.(map[string]interface{})["item_id"].(string)
+		if diags := deleteRetry(ctx, id, getItemPrototypeParentID, api.ItemPrototypesDeleteIDs, api, d.Timeout(schema.TimeoutDelete)); diags.HasError() {
+			return diags
+		}
+	}
 	return nil
 }
 
-func getTerraformTemplateItemPrototypes(d *schema.ResourceData, api *zabbix.API) ([]interface{}, error) {
+func getTerraformTemplateItemPrototypes(d *schema.ResourceData, api *zabbixClient) ([]interface{}, error) {
 	params := zabbix.Params{
 		"output": "extend",
 		"discoveryids": []string{
@@ -136,7 +172,7 @@ func getTerraformTemplateItemPrototypes(d *schema.ResourceData, api *zabbix.API)
 	return itemsTerraform, nil
 }
 
-func getTerraformTemplateTriggerPrototypes(d *schema.ResourceData, api *zabbix.API) ([]interface{}, error) {
+func getTerraformTemplateTriggerPrototypes(d *schema.ResourceData, api *zabbixClient) ([]interface{}, error) {
 	params := zabbix.Params{
 		"output": "extend",
 		"discoveryids": []string{
@@ -160,13 +196,14 @@ func getTerraformTemplateTriggerPrototypes(d *schema.ResourceData, api *zabbix.A
 	return triggersTerraform, nil
 }
 
-func updateZabbixTemplateItemPrototypes(d *schema.ResourceData, api *zabbix.API) error {
+func updateZabbixTemplateItemPrototypes(d *schema.ResourceData, api *zabbixClient) error {
 	if d.HasChange("item_prototype") {
 		oldV, newV := d.GetChange("item_prototype")
 		oldItems := oldV.(*schema.Set).List()
 		newItems := newV.(*schema.Set).List()
 		var deletedItems []string
 		templatedItems, err := api.ItemPrototypesGet(zabbix.Params{
+			"output": "extend",
 			"discoveryids": []string{
 				d.Get("lld_rule_id").(string),
 			},
@@ -180,11 +217,6 @@ func updateZabbixTemplateItemPrototypes(d *schema.ResourceData, api *zabbix.API)
 		for _, oldItem := range oldItems {
 			oldItemValue := oldItem.(map[string]interface{})
 			exist := false
-
-			if oldItemValue["local"] == true {
-				continue
-			}
-
 			for _, newItem := range newItems {
 				newItemValue := newItem.(map[string]interface{})
 				if newItemValue["item_id"].(string) == oldItemValue["item_id"].(string) {
@@ -217,7 +249,7 @@ func updateZabbixTemplateItemPrototypes(d *schema.ResourceData, api *zabbix.API)
 	return nil
 }
 
-func updateZabbixTemplateTriggerPrototypes(d *schema.ResourceData, api *zabbix.API) error {
+func updateZabbixTemplateTriggerPrototypes(d *schema.ResourceData, api *zabbixClient) error {
 	if d.HasChange("trigger_prototype") {
 		oldV, newV := d.GetChange("trigger_prototype")
 		oldTriggers := oldV.(*schema.Set).List()
@@ -238,11 +270,6 @@ func updateZabbixTemplateTriggerPrototypes(d *schema.ResourceData, api *zabbix.A
 		for _, oldTrigger := range oldTriggers {
 			oldTriggerValue := oldTrigger.(map[string]interface{})
 			exist := false
-
-			if oldTriggerValue["local"] == true {
-				continue
-			}
-
 			for _, newTrigger := range newTriggers {
 				newTriggerValue := newTrigger.(map[string]interface{})
 				if oldTriggerValue["trigger_id"].(string) == newTriggerValue["trigger_id"].(string) {
@@ -0,0 +1,297 @@
+package zabbix
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/claranet/go-zabbix-api"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccZabbixLLDRuleLink_Basic(t *testing.T) {
+	strID := acctest.RandString(5)
+	groupName := fmt.Sprintf("template_group_%s", strID)
+	templateName := fmt.Sprintf("template_%s", strID)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckZabbixLLDRuleLinkDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccZabbixLLDRuleLinkConfig(groupName, templateName),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrPair("zabbix_lld_rule_link.lld_rule_link_test", "id", "zabbix_lld_rule.lld_rule_test", "id"),
+					resource.TestCheckResourceAttr("zabbix_lld_rule_link.lld_rule_link_test", "item_prototype.#", "1"),
+					resource.TestCheckResourceAttr("zabbix_lld_rule_link.lld_rule_link_test", "trigger_prototype.#", "1"),
+				),
+			},
+			{
+				Config: testAccZabbixLLDRuleLinkDeleteTriggerPrototype(groupName, templateName),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("zabbix_lld_rule_link.lld_rule_link_test", "item_prototype.#", "1"),
+					resource.TestCheckResourceAttr("zabbix_lld_rule_link.lld_rule_link_test", "trigger_prototype.#", "0"),
+				),
+			},
+			{
+				Config: testAccZabbixLLDRuleLinkDeleteItemPrototype(groupName, templateName),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("zabbix_lld_rule_link.lld_rule_link_test", "item_prototype.#", "0"),
+					resource.TestCheckResourceAttr("zabbix_lld_rule_link.lld_rule_link_test", "trigger_prototype.#", "0"),
+				),
+			},
+			{
+				Config: testAccZabbixLLDRuleLinkConfig(groupName, templateName),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("zabbix_lld_rule_link.lld_rule_link_test", "item_prototype.#", "1"),
+					resource.TestCheckResourceAttr("zabbix_lld_rule_link.lld_rule_link_test", "trigger_prototype.#", "1"),
+				),
+			},
+			{
+				ResourceName:      "zabbix_lld_rule_link.lld_rule_link_test",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestAccZabbixLLDRuleLink_DeleteServerItemPrototype(t *testing.T) {
+	strID := acctest.RandString(5)
+	groupName := fmt.Sprintf("template_group_%s", strID)
+	templateName := fmt.Sprintf("template_%s", strID)
+
+	var lldRule zabbix.LLDRule
+	item := zabbix.ItemPrototype{
+		Name:  "server_item_prototype",
+		Key:   "server.key[{#TESTMACRO}]",
+		Type:  zabbix.ZabbixAgent,
+		Delay: "30",
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckZabbixLLDRuleLinkDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccZabbixLLDRuleLinkConfig(groupName, templateName),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testAccCheckLLDRuleExists("zabbix_lld_rule.lld_rule_test", &lldRule),
+					resource.TestCheckResourceAttr("zabbix_lld_rule_link.lld_rule_link_test", "item_prototype.#", "1"),
+					resource.TestCheckResourceAttr("zabbix_lld_rule_link.lld_rule_link_test", "trigger_prototype.#", "1"),
+				),
+			},
+			{
+				PreConfig: testAccZabbixLLDRuleLinkCreateServerItemPrototype(t, &lldRule, &item),
+				Config:    testAccZabbixLLDRuleLinkConfig(groupName, templateName),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testAccCheckLLDRuleServerItemPrototypeDelete(&item),
+					resource.TestCheckResourceAttr("zabbix_lld_rule_link.lld_rule_link_test", "item_prototype.#", "1"),
+					resource.TestCheckResourceAttr("zabbix_lld_rule_link.lld_rule_link_test", "trigger_prototype.#", "1"),
+				),
+			},
+		},
+	})
+}
+
+func testAccZabbixLLDRuleLinkBaseConfig(groupName, templateName string) string {
+	return fmt.Sprintf(`
+		resource "zabbix_template_group" "zabbix" {
+			name = "template group test %s"
+		}
+
+		resource "zabbix_template" "template_test" {
+			host = "%s"
+			groups = ["${zabbix_template_group.zabbix.name}"]
+			name = "display name for template test %s"
+	  	}
+
+		resource "zabbix_lld_rule" "lld_rule_test" {
+			delay = 60
+			host_id = zabbix_template.template_test.id
+			interface_id = "0"
+			key = "key.lolo"
+			name = "test_low_level_discovery_rule"
+			type = 0
+			filter {
+				condition {
+					macro = "{#TESTMACRO}"
+					value = "^lo$"
+				}
+				eval_type = "and/or"
+			}
+		}
+	`, groupName, templateName, templateName)
+}
+
+func testAccZabbixLLDRuleLinkConfig(groupName, templateName string) string {
+	return testAccZabbixLLDRuleLinkBaseConfig(groupName, templateName) + `
+		resource "zabbix_item_prototype" "item_prototype_test" {
+			delay = 60
+			host_id  = zabbix_template.template_test.id
+			rule_id = zabbix_lld_rule.lld_rule_test.id
+			interface_id = "0"
+			key = "test.key[{#TESTMACRO}]"
+			name = "item_prototype_test"
+			type = 0
+			status = 0
+		}
+
+		resource "zabbix_trigger_prototype" "trigger_prototype_test" {
+			description = "trigger_prototype_test"
+			expression = "last(/${zabbix_template.template_test.host}/${zabbix_item_prototype.item_prototype_test.key})=0"
+			priority = 5
+			status = 0
+		}
+
+		resource "zabbix_lld_rule_link" "lld_rule_link_test" {
+			lld_rule_id = zabbix_lld_rule.lld_rule_test.id
+			item_prototype {
+				item_id = zabbix_item_prototype.item_prototype_test.id
+			}
+			trigger_prototype {
+				trigger_id = zabbix_trigger_prototype.trigger_prototype_test.id
+			}
+		}
+	`
+}
+
+func testAccZabbixLLDRuleLinkDeleteTriggerPrototype(groupName, templateName string) string {
+	return testAccZabbixLLDRuleLinkBaseConfig(groupName, templateName) + `
+		resource "zabbix_item_prototype" "item_prototype_test" {
+			delay = 60
+			host_id  = zabbix_template.template_test.id
+			rule_id = zabbix_lld_rule.lld_rule_test.id
+			interface_id = "0"
+			key = "test.key[{#TESTMACRO}]"
+			name = "item_prototype_test"
+			type = 0
+			status = 0
+		}
+
+		resource "zabbix_lld_rule_link" "lld_rule_link_test" {
+			lld_rule_id = zabbix_lld_rule.lld_rule_test.id
+			item_prototype {
+				item_id = zabbix_item_prototype.item_prototype_test.id
+			}
+		}
+	`
+}
+
+func testAccZabbixLLDRuleLinkDeleteItemPrototype(groupName, templateName string) string {
+	return testAccZabbixLLDRuleLinkBaseConfig(groupName, templateName) + `
+		resource "zabbix_lld_rule_link" "lld_rule_link_test" {
+			lld_rule_id = zabbix_lld_rule.lld_rule_test.id
+		}
+	`
+}
+
+func testAccZabbixLLDRuleLinkCreateServerItemPrototype(t *testing.T, lldRule *zabbix.LLDRule, item *zabbix.ItemPrototype) func() {
+	return func() {
+		api := testAccProvider.Meta().(*zabbixClient)
+
+		item.HostID = lldRule.HostID
+		item.RuleID = lldRule.ItemID
+		items := zabbix.ItemPrototypes{*item}
+		err := api.ItemPrototypesCreate(items)
+		if err != nil {
+			t.Fatal(err)
+		}
+		item.ItemID = items[0].ItemID
+	}
+}
+
+// testAccCheckZabbixLLDRuleLinkDestroy checks that the prototypes tracked by the links are deleted with them
+func testAccCheckZabbixLLDRuleLinkDestroy(s *terraform.State) error {
+	api := testAccProvider.Meta().(*zabbixClient)
+
+	for _, rs := range s.RootModule().Resources {
+		switch rs.Type {
+		case "zabbix_item_prototype":
+			if err := testAccCheckLLDRuleLinkPrototypeDestroy(api, "item", rs.Primary.ID); err != nil {
+				return err
+			}
+		case "zabbix_trigger_prototype":
+			if err := testAccCheckLLDRuleLinkPrototypeDestroy(api, "trigger", rs.Primary.ID); err != nil {
+				return err
+			}
+		case "zabbix_lld_rule_link":
+			for key, id := range rs.Primary.Attributes {
+				var prototypeType string
+				switch {
+				case strings.HasPrefix(key, "item_prototype.") && strings.HasSuffix(key, ".item_id"):
+					prototypeType = "item"
+				case strings.HasPrefix(key, "trigger_prototype.") && strings.HasSuffix(key, ".trigger_id"):
+					prototypeType = "trigger"
+				default:
+					continue
+				}
+				if err := testAccCheckLLDRuleLinkPrototypeDestroy(api, prototypeType, id); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func testAccCheckLLDRuleLinkPrototypeDestroy(api *zabbixClient, prototypeType, id string) error {
+	var count int
+	switch prototypeType {
+	case "item":
+		items, err := api.ItemPrototypesGet(zabbix.Params{"itemids": []string{id}})
+		if err != nil {
+			return err
+		}
+		count = len(items)
+	case "trigger":
+		triggers, err := api.TriggerPrototypesGet(zabbix.Params{"triggerids": []string{id}})
+		if err != nil {
+			return err
+		}
+		count = len(triggers)
+	}
+
+	if count != 0 {
+		return fmt.Errorf("%s prototype %s still exists", prototypeType, id)
+	}
+	return nil
+}
+
+func testAccCheckLLDRuleExists(n string, lldRule *zabbix.LLDRule) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+
+		api := testAccProvider.Meta().(*zabbixClient)
+		rule, err := api.DiscoveryRulesGetByID(rs.Primary.ID)
+		if err != nil {
+			return err
+		}
+		*lldRule = *rule
+		return nil
+	}
+}
+
+func testAccCheckLLDRuleServerItemPrototypeDelete(item *zabbix.ItemPrototype) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		api := testAccProvider.Meta().(*zabbixClient)
+
+		_, err := api.ItemPrototypeGetByID(item.ItemID)
+		if err == nil {
+			return fmt.Errorf("Expected an error")
+		}
+
+		expectedErr := "Expected exactly one result, got 0."
+		if err.Error() != expectedErr {
+			return fmt.Errorf("expected error : %s, got : %s", expectedErr, err.Error())
+		}
+		return nil
+	}
+}
@@ -1,7 +1,10 @@
 package zabbix
 
 import (
+	"context"
 	"fmt"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/claranet/go-zabbix-api"
@@ -32,9 +35,15 @@ func TestAccZabbixLLDRule_Basic(t *testing.T) {
 					resource.TestCheckResourceAttr("zabbix_lld_rule.lld_rule_test", "filter.0.condition.#", "1"),
 					resource.TestCheckResourceAttr("zabbix_lld_rule.lld_rule_test", "filter.0.condition.0.macro", "{#TESTMACRO}"),
 					resource.TestCheckResourceAttr("zabbix_lld_rule.lld_rule_test", "filter.0.condition.0.value", "^lo$"),
-					resource.TestCheckResourceAttr("zabbix_lld_rule.lld_rule_test", "filter.0.condition.0.operator", "8"),
+					resource.TestCheckResourceAttr("zabbix_lld_rule.lld_rule_test", "filter.0.condition.0.operator", "matches"),
 				),
 			},
+			{
+				ResourceName:      "zabbix_lld_rule.lld_rule_test",
+				ImportState:       true,
+				ImportStateId:     fmt.Sprintf("%s/key.lolo", templateName),
+				ImportStateVerify: true,
+			},
 			{
 				Config: testAccZabbixLLDRuleUpdateConfig(groupName, templateName),
 				Check: resource.ComposeAggregateTestCheckFunc(
@@ -49,12 +58,157 @@ func TestAccZabbixLLDRule_Basic(t *testing.T) {
 					resource.TestCheckResourceAttr("zabbix_lld_rule.lld_rule_test", "filter.0.condition.0.value", "^lo$"),
 				),
 			},
+			{
+				Config: testAccZabbixLLDRuleNoFilterConfig(groupName, templateName),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("zabbix_lld_rule.lld_rule_test", "key", "key.update"),
+					resource.TestCheckResourceAttr("zabbix_lld_rule.lld_rule_test", "filter.#", "0"),
+				),
+			},
 		},
 	})
 }
 
+func TestAccZabbixLLDRule_Advanced(t *testing.T) {
+	strID := acctest.RandString(5)
+	groupName := fmt.Sprintf("template_group_%s", strID)
+	templateName := fmt.Sprintf("template_%s", strID)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckZabbixLLDRuleDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccZabbixLLDRuleAdvancedConfig(groupName, templateName),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("zabbix_lld_rule.lld_rule_test", "description", "Discover kubernetes pods"),
+					resource.TestCheckResourceAttr("zabbix_lld_rule.lld_rule_test", "status", "disabled"),
+					resource.TestCheckResourceAttr("zabbix_lld_rule.lld_rule_test", "lifetime", "7d"),
+					resource.TestCheckResourceAttr("zabbix_lld_rule.lld_rule_test", "lld_macro_paths.%", "2"),
+					resource.TestCheckResourceAttr("zabbix_lld_rule.lld_rule_test", "lld_macro_paths.{#NAME}", "$.metadata.name"),
+					resource.TestCheckResourceAttr("zabbix_lld_rule.lld_rule_test", "lld_macro_paths.{#NAMESPACE}", "$.metadata.namespace"),
+					resource.TestCheckResourceAttr("zabbix_lld_rule.lld_rule_test", "preprocessing.#", "1"),
+					resource.TestCheckResourceAttr("zabbix_lld_rule.lld_rule_test", "preprocessing.0.type", "12"),
+					resource.TestCheckResourceAttr("zabbix_lld_rule.lld_rule_test", "preprocessing.0.params.0", "$.items"),
+					resource.TestCheckResourceAttr("zabbix_lld_rule.lld_rule_test", "override.#", "1"),
+					resource.TestCheckResourceAttr("zabbix_lld_rule.lld_rule_test", "override.0.name", "system namespaces"),
+					resource.TestCheckResourceAttr("zabbix_lld_rule.lld_rule_test", "override.0.stop", "true"),
+					resource.TestCheckResourceAttr("zabbix_lld_rule.lld_rule_test", "override.0.operation.#", "1"),
+					resource.TestCheckResourceAttr("zabbix_lld_rule.lld_rule_test", "override.0.operation.0.object", "item_prototype"),
+					resource.TestCheckResourceAttr("zabbix_lld_rule.lld_rule_test", "override.0.operation.0.operator", "matches"),
+					resource.TestCheckResourceAttr("zabbix_lld_rule.lld_rule_test", "override.0.operation.0.discover", "no"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccZabbixLLDRule_CustomFilter(t *testing.T) {
+	strID := acctest.RandString(5)
+	groupName := fmt.Sprintf("template_group_%s", strID)
+	templateName := fmt.Sprintf("template_%s", strID)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckZabbixLLDRuleDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccZabbixLLDRuleCustomFilterConfig(groupName, templateName, ""),
+				ExpectError: regexp.MustCompile("a formula is required by custom filters"),
+			},
+			{
+				Config:      strings.Replace(testAccZabbixLLDRuleCustomFilterConfig(groupName, templateName, ""), `"custom"`, `"and"`, 1),
+				ExpectError: regexp.MustCompile("can only have a formulaid in custom filters"),
+			},
+			{
+				Config: testAccZabbixLLDRuleCustomFilterConfig(groupName, templateName, "(A or B) and C"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("zabbix_lld_rule.lld_rule_test", "filter.0.eval_type", "custom"),
+					resource.TestCheckResourceAttr("zabbix_lld_rule.lld_rule_test", "filter.0.formula", "(A or B) and C"),
+					resource.TestCheckResourceAttr("zabbix_lld_rule.lld_rule_test", "filter.0.condition.#", "3"),
+					resource.TestCheckTypeSetElemNestedAttrs("zabbix_lld_rule.lld_rule_test", "filter.0.condition.*", map[string]string{
+						"formulaid": "C",
+						"macro":     "{#FSNAME}",
+						"operator":  "not_matches",
+					}),
+				),
+			},
+			{
+				ResourceName:      "zabbix_lld_rule.lld_rule_test",
+				ImportState:       true,
+				ImportStateId:     fmt.Sprintf("%s/vfs.fs.discovery", templateName),
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestResourceZabbixLLDRuleStateUpgradeV0(t *testing.T) {
+	rawState := map[string]interface{}{
+		"filter": []interface{}{
+			map[string]interface{}{
+				"eval_type": float64(3),
+				"formula":   "A",
+				"condition": []interface{}{
+					map[string]interface{}{"macro": "{#FSTYPE}", "value": "@fs", "operator": float64(9)},
+				},
+			},
+		},
+		"override": []interface{}{
+			map[string]interface{}{
+				"filter": []interface{}{
+					map[string]interface{}{
+						"eval_type": float64(0),
+						"condition": []interface{}{
+							map[string]interface{}{"macro": "{#NAME}", "value": ".+", "operator": float64(8)},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	actual, err := resourceZabbixLLDRuleStateUpgradeV0(context.Background(), rawState, nil)
+	if err != nil {
+		t.Fatalf("error upgrading state: %s", err)
+	}
+
+	filter := actual["filter"].([]interface{})[0].(map[string]interface{})
+	if filter["eval_type"] != "custom" {
+		t.Errorf("expected eval_type custom, got %v", filter["eval_type"])
+	}
+	condition := filter["condition"].([]interface{})[0].(map[string]interface{})
+	if condition["operator"] != "not_matches" {
+		t.Errorf("expected operator not_matches, got %v", condition["operator"])
+	}
+
+	override := actual["override"].([]interface{})[0].(map[string]interface{})
+	overrideFilter := override["filter"].([]interface{})[0].(map[string]interface{})
+	if overrideFilter["eval_type"] != "and/or" {
+		t.Errorf("expected override eval_type and/or, got %v", overrideFilter["eval_type"])
+	}
+	overrideCondition := overrideFilter["condition"].([]interface{})[0].(map[string]interface{})
+	if overrideCondition["operator"] != "matches" {
+		t.Errorf("expected override operator matches, got %v", overrideCondition["operator"])
+	}
+}
+
+func TestResourceZabbixLLDRuleStateUpgradeV1(t *testing.T) {
+	for status, expected := range map[interface{}]string{float64(0): "enabled", float64(1): "disabled", nil: "enabled"} {
+		actual, err := resourceZabbixLLDRuleStateUpgradeV1(context.Background(), map[string]interface{}{"status": status}, nil)
+		if err != nil {
+			t.Fatalf("error upgrading state: %s", err)
+		}
+		if actual["status"] != expected {
+			t.Errorf("expected status %v to be upgraded to %s, got %v", status, expected, actual["status"])
+		}
+	}
+}
+
 func testAccCheckZabbixLLDRuleDestroy(s *terraform.State) error {
-	api := testAccProvider.Meta().(*zabbix.API)
+	api := testAccProvider.Meta().(*zabbixClient)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "zabbix_lld_rule" {
@@ -99,7 +253,7 @@ func testAccZabbixLLDRuleConfig(groupName, templateName string) string {
 					macro = "{#TESTMACRO}"
 					value = "^lo$"
 				}
-				eval_type = 0
+				eval_type = "and/or"
 			}
 		}
 	`, groupName, templateName, templateName)
@@ -129,8 +283,132 @@ func testAccZabbixLLDRuleUpdateConfig(groupName, templateName string) string {
 					macro = "{#UPDATE}"
 					value = "^lo$"
 				}
-				eval_type = 0
+				eval_type = "and/or"
+			}
+		}
+	`, groupName, templateName, templateName)
+}
+
+func testAccZabbixLLDRuleNoFilterConfig(groupName, templateName string) string {
+	return fmt.Sprintf(`
+		resource "zabbix_template_group" "zabbix" {
+			name = "template group test %s"
+		}
+
+		resource "zabbix_template" "template_test" {
+			host = "%s"
+			groups = ["${zabbix_template_group.zabbix.name}"]
+			name = "display name for template test %s"
+	  	}
+
+		resource "zabbix_lld_rule" "lld_rule_test" {
+			delay = 90
+			host_id = zabbix_template.template_test.id
+			interface_id = "0"
+			key = "key.update"
+			name = "test_low_level_discovery_rule_update"
+			type = 0
+		}
+	`, groupName, templateName, templateName)
+}
+
+func testAccZabbixLLDRuleAdvancedConfig(groupName, templateName string) string {
+	return fmt.Sprintf(`
+		resource "zabbix_template_group" "zabbix" {
+			name = "template group test %s"
+		}
+
+		resource "zabbix_template" "template_test" {
+			host = "%s"
+			groups = ["${zabbix_template_group.zabbix.name}"]
+			name = "display name for template test %s"
+	  	}
+
+		resource "zabbix_lld_rule" "lld_rule_test" {
+			delay = 60
+			host_id = zabbix_template.template_test.id
+			interface_id = "0"
+			key = "kube.pods.discovery"
+			name = "test_low_level_discovery_rule"
+			type = 2
+			description = "Discover kubernetes pods"
+			status = "disabled"
+			lifetime = "7d"
+			filter {
+				condition {
+					macro = "{#NAME}"
+					value = ".+"
+				}
+				eval_type = "and/or"
+			}
+			lld_macro_paths = {
+				"{#NAME}"      = "$.metadata.name"
+				"{#NAMESPACE}" = "$.metadata.namespace"
+			}
+			preprocessing {
+				type   = 12
+				params = ["$.items"]
+			}
+			override {
+				name = "system namespaces"
+				stop = true
+				filter {
+					condition {
+						macro = "{#NAMESPACE}"
+						value = "^kube-"
+					}
+					eval_type = "and/or"
+				}
+				operation {
+					object   = "item_prototype"
+					operator = "matches"
+					value    = ".*"
+					discover = "no"
+				}
 			}
 		}
 	`, groupName, templateName, templateName)
 }
+
+func testAccZabbixLLDRuleCustomFilterConfig(groupName, templateName, formula string) string {
+	return fmt.Sprintf(`
+		resource "zabbix_template_group" "zabbix" {
+			name = "template group test %s"
+		}
+
+		resource "zabbix_template" "template_test" {
+			host = "%s"
+			groups = ["${zabbix_template_group.zabbix.name}"]
+			name = "display name for template test %s"
+	  	}
+
+		resource "zabbix_lld_rule" "lld_rule_test" {
+			delay = 60
+			host_id = zabbix_template.template_test.id
+			interface_id = "0"
+			key = "vfs.fs.discovery"
+			name = "test_low_level_discovery_rule"
+			type = 0
+			filter {
+				condition {
+					formulaid = "A"
+					macro = "{#FSTYPE}"
+					value = "^ext4$"
+				}
+				condition {
+					formulaid = "B"
+					macro = "{#FSTYPE}"
+					value = "^xfs$"
+				}
+				condition {
+					formulaid = "C"
+					macro = "{#FSNAME}"
+					value = "^/boot"
+					operator = "not_matches"
+				}
+				eval_type = "custom"
+				formula = "%s"
+			}
+		}
+	`, groupName, templateName, templateName, formula)
+}
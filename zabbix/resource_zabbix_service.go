@@ -0,0 +1,504 @@
+package zabbix
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/claranet/go-zabbix-api"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+type zabbixService struct {
+	ServiceID        string              `json:"serviceid,omitempty"`
+	Name             string              `json:"name"`
+	Algorithm        string              `json:"algorithm"`
+	SortOrder        string              `json:"sortorder"`
+	Weight           string              `json:"weight"`
+	PropagationRule  string              `json:"propagation_rule"`
+	PropagationValue string              `json:"propagation_value"`
+	Description      string              `json:"description"`
+	Tags             []serviceTag        `json:"tags"`
+	ProblemTags      []serviceTag        `json:"problem_tags"`
+	StatusRules      []serviceStatusRule `json:"status_rules"`
+	Parents          *[]serviceReference `json:"parents,omitempty"`
+	Children         *[]serviceReference `json:"children,omitempty"`
+}
+
+// serviceTag is a tag of a service, or a tag matching the tags of problems or services, with an operator
+type serviceTag struct {
+	Tag      string `json:"tag"`
+	Operator string `json:"operator,omitempty"`
+	Value    string `json:"value"`
+}
+
+type serviceStatusRule struct {
+	Type        string `json:"type"`
+	LimitValue  string `json:"limit_value"`
+	LimitStatus string `json:"limit_status"`
+	NewStatus   string `json:"new_status"`
+}
+
+type serviceReference struct {
+	ServiceID string `json:"serviceid"`
+}
+
+var StringServiceAlgorithmMap = map[string]string{
+	"ok":                   "0",
+	"most_critical_if_all": "1",
+	"most_critical":        "2",
+}
+
+var ServiceAlgorithmStringMap = map[string]string{
+	"0": "ok",
+	"1": "most_critical_if_all",
+	"2": "most_critical",
+}
+
+var StringServicePropagationRuleMap = map[string]string{
+	"as_is":    "0",
+	"increase": "1",
+	"decrease": "2",
+	"ignore":   "3",
+	"fixed":    "4",
+}
+
+var ServicePropagationRuleStringMap = map[string]string{
+	"0": "as_is",
+	"1": "increase",
+	"2": "decrease",
+	"3": "ignore",
+	"4": "fixed",
+}
+
+var StringServiceStatusRuleTypeMap = map[string]string{
+	"count_at_least":              "0",
+	"percentage_at_least":         "1",
+	"count_less_than":             "2",
+	"percentage_less_than":        "3",
+	"weight_at_least":             "4",
+	"weight_percentage_at_least":  "5",
+	"weight_less_than":            "6",
+	"weight_percentage_less_than": "7",
+}
+
+var ServiceStatusRuleTypeStringMap = map[string]string{
+	"0": "count_at_least",
+	"1": "percentage_at_least",
+	"2": "count_less_than",
+	"3": "percentage_less_than",
+	"4": "weight_at_least",
+	"5": "weight_percentage_at_least",
+	"6": "weight_less_than",
+	"7": "weight_percentage_less_than",
+}
+
+var StringServiceStatusMap = map[string]string{
+	"ok":             "-1",
+	"not_classified": "0",
+	"information":    "1",
+	"warning":        "2",
+	"average":        "3",
+	"high":           "4",
+	"disaster":       "5",
+}
+
+var ServiceStatusStringMap = map[string]string{
+	"-1": "ok",
+	"0":  "not_classified",
+	"1":  "information",
+	"2":  "warning",
+	"3":  "average",
+	"4":  "high",
+	"5":  "disaster",
+}
+
+var StringServiceTagOperatorMap = map[string]string{
+	"equals": "0",
+	"like":   "2",
+}
+
+var ServiceTagOperatorStringMap = map[string]string{
+	"0": "equals",
+	"2": "like",
+}
+
+func resourceZabbixService() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceZabbixServiceCreate,
+		ReadContext:   resourceZabbixServiceRead,
+		UpdateContext: resourceZabbixServiceUpdate,
+		DeleteContext: resourceZabbixServiceDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		CustomizeDiff: customizeDiffVersion(
+			resourceRequiresVersion("6.0", "zabbix_service"),
+			servicePropagationValue,
+		),
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of the service.",
+			},
+			"algorithm": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "most_critical",
+				ValidateFunc: validation.StringInSlice(
+					[]string{"ok", "most_critical_if_all", "most_critical"},
+					false,
+				),
+				Description: "Status calculation rule: ok, most_critical_if_all (most critical if all children have problems) or most_critical (most critical of child services).",
+			},
+			"sort_order": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntBetween(0, 999),
+				Description:  "Position of the service used for sorting.",
+			},
+			"weight": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntBetween(0, 1000000),
+				Description:  "Weight of the service, used by the status rules of its parents.",
+			},
+			"propagation_rule": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "as_is",
+				ValidateFunc: validation.StringInSlice(
+					[]string{"as_is", "increase", "decrease", "ignore", "fixed"},
+					false,
+				),
+				Description: "How the status of the service propagates to its parents: as_is, increase or decrease by propagation_value, ignore, or fixed to the status propagation_value.",
+			},
+			"propagation_value": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntBetween(-1, 5),
+				Description:  "Number of severities to increase or decrease the status by, from 1 to 5, or the fixed status, from -1 (OK) to 5.",
+			},
+			"description": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "",
+				Description: "Description of the service.",
+			},
+			"parents": &schema.Schema{
+				Type:        schema.TypeSet,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Optional:    true,
+				Computed:    true,
+				Description: "IDs of the parent services. Link two services either by the parents of the child or by the children of the parent, not both. Removing the attribute leaves the links in place, set it to an empty set to remove them.",
+			},
+			"children": &schema.Schema{
+				Type:        schema.TypeSet,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Optional:    true,
+				Computed:    true,
+				Description: "IDs of the child services. Link two services either by the parents of the child or by the children of the parent, not both. Removing the attribute leaves the links in place, set it to an empty set to remove them.",
+			},
+			"tag": &schema.Schema{
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "Tags of the service, matched by the service tags of SLAs.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"tag": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"value": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+							Default:  "",
+						},
+					},
+				},
+			},
+			"problem_tag": &schema.Schema{
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "Tags of the problems that the status of the service is calculated from.",
+				Elem:        serviceTagSchema,
+			},
+			"status_rule": &schema.Schema{
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "Rules setting the status of the service from its child services, overriding the algorithm.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"type": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+							ValidateFunc: validation.StringInSlice(
+								[]string{
+									"count_at_least", "percentage_at_least", "count_less_than", "percentage_less_than",
+									"weight_at_least", "weight_percentage_at_least", "weight_less_than", "weight_percentage_less_than",
+								},
+								false,
+							),
+							Description: "Condition on the child services: count_at_least, percentage_at_least, weight_at_least and weight_percentage_at_least apply to the children with limit_status or above, count_less_than, percentage_less_than, weight_less_than and weight_percentage_less_than to the children with limit_status or below.",
+						},
+						"limit_value": &schema.Schema{
+							Type:         schema.TypeInt,
+							Required:     true,
+							ValidateFunc: validation.IntBetween(1, 1000000),
+							Description:  "Number, percentage or weight of the child services.",
+						},
+						"limit_status": &schema.Schema{
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringInSlice(serviceStatuses, false),
+							Description:  "Status of the child services, ok or a severity.",
+						},
+						"new_status": &schema.Schema{
+							Type:         schema.TypeString,
+							Required:     true,
+							ValidateFunc: validation.StringInSlice(serviceStatuses[1:], false),
+							Description:  "Status of the service when the condition is met, a severity.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+var serviceStatuses = []string{"ok", "not_classified", "information", "warning", "average", "high", "disaster"}
+
+// serviceTagSchema matches the tags of problems or services
+var serviceTagSchema = &schema.Resource{
+	Schema: map[string]*schema.Schema{
+		"tag": &schema.Schema{
+			Type:     schema.TypeString,
+			Required: true,
+		},
+		"operator": &schema.Schema{
+			Type:     schema.TypeString,
+			Optional: true,
+			Default:  "equals",
+			ValidateFunc: validation.StringInSlice(
+				[]string{"equals", "like"},
+				false,
+			),
+		},
+		"value": &schema.Schema{
+			Type:     schema.TypeString,
+			Optional: true,
+			Default:  "",
+		},
+	},
+}
+
+// servicePropagationValue checks that propagation_value is in the range of its propagation_rule, which the schema
+// can't validate on its own
+func servicePropagationValue(d *schema.ResourceDiff, serverVersion *version.Version) error {
+	if !d.NewValueKnown("propagation_rule") || !d.NewValueKnown("propagation_value") {
+		return nil
+	}
+
+	rule := d.Get("propagation_rule").(string)
+	value := d.Get("propagation_value").(int)
+	switch rule {
+	case "increase", "decrease":
+		if value < 1 {
+			return fmt.Errorf("propagation_value must be between 1 and 5 to %s the status, got %d", rule, value)
+		}
+	case "as_is", "ignore":
+		if value != 0 {
+			return fmt.Errorf("propagation_value must be 0 when propagation_rule is %s, got %d", rule, value)
+		}
+	}
+	return nil
+}
+
+func createServiceObject(d *schema.ResourceData) *zabbixService {
+	service := &zabbixService{
+		Name:             d.Get("name").(string),
+		Algorithm:        StringServiceAlgorithmMap[d.Get("algorithm").(string)],
+		SortOrder:        strconv.Itoa(d.Get("sort_order").(int)),
+		Weight:           strconv.Itoa(d.Get("weight").(int)),
+		PropagationRule:  StringServicePropagationRuleMap[d.Get("propagation_rule").(string)],
+		PropagationValue: strconv.Itoa(d.Get("propagation_value").(int)),
+		Description:      d.Get("description").(string),
+		Tags:             createServiceTags(d.Get("tag").(*schema.Set).List()),
+		ProblemTags:      createServiceTags(d.Get("problem_tag").(*schema.Set).List()),
+		StatusRules:      []serviceStatusRule{},
+	}
+
+	for _, v := range d.Get("status_rule").(*schema.Set).List() {
+		m := v.(map[string]interface{})
+		service.StatusRules = append(service.StatusRules, serviceStatusRule{
+			Type:        StringServiceStatusRuleTypeMap[m["type"].(string)],
+			LimitValue:  strconv.Itoa(m["limit_value"].(int)),
+			LimitStatus: StringServiceStatusMap[m["limit_status"].(string)],
+			NewStatus:   StringServiceStatusMap[m["new_status"].(string)],
+		})
+	}
+
+	// The parents and children of a service are replaced when sent, they are left alone when they are only read
+	// because the other side of the link is configured
+	if d.IsNewResource() || d.HasChange("parents") {
+		service.Parents = createServiceReferences(d.Get("parents").(*schema.Set).List())
+	}
+	if d.IsNewResource() || d.HasChange("children") {
+		service.Children = createServiceReferences(d.Get("children").(*schema.Set).List())
+	}
+	return service
+}
+
+// createServiceTags returns the tags of services, problems or SLAs, with their operator when the schema has one
+func createServiceTags(lst []interface{}) []serviceTag {
+	tags := []serviceTag{}
+	for _, v := range lst {
+		m := v.(map[string]interface{})
+		tag := serviceTag{
+			Tag:   m["tag"].(string),
+			Value: m["value"].(string),
+		}
+		if operator, ok := m["operator"]; ok {
+			tag.Operator = StringServiceTagOperatorMap[operator.(string)]
+		}
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+func createServiceReferences(ids []interface{}) *[]serviceReference {
+	references := []serviceReference{}
+	for _, id := range ids {
+		references = append(references, serviceReference{ServiceID: id.(string)})
+	}
+	return &references
+}
+
+func resourceZabbixServiceCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
+
+	response, err := api.CallWithError("service.create", createServiceObject(d))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	id, err := idFromResponse(response, "serviceids")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	log.Printf("[DEBUG] Created service, id is %s", id)
+
+	d.SetId(id)
+	return resourceZabbixServiceRead(ctx, d, meta)
+}
+
+func resourceZabbixServiceRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
+
+	var services []zabbixService
+	err := api.CallWithErrorParse("service.get", zabbix.Params{
+		"output":            "extend",
+		"serviceids":        d.Id(),
+		"selectParents":     []string{"serviceid"},
+		"selectChildren":    []string{"serviceid"},
+		"selectTags":        "extend",
+		"selectProblemTags": "extend",
+		"selectStatusRules": "extend",
+	}, &services)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err := expectOne("service", d.Id(), len(services)); err != nil {
+		return readError(d, err)
+	}
+	service := services[0]
+	sortOrder, _ := strconv.Atoi(service.SortOrder)
+	weight, _ := strconv.Atoi(service.Weight)
+	propagationValue, _ := strconv.Atoi(service.PropagationValue)
+
+	d.Set("name", service.Name)
+	d.Set("algorithm", ServiceAlgorithmStringMap[service.Algorithm])
+	d.Set("sort_order", sortOrder)
+	d.Set("weight", weight)
+	d.Set("propagation_rule", ServicePropagationRuleStringMap[service.PropagationRule])
+	d.Set("propagation_value", propagationValue)
+	d.Set("description", service.Description)
+	d.Set("parents", readServiceReferences(service.Parents))
+	d.Set("children", readServiceReferences(service.Children))
+
+	var tags []interface{}
+	for _, tag := range service.Tags {
+		tags = append(tags, map[string]interface{}{
+			"tag":   tag.Tag,
+			"value": tag.Value,
+		})
+	}
+	d.Set("tag", tags)
+	d.Set("problem_tag", readServiceTags(service.ProblemTags))
+
+	var rules []interface{}
+	for _, rule := range service.StatusRules {
+		limitValue, _ := strconv.Atoi(rule.LimitValue)
+		rules = append(rules, map[string]interface{}{
+			"type":         ServiceStatusRuleTypeStringMap[rule.Type],
+			"limit_value":  limitValue,
+			"limit_status": ServiceStatusStringMap[rule.LimitStatus],
+			"new_status":   ServiceStatusStringMap[rule.NewStatus],
+		})
+	}
+	d.Set("status_rule", rules)
+
+	log.Printf("[DEBUG] Service name is %s\n", service.Name)
+	return nil
+}
+
+// readServiceTags returns the tags matching problems or services, with their operator
+func readServiceTags(tags []serviceTag) []interface{} {
+	var lst []interface{}
+	for _, tag := range tags {
+		lst = append(lst, map[string]interface{}{
+			"tag":      tag.Tag,
+			"operator": ServiceTagOperatorStringMap[tag.Operator],
+			"value":    tag.Value,
+		})
+	}
+	return lst
+}
+
+func readServiceReferences(references *[]serviceReference) []string {
+	ids := []string{}
+	if references != nil {
+		for _, reference := range *references {
+			ids = append(ids, reference.ServiceID)
+		}
+	}
+	return ids
+}
+
+func resourceZabbixServiceUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
+
+	service := createServiceObject(d)
+	service.ServiceID = d.Id()
+
+	_, err := api.CallWithError("service.update", service)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	return resourceZabbixServiceRead(ctx, d, meta)
+}
+
+func resourceZabbixServiceDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
+
+	_, err := api.CallWithError("service.delete", []string{d.Id()})
+	return diag.FromErr(err)
+}
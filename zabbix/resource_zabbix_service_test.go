@@ -0,0 +1,136 @@
+package zabbix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccZabbixService_Basic(t *testing.T) {
+	serviceName := fmt.Sprintf("service_%s", acctest.RandString(5))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckZabbixServiceDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccZabbixServiceConfig(serviceName, "zabbix_service.parent.id"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("zabbix_service.parent", "name", serviceName),
+					resource.TestCheckResourceAttr("zabbix_service.parent", "algorithm", "most_critical"),
+					resource.TestCheckResourceAttr("zabbix_service.parent", "status_rule.#", "1"),
+					resource.TestCheckResourceAttr("zabbix_service.parent", "tag.#", "1"),
+					resource.TestCheckResourceAttr("zabbix_service.child", "parents.#", "1"),
+					resource.TestCheckTypeSetElemAttrPair("zabbix_service.child", "parents.*", "zabbix_service.parent", "id"),
+					resource.TestCheckResourceAttr("zabbix_service.child", "problem_tag.#", "1"),
+				),
+			},
+			{
+				Config: testAccZabbixServiceConfig(serviceName, ""),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("zabbix_service.child", "parents.#", "0"),
+				),
+			},
+			{
+				ResourceName:      "zabbix_service.parent",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestServiceChildren(t *testing.T) {
+	s, api := newFakeZabbixClient(t, "6.0.0")
+
+	child := testApplyResource(t, "child", resourceZabbixService(), api, nil, map[string]interface{}{"name": "Child"})
+	other := testApplyResource(t, "other", resourceZabbixService(), api, nil, map[string]interface{}{"name": "Other"})
+	parent := testApplyResource(t, "parent", resourceZabbixService(), api, nil, map[string]interface{}{
+		"name":     "Parent",
+		"children": []interface{}{child.ID},
+	})
+
+	// The services linked from the other side read their links without planning changes
+	child, diags := resourceZabbixService().RefreshWithoutUpgrade(context.Background(), child, api)
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	if got := child.Attributes["parents.#"]; got != "1" {
+		t.Errorf("expected the child to read its parent, got %v", child.Attributes)
+	}
+	testApplyResource(t, "other", resourceZabbixService(), api, other, map[string]interface{}{"name": "Other", "weight": 1})
+
+	// The children replace the links of the parent
+	testApplyResource(t, "parent", resourceZabbixService(), api, parent, map[string]interface{}{
+		"name":     "Parent",
+		"children": []interface{}{other.ID},
+	})
+	_, service := s.find(child.ID, "service")
+	if parents := fakeIDs(service["parents"], "serviceid"); len(parents) != 0 {
+		t.Errorf("expected the child to be unlinked from its parent, got the parents %v", parents)
+	}
+	_, service = s.find(other.ID, "service")
+	if parents := fakeIDs(service["parents"], "serviceid"); len(parents) != 1 || parents[0] != parent.ID {
+		t.Errorf("expected the other service to be linked to the parent, got the parents %v", parents)
+	}
+}
+
+func testAccCheckZabbixServiceDestroy(s *terraform.State) error {
+	api := testAccProvider.Meta().(*zabbixClient)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "zabbix_service" {
+			continue
+		}
+
+		var services []zabbixService
+		err := api.CallWithErrorParse("service.get", map[string]interface{}{"serviceids": rs.Primary.ID}, &services)
+		if err == nil {
+			err = expectOne("service", rs.Primary.ID, len(services))
+		}
+		if err == nil {
+			return fmt.Errorf("Service still exist %s", rs.Primary.ID)
+		}
+		var notFound *notFoundError
+		if !errors.As(err, &notFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+func testAccZabbixServiceConfig(serviceName, parent string) string {
+	return fmt.Sprintf(`
+		resource "zabbix_service" "parent" {
+			name = "%s"
+
+			status_rule {
+				type         = "count_at_least"
+				limit_value  = 1
+				limit_status = "warning"
+				new_status   = "average"
+			}
+
+			tag {
+				tag   = "sla"
+				value = "%s"
+			}
+		}
+
+		resource "zabbix_service" "child" {
+			name    = "%s_child"
+			parents = [%s]
+
+			problem_tag {
+				tag      = "service"
+				operator = "like"
+				value    = "mysql"
+			}
+		}`, serviceName, serviceName, serviceName, parent)
+}
@@ -0,0 +1,302 @@
+package zabbix
+
+import (
+	"context"
+	"log"
+	"strconv"
+
+	"github.com/claranet/go-zabbix-api"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+type zabbixSLA struct {
+	SLAID             string                `json:"slaid,omitempty"`
+	Name              string                `json:"name"`
+	Period            string                `json:"period"`
+	SLO               string                `json:"slo"`
+	EffectiveDate     string                `json:"effective_date,omitempty"`
+	Timezone          string                `json:"timezone"`
+	Status            string                `json:"status"`
+	Description       string                `json:"description"`
+	Schedule          []slaSchedule         `json:"schedule"`
+	ExcludedDowntimes []slaExcludedDowntime `json:"excluded_downtimes"`
+	ServiceTags       []serviceTag          `json:"service_tags"`
+}
+
+type slaSchedule struct {
+	PeriodFrom string `json:"period_from"`
+	PeriodTo   string `json:"period_to"`
+}
+
+type slaExcludedDowntime struct {
+	Name       string `json:"name"`
+	PeriodFrom string `json:"period_from"`
+	PeriodTo   string `json:"period_to"`
+}
+
+var StringSLAPeriodMap = map[string]string{
+	"daily":     "0",
+	"weekly":    "1",
+	"monthly":   "2",
+	"quarterly": "3",
+	"annually":  "4",
+}
+
+var SLAPeriodStringMap = map[string]string{
+	"0": "daily",
+	"1": "weekly",
+	"2": "monthly",
+	"3": "quarterly",
+	"4": "annually",
+}
+
+func resourceZabbixSLA() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceZabbixSLACreate,
+		ReadContext:   resourceZabbixSLARead,
+		UpdateContext: resourceZabbixSLAUpdate,
+		DeleteContext: resourceZabbixSLADelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		CustomizeDiff: customizeDiffVersion(
+			resourceRequiresVersion("6.0", "zabbix_sla"),
+		),
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of the SLA.",
+			},
+			"slo": &schema.Schema{
+				Type:         schema.TypeFloat,
+				Required:     true,
+				ValidateFunc: validation.FloatBetween(0, 100),
+				Description:  "Service level objective, the minimum acceptable SLI in percent.",
+			},
+			"period": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "monthly",
+				ValidateFunc: validation.StringInSlice(
+					[]string{"daily", "weekly", "monthly", "quarterly", "annually"},
+					false,
+				),
+				Description: "Reporting period of the SLA.",
+			},
+			"timezone": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "UTC",
+				Description: "Time zone of the reporting periods, such as Europe/Riga.",
+			},
+			"effective_date": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Unix timestamp of the day the SLA starts being calculated from.",
+			},
+			"enabled": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+			"description": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "",
+				Description: "Description of the SLA.",
+			},
+			"schedule": &schema.Schema{
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "Weekly periods when the services must be up, all the time when none is given.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"period_from": &schema.Schema{
+							Type:         schema.TypeInt,
+							Required:     true,
+							ValidateFunc: validation.IntBetween(0, 604800),
+							Description:  "Start of the period, in seconds since Sunday 00:00.",
+						},
+						"period_to": &schema.Schema{
+							Type:         schema.TypeInt,
+							Required:     true,
+							ValidateFunc: validation.IntBetween(0, 604800),
+							Description:  "End of the period, in seconds since Sunday 00:00.",
+						},
+					},
+				},
+			},
+			"excluded_downtime": &schema.Schema{
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "Planned downtimes not counted against the SLA.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": &schema.Schema{
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"period_from": &schema.Schema{
+							Type:         schema.TypeInt,
+							Required:     true,
+							ValidateFunc: validation.IntAtLeast(0),
+							Description:  "Unix timestamp of the start of the downtime.",
+						},
+						"period_to": &schema.Schema{
+							Type:         schema.TypeInt,
+							Required:     true,
+							ValidateFunc: validation.IntAtLeast(0),
+							Description:  "Unix timestamp of the end of the downtime.",
+						},
+					},
+				},
+			},
+			"service_tag": &schema.Schema{
+				Type:        schema.TypeSet,
+				Required:    true,
+				MinItems:    1,
+				Description: "Tags of the services that the SLA applies to.",
+				Elem:        serviceTagSchema,
+			},
+		},
+	}
+}
+
+func createSLAObject(d *schema.ResourceData) *zabbixSLA {
+	status := "0"
+	if d.Get("enabled").(bool) {
+		status = "1"
+	}
+
+	sla := &zabbixSLA{
+		Name:              d.Get("name").(string),
+		Period:            StringSLAPeriodMap[d.Get("period").(string)],
+		SLO:               strconv.FormatFloat(d.Get("slo").(float64), 'f', -1, 64),
+		Timezone:          d.Get("timezone").(string),
+		Status:            status,
+		Description:       d.Get("description").(string),
+		Schedule:          []slaSchedule{},
+		ExcludedDowntimes: []slaExcludedDowntime{},
+		ServiceTags:       createServiceTags(d.Get("service_tag").(*schema.Set).List()),
+	}
+	if effectiveDate, ok := d.GetOk("effective_date"); ok {
+		sla.EffectiveDate = strconv.Itoa(effectiveDate.(int))
+	}
+
+	for _, v := range d.Get("schedule").(*schema.Set).List() {
+		m := v.(map[string]interface{})
+		sla.Schedule = append(sla.Schedule, slaSchedule{
+			PeriodFrom: strconv.Itoa(m["period_from"].(int)),
+			PeriodTo:   strconv.Itoa(m["period_to"].(int)),
+		})
+	}
+	for _, v := range d.Get("excluded_downtime").(*schema.Set).List() {
+		m := v.(map[string]interface{})
+		sla.ExcludedDowntimes = append(sla.ExcludedDowntimes, slaExcludedDowntime{
+			Name:       m["name"].(string),
+			PeriodFrom: strconv.Itoa(m["period_from"].(int)),
+			PeriodTo:   strconv.Itoa(m["period_to"].(int)),
+		})
+	}
+	return sla
+}
+
+func resourceZabbixSLACreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
+
+	response, err := api.CallWithError("sla.create", createSLAObject(d))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	id, err := idFromResponse(response, "slaids")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	log.Printf("[DEBUG] Created SLA, id is %s", id)
+
+	d.SetId(id)
+	return resourceZabbixSLARead(ctx, d, meta)
+}
+
+func resourceZabbixSLARead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
+
+	var slas []zabbixSLA
+	err := api.CallWithErrorParse("sla.get", zabbix.Params{
+		"output":                  "extend",
+		"slaids":                  d.Id(),
+		"selectSchedule":          "extend",
+		"selectExcludedDowntimes": "extend",
+		"selectServiceTags":       "extend",
+	}, &slas)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err := expectOne("SLA", d.Id(), len(slas)); err != nil {
+		return readError(d, err)
+	}
+	sla := slas[0]
+	slo, _ := strconv.ParseFloat(sla.SLO, 64)
+	effectiveDate, _ := strconv.Atoi(sla.EffectiveDate)
+
+	d.Set("name", sla.Name)
+	d.Set("slo", slo)
+	d.Set("period", SLAPeriodStringMap[sla.Period])
+	d.Set("timezone", sla.Timezone)
+	d.Set("effective_date", effectiveDate)
+	d.Set("enabled", sla.Status == "1")
+	d.Set("description", sla.Description)
+	d.Set("service_tag", readServiceTags(sla.ServiceTags))
+
+	var schedule []interface{}
+	for _, period := range sla.Schedule {
+		from, _ := strconv.Atoi(period.PeriodFrom)
+		to, _ := strconv.Atoi(period.PeriodTo)
+		schedule = append(schedule, map[string]interface{}{
+			"period_from": from,
+			"period_to":   to,
+		})
+	}
+	d.Set("schedule", schedule)
+
+	var downtimes []interface{}
+	for _, downtime := range sla.ExcludedDowntimes {
+		from, _ := strconv.Atoi(downtime.PeriodFrom)
+		to, _ := strconv.Atoi(downtime.PeriodTo)
+		downtimes = append(downtimes, map[string]interface{}{
+			"name":        downtime.Name,
+			"period_from": from,
+			"period_to":   to,
+		})
+	}
+	d.Set("excluded_downtime", downtimes)
+
+	log.Printf("[DEBUG] SLA name is %s\n", sla.Name)
+	return nil
+}
+
+func resourceZabbixSLAUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
+
+	sla := createSLAObject(d)
+	sla.SLAID = d.Id()
+
+	_, err := api.CallWithError("sla.update", sla)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	return resourceZabbixSLARead(ctx, d, meta)
+}
+
+func resourceZabbixSLADelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
+
+	_, err := api.CallWithError("sla.delete", []string{d.Id()})
+	return diag.FromErr(err)
+}
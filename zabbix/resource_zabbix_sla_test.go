@@ -0,0 +1,96 @@
+package zabbix
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccZabbixSLA_Basic(t *testing.T) {
+	slaName := fmt.Sprintf("sla_%s", acctest.RandString(5))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckZabbixSLADestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccZabbixSLAConfig(slaName, "99.9"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("zabbix_sla.sla", "name", slaName),
+					resource.TestCheckResourceAttr("zabbix_sla.sla", "slo", "99.9"),
+					resource.TestCheckResourceAttr("zabbix_sla.sla", "period", "weekly"),
+					resource.TestCheckResourceAttr("zabbix_sla.sla", "timezone", "Europe/Riga"),
+					resource.TestCheckResourceAttr("zabbix_sla.sla", "schedule.#", "1"),
+					resource.TestCheckResourceAttr("zabbix_sla.sla", "excluded_downtime.#", "1"),
+					resource.TestCheckResourceAttr("zabbix_sla.sla", "service_tag.#", "1"),
+				),
+			},
+			{
+				Config: testAccZabbixSLAConfig(slaName, "95"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("zabbix_sla.sla", "slo", "95"),
+				),
+			},
+			{
+				ResourceName:      "zabbix_sla.sla",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testAccCheckZabbixSLADestroy(s *terraform.State) error {
+	api := testAccProvider.Meta().(*zabbixClient)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "zabbix_sla" {
+			continue
+		}
+
+		var slas []zabbixSLA
+		err := api.CallWithErrorParse("sla.get", map[string]interface{}{"slaids": rs.Primary.ID}, &slas)
+		if err == nil {
+			err = expectOne("SLA", rs.Primary.ID, len(slas))
+		}
+		if err == nil {
+			return fmt.Errorf("SLA still exist %s", rs.Primary.ID)
+		}
+		var notFound *notFoundError
+		if !errors.As(err, &notFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+func testAccZabbixSLAConfig(slaName, slo string) string {
+	return fmt.Sprintf(`
+		resource "zabbix_sla" "sla" {
+			name     = "%s"
+			slo      = %s
+			period   = "weekly"
+			timezone = "Europe/Riga"
+
+			schedule {
+				period_from = 115200
+				period_to   = 158400
+			}
+
+			excluded_downtime {
+				name        = "Migration"
+				period_from = 1767225600
+				period_to   = 1767312000
+			}
+
+			service_tag {
+				tag   = "sla"
+				value = "%s"
+			}
+		}`, slaName, slo, slaName)
+}
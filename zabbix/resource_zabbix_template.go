@@ -1,74 +1,91 @@
 package zabbix
 
 import (
+	"context"
 	"fmt"
 	"log"
-	"strings"
+	"time"
 
 	"github.com/claranet/go-zabbix-api"
 	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
+// zabbixTemplate carries the macro properties that zabbix.Template does not know about
+type zabbixTemplate struct {
+	zabbix.Template
+	UserMacros *[]hostMacro `json:"macros,omitempty"`
+}
+
 func resourceZabbixTemplate() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceZabbixTemplateCreate,
-		Read:   resourceZabbixTemplateRead,
-		Exists: resourceZabbixTemplateExists,
-		Update: resourceZabbixTemplateUpdate,
-		Delete: resourceZabbixTemplateDelete,
+		CreateContext: resourceZabbixTemplateCreate,
+		ReadContext:   resourceZabbixTemplateRead,
+		UpdateContext: resourceZabbixTemplateUpdate,
+		DeleteContext: resourceZabbixTemplateDelete,
 		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
+			StateContext: importStateByName("template", getTemplateIDs, "name", "host"),
 		},
-		Schema: map[string]*schema.Schema{
-			"host": &schema.Schema{
-				Type:        schema.TypeString,
-				Required:    true,
-				Description: "Technical name of the template.",
-			},
-			"groups": &schema.Schema{
-				Type:        schema.TypeSet,
-				Elem:        &schema.Schema{Type: schema.TypeString},
-				Required:    true,
-				Description: "ID of the Host Group.",
-			},
-			"name": &schema.Schema{
-				Type:        schema.TypeString,
-				Optional:    true,
-				Description: "Visible name of the template.",
-			},
-			"description": &schema.Schema{
-				Type:        schema.TypeString,
-				Optional:    true,
-				Description: "Description of the template.",
-			},
-			"macro": &schema.Schema{
-				Type:        schema.TypeMap,
-				Elem:        &schema.Schema{Type: schema.TypeString},
-				Optional:    true,
-				Description: "User macros for the template.",
-			},
-			"linked_template": &schema.Schema{
-				Type:     schema.TypeSet,
-				Elem:     &schema.Schema{Type: schema.TypeString},
-				Optional: true,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(time.Minute),
+			Update: schema.DefaultTimeout(time.Minute),
+		},
+		CustomizeDiff: customizeDiffVersion(
+			requiresVersion("4.4", "macro.*.description", ""),
+			requiresVersion("5.0", "macro.*.type", "text"),
+		),
+		SchemaVersion: 1,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Type:    resourceZabbixTemplateV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceZabbixMacroStateUpgradeV0,
+				Version: 0,
 			},
 		},
+		Schema: schemaTemplate(),
 	}
 }
 
-func createZabbixMacro(d *schema.ResourceData) zabbix.Macros {
-	var macros zabbix.Macros
+// resourceZabbixTemplateV0 is the schema used while macros were a map of names to values
+func resourceZabbixTemplateV0() *schema.Resource {
+	templateSchema := schemaTemplate()
+	templateSchema["macro"] = schemaMacrosV0()
+	return &schema.Resource{
+		Schema: templateSchema,
+	}
+}
 
-	terraformMacros := d.Get("macro").(map[string]interface{})
-	for i, terraformMacro := range terraformMacros {
-		macro := zabbix.Macro{
-			MacroName: fmt.Sprintf("{$%s}", i),
-			Value:     terraformMacro.(string),
-		}
-		macros = append(macros, macro)
+func schemaTemplate() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"host": &schema.Schema{
+			Type:        schema.TypeString,
+			Required:    true,
+			Description: "Technical name of the template.",
+		},
+		"groups": &schema.Schema{
+			Type:        schema.TypeSet,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Required:    true,
+			Description: "ID of the Host Group.",
+		},
+		"name": &schema.Schema{
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Visible name of the template.",
+		},
+		"description": &schema.Schema{
+			Type:        schema.TypeString,
+			Optional:    true,
+			Description: "Description of the template.",
+		},
+		"macro": schemaMacros("User macros for the template. Macros are left untouched when omitted, so that they can be managed with zabbix_user_macro, the two being exclusive."),
+		"linked_template": &schema.Schema{
+			Type:     schema.TypeSet,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+			Optional: true,
+		},
 	}
-	return macros
 }
 
 func createLinkedTemplate(d *schema.ResourceData) zabbix.TemplateIDs {
@@ -84,17 +101,23 @@ func createLinkedTemplate(d *schema.ResourceData) zabbix.TemplateIDs {
 	return templates
 }
 
-func createTemplateObj(d *schema.ResourceData, api *zabbix.API) (*zabbix.Template, error) {
-	template := zabbix.Template{
-		Host:            d.Get("host").(string),
-		Name:            d.Get("name").(string),
-		Description:     d.Get("description").(string),
-		UserMacros:      createZabbixMacro(d),
-		LinkedTemplates: createLinkedTemplate(d),
+func createTemplateObj(d *schema.ResourceData, api *zabbixClient) (*zabbixTemplate, error) {
+	macros, err := createZabbixMacros(d, api)
+	if err != nil {
+		return nil, err
+	}
+
+	template := zabbixTemplate{
+		Template: zabbix.Template{
+			Host:            d.Get("host").(string),
+			Name:            d.Get("name").(string),
+			Description:     d.Get("description").(string),
+			LinkedTemplates: createLinkedTemplate(d),
+		},
+		UserMacros: macros,
 	}
 
 	var groupIds zabbix.HostGroupIDs
-	var err error
 	if api.ServerVersion.GreaterThanOrEqual(version.Must(version.NewVersion("6.2"))) {
 		groupIds, err = getTemplateGroups(d, api)
 	} else {
@@ -105,38 +128,33 @@ func createTemplateObj(d *schema.ResourceData, api *zabbix.API) (*zabbix.Templat
 		return nil, err
 	}
 	template.Groups = groupIds
-	if template.UserMacros == nil {
-		template.UserMacros = zabbix.Macros{}
-	}
 	return &template, nil
 }
 
-func resourceZabbixTemplateCreate(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixTemplateCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
 
 	template, err := createTemplateObj(d, api)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 
-	return createRetry(d, meta, createTemplate, *template, resourceZabbixTemplateRead)
+	return createRetry(ctx, d, meta, createTemplate, *template, resourceZabbixTemplateRead, d.Timeout(schema.TimeoutCreate))
 }
 
-func resourceZabbixTemplateRead(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixTemplateRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
 
 	params := zabbix.Params{
-		"templateids":  d.Id(),
-		"output":       "extend",
-		"selectMacros": "extend",
+		"templateids": d.Id(),
+		"output":      "extend",
 	}
 	templates, err := api.TemplatesGet(params)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
-	if len(templates) != 1 {
-		log.Printf("[DEBUG] Expected one template with id %s and got %#v", d.Id(), templates)
-		return fmt.Errorf("Expected one template with id %s and got %d templates", d.Id(), len(templates))
+	if err := expectOne("template", d.Id(), len(templates)); err != nil {
+		return readError(d, err)
 	}
 
 	template := templates[0]
@@ -146,74 +164,47 @@ func resourceZabbixTemplateRead(d *schema.ResourceData, meta interface{}) error
 	}
 	d.Set("description", template.Description)
 
-	terraformMacros, err := createTerraformMacro(template)
+	macros, err := getUserMacros(api, zabbix.Params{
+		"output":  "extend",
+		"hostids": d.Id(),
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	terraformMacros, err := createTerraformMacros(d, macros)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 	d.Set("macro", terraformMacros)
 
 	terraformGroups, err := createTerraformTemplateGroup(d, api)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 	d.Set("groups", terraformGroups)
 	return nil
 }
 
-func resourceZabbixTemplateExists(d *schema.ResourceData, meta interface{}) (bool, error) {
-	api := meta.(*zabbix.API)
-
-	_, err := api.TemplateGetByID(d.Id())
-	if err != nil {
-		if strings.Contains(err.Error(), "Expected exactly one result") {
-			log.Printf("[DEBUG] Template with id %s doesn't exist", d.Id())
-			return false, nil
-		}
-		return false, err
-	}
-	return true, nil
-}
-
-func resourceZabbixTemplateUpdate(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixTemplateUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
 
 	template, err := createTemplateObj(d, api)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 	template.TemplatesClear = getUnlinkedTemplate(d)
 	template.TemplateID = d.Id()
 
-	return createRetry(d, meta, updateTemplate, *template, resourceZabbixTemplateRead)
-}
-
-func resourceZabbixTemplateDelete(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
-
-	return api.TemplatesDeleteByIds([]string{d.Id()})
+	return createRetry(ctx, d, meta, updateTemplate, *template, resourceZabbixTemplateRead, d.Timeout(schema.TimeoutUpdate))
 }
 
-func createTerraformMacro(template zabbix.Template) (map[string]interface{}, error) {
-	terraformMacros := make(map[string]interface{}, len(template.UserMacros))
+func resourceZabbixTemplateDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
 
-	for _, macro := range template.UserMacros {
-		var name string
-		if noPrefix := strings.Split(macro.MacroName, "{$"); len(noPrefix) == 2 {
-			name = noPrefix[1]
-		} else {
-			return nil, fmt.Errorf("Invalid macro name \"%s\"", macro.MacroName)
-		}
-		if noSuffix := strings.Split(name, "}"); len(noSuffix) == 2 {
-			name = noSuffix[0]
-		} else {
-			return nil, fmt.Errorf("Invalid macro name \"%s\"", macro.MacroName)
-		}
-		terraformMacros[name] = macro.Value
-	}
-	return terraformMacros, nil
+	return diag.FromErr(api.TemplatesDeleteByIds([]string{d.Id()}))
 }
 
-func createTerraformTemplateGroup(d *schema.ResourceData, api *zabbix.API) ([]string, error) {
+func createTerraformTemplateGroup(d *schema.ResourceData, api *zabbixClient) ([]string, error) {
 	if api.ServerVersion.GreaterThanOrEqual(version.Must(version.NewVersion("6.2"))) {
 		params := zabbix.Params{
 			"output": "extend",
@@ -283,78 +274,63 @@ func getUnlinkedTemplate(d *schema.ResourceData) zabbix.TemplateIDs {
 	return unlinkID
 }
 
-func createTemplate(template interface{}, api *zabbix.API) (id string, err error) {
-	templates := zabbix.Templates{template.(zabbix.Template)}
-
-	err = api.TemplatesCreate(templates)
+func createTemplate(template interface{}, api *zabbixClient) (id string, err error) {
+	response, err := api.CallWithError("template.create", []zabbixTemplate{template.(zabbixTemplate)})
 	if err != nil {
 		return
 	}
-	id = templates[0].TemplateID
-	return
+	return idFromResponse(response, "templateids")
 }
 
-func updateTemplate(template interface{}, api *zabbix.API) (id string, err error) {
-	templates := zabbix.Templates{template.(zabbix.Template)}
-
-	err = api.TemplatesUpdate(templates)
+func updateTemplate(template interface{}, api *zabbixClient) (id string, err error) {
+	response, err := api.CallWithError("template.update", []zabbixTemplate{template.(zabbixTemplate)})
 	if err != nil {
 		return
 	}
-	id = templates[0].TemplateID
-	return
+	return idFromResponse(response, "templateids")
 }
 
-func getTemplateGroups(d *schema.ResourceData, api *zabbix.API) (zabbix.HostGroupIDs, error) {
+func getTemplateGroups(d *schema.ResourceData, api *zabbixClient) (zabbix.HostGroupIDs, error) {
 	configGroups := d.Get("groups").(*schema.Set)
-	setTemplateGroups := make([]string, configGroups.Len())
+	names := make([]string, configGroups.Len())
 
 	for i, g := range configGroups.List() {
-		setTemplateGroups[i] = g.(string)
+		names[i] = g.(string)
 	}
 
-	log.Printf("[DEBUG] Groups %v\n", setTemplateGroups)
-
-	groupParams := zabbix.Params{
-		"output": "extend",
-		"filter": map[string]interface{}{
-			"name": setTemplateGroups,
-		},
-	}
-
-	groups, err := api.TemplateGroupsGet(groupParams)
+	log.Printf("[DEBUG] Groups %v\n", names)
 
+	ids, err := api.lookupIDs(lookupTemplateGroup, names)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(groups) < configGroups.Len() {
-		log.Printf("[DEBUG] Not all of the specified groups were found on zabbix server")
+	templateGroups := make(zabbix.HostGroupIDs, len(names))
 
-		for _, n := range configGroups.List() {
-			found := false
-
-			for _, g := range groups {
-				if n == g.Name {
-					found = true
-					break
-				}
-			}
-
-			if !found {
-				return nil, fmt.Errorf("template group %s doesnt exist in zabbix server", n)
-			}
-			log.Printf("[DEBUG] %s exists on zabbix server", n)
+	for i, n := range names {
+		id, ok := ids[n]
+		if !ok {
+			return nil, fmt.Errorf("template group %s doesnt exist in zabbix server", n)
+		}
+		templateGroups[i] = zabbix.HostGroupID{
+			GroupID: id,
 		}
 	}
 
-	hostGroups := make(zabbix.HostGroupIDs, len(groups))
+	return templateGroups, nil
+}
 
-	for i, g := range groups {
-		hostGroups[i] = zabbix.HostGroupID{
-			GroupID: g.GroupID,
-		}
+func getTemplateIDs(api *zabbixClient, field, value string) ([]string, error) {
+	templates, err := api.TemplatesGet(zabbix.Params{
+		"output": []string{"templateid"},
+		"filter": map[string]interface{}{field: value},
+	})
+	if err != nil {
+		return nil, err
 	}
-
-	return hostGroups, nil
+	ids := make([]string, len(templates))
+	for i, template := range templates {
+		ids[i] = template.TemplateID
+	}
+	return ids, nil
 }
@@ -1,23 +1,26 @@
 package zabbix
 
 import (
+	"context"
 	"log"
-	"strings"
 
 	"github.com/claranet/go-zabbix-api"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 func resourceZabbixTemplateGroup() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceZabbixTemplateGroupCreate,
-		Read:   resourceZabbixTemplateGroupRead,
-		Exists: resourceZabbixTemplateGroupExists,
-		Update: resourceZabbixTemplateGroupUpdate,
-		Delete: resourceZabbixTemplateGroupDelete,
+		CreateContext: resourceZabbixTemplateGroupCreate,
+		ReadContext:   resourceZabbixTemplateGroupRead,
+		UpdateContext: resourceZabbixTemplateGroupUpdate,
+		DeleteContext: resourceZabbixTemplateGroupDelete,
 		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
+			StateContext: importStateByName("template group", getTemplateGroupIDs, "name"),
 		},
+		CustomizeDiff: customizeDiffVersion(
+			resourceRequiresVersion("6.2", "zabbix_template_group"),
+		),
 		Schema: map[string]*schema.Schema{
 			"name": &schema.Schema{
 				Type:        schema.TypeString,
@@ -33,8 +36,8 @@ func resourceZabbixTemplateGroup() *schema.Resource {
 	}
 }
 
-func resourceZabbixTemplateGroupCreate(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixTemplateGroupCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
 
 	templateGroup := zabbix.TemplateGroup{
 		Name: d.Get("name").(string),
@@ -43,7 +46,7 @@ func resourceZabbixTemplateGroupCreate(d *schema.ResourceData, meta interface{})
 
 	err := api.TemplateGroupsCreate(groups)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 
 	groupID := groups[0].GroupID
@@ -56,16 +59,21 @@ func resourceZabbixTemplateGroupCreate(d *schema.ResourceData, meta interface{})
 	return nil
 }
 
-func resourceZabbixTemplateGroupRead(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixTemplateGroupRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
 
 	log.Printf("[DEBUG] Will read template group with id %s", d.Id())
 
-	group, err := api.TemplateGroupGetByID(d.Id())
-
+	groups, err := api.TemplateGroupsGet(zabbix.Params{
+		"groupids": d.Id(),
+	})
 	if err != nil {
-		return err
+		return diag.FromErr(err)
+	}
+	if err := expectOne("template group", d.Id(), len(groups)); err != nil {
+		return readError(d, err)
 	}
+	group := groups[0]
 
 	d.Set("name", group.Name)
 	d.Set("group_id", group.GroupID)
@@ -73,33 +81,34 @@ func resourceZabbixTemplateGroupRead(d *schema.ResourceData, meta interface{}) e
 	return nil
 }
 
-func resourceZabbixTemplateGroupExists(d *schema.ResourceData, meta interface{}) (bool, error) {
-	api := meta.(*zabbix.API)
-
-	_, err := api.TemplateGroupGetByID(d.Id())
-	if err != nil {
-		if strings.Contains(err.Error(), "Expected exactly one result") {
-			log.Printf("[DEBUG] Template group with id %s doesn't exist", d.Id())
-			return false, nil
-		}
-		return false, err
-	}
-	return true, nil
-}
-
-func resourceZabbixTemplateGroupUpdate(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixTemplateGroupUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
 
 	templateGroup := zabbix.TemplateGroup{
 		Name:    d.Get("name").(string),
 		GroupID: d.Id(),
 	}
 
-	return api.TemplateGroupsUpdate(zabbix.TemplateGroups{templateGroup})
+	return diag.FromErr(api.TemplateGroupsUpdate(zabbix.TemplateGroups{templateGroup}))
 }
 
-func resourceZabbixTemplateGroupDelete(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixTemplateGroupDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
+
+	return diag.FromErr(api.TemplateGroupsDeleteByIds([]string{d.Id()}))
+}
 
-	return api.TemplateGroupsDeleteByIds([]string{d.Id()})
+func getTemplateGroupIDs(api *zabbixClient, field, value string) ([]string, error) {
+	groups, err := api.TemplateGroupsGet(zabbix.Params{
+		"output": []string{"groupid"},
+		"filter": map[string]interface{}{field: value},
+	})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(groups))
+	for i, group := range groups {
+		ids[i] = group.GroupID
+	}
+	return ids, nil
 }
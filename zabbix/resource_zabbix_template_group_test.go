@@ -28,12 +28,18 @@ func TestAccZabbixTemplateGroup_Basic(t *testing.T) {
 					resource.TestCheckResourceAttr("zabbix_template_group.zabbix", "name", groupName),
 				),
 			},
+			{
+				ResourceName:      "zabbix_template_group.zabbix",
+				ImportState:       true,
+				ImportStateId:     fmt.Sprintf("name:%s", groupName),
+				ImportStateVerify: true,
+			},
 		},
 	})
 }
 
 func testAccCheckZabbixTemplateGroupDestroy(s *terraform.State) error {
-	api := testAccProvider.Meta().(*zabbix.API)
+	api := testAccProvider.Meta().(*zabbixClient)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "zabbix_template_group" {
@@ -70,7 +76,7 @@ func testAccCheckZabbixTemplateGroupExists(resource string, templateGroup *zabbi
 			return fmt.Errorf("No record ID set")
 		}
 
-		api := testAccProvider.Meta().(*zabbix.API)
+		api := testAccProvider.Meta().(*zabbixClient)
 		group, err := api.TemplateGroupGetByID(rs.Primary.ID)
 		if err != nil {
 			return err
@@ -1,21 +1,22 @@
 package zabbix
 
 import (
+	"context"
 	"log"
 
 	"github.com/claranet/go-zabbix-api"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 func resourceZabbixTemplateLink() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceZabbixTemplateLinkCreate,
-		Read:   resourceZabbixTemplateLinkRead,
-		Exists: resourceZabbixTemplateLinkExists,
-		Update: resourceZabbixTemplateLinkUpdate,
-		Delete: resourceZabbixTemplateLinkDelete,
+		CreateContext: resourceZabbixTemplateLinkCreate,
+		ReadContext:   resourceZabbixTemplateLinkRead,
+		UpdateContext: resourceZabbixTemplateLinkUpdate,
+		DeleteContext: resourceZabbixTemplateLinkDelete,
 		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
+			StateContext: resourceZabbixTemplateLinkImportState,
 		},
 		Schema: map[string]*schema.Schema{
 			"template_id": &schema.Schema{
@@ -86,28 +87,28 @@ func schemaTemplatelldRule() *schema.Resource {
 	}
 }
 
-func resourceZabbixTemplateLinkCreate(d *schema.ResourceData, meta interface{}) error {
-	return resourceZabbixTemplateLinkRead(d, meta)
+func resourceZabbixTemplateLinkCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	return resourceZabbixTemplateLinkRead(ctx, d, meta)
 }
 
-func resourceZabbixTemplateLinkRead(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixTemplateLinkRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
 
 	itemsTerraform, err := getTerraformTemplateItems(d, api)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 	d.Set("item", itemsTerraform)
 
 	triggersTerraform, err := getTerraformTemplateTriggers(d, api)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 	d.Set("trigger", triggersTerraform)
 
 	lldRulesTerraform, err := getTerraformTemplateLLDRules(d, api)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 	d.Set("lld_rule", lldRulesTerraform)
 
@@ -115,33 +116,39 @@ func resourceZabbixTemplateLinkRead(d *schema.ResourceData, meta interface{}) er
 	return nil
 }
 
-func resourceZabbixTemplateLinkExists(d *schema.ResourceData, meta interface{}) (bool, error) {
-	return true, nil
+// resourceZabbixTemplateLinkImportState accepts the ID of the template, or its technical or visible name prefixed
+// with host: or name:, and sets template_id the ID of the link derives from
+func resourceZabbixTemplateLinkImportState(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	if _, err := importStateByName("template", getTemplateIDs, "name", "host")(ctx, d, meta); err != nil {
+		return nil, err
+	}
+	d.Set("template_id", d.Id())
+	return []*schema.ResourceData{d}, nil
 }
 
-func resourceZabbixTemplateLinkUpdate(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixTemplateLinkUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
 
 	err := updateZabbixTemplateItems(d, api)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 	err = updateZabbixTemplateTriggers(d, api)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 	err = updateZabbixTemplateDiscoveryRules(d, api)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
-	return resourceZabbixTemplateLinkRead(d, meta)
+	return resourceZabbixTemplateLinkRead(ctx, d, meta)
 }
 
-func resourceZabbixTemplateLinkDelete(d *schema.ResourceData, meta interface{}) error {
+func resourceZabbixTemplateLinkDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	return nil
 }
 
-func getTerraformTemplateItems(d *schema.ResourceData, api *zabbix.API) ([]interface{}, error) {
+func getTerraformTemplateItems(d *schema.ResourceData, api *zabbixClient) ([]interface{}, error) {
 	params := zabbix.Params{
 		"output": "extend",
 		"templateids": []string{
@@ -165,7 +172,7 @@ func getTerraformTemplateItems(d *schema.ResourceData, api *zabbix.API) ([]inter
 	return itemsTerraform, nil
 }
 
-func getTerraformTemplateTriggers(d *schema.ResourceData, api *zabbix.API) ([]interface{}, error) {
+func getTerraformTemplateTriggers(d *schema.ResourceData, api *zabbixClient) ([]interface{}, error) {
 	params := zabbix.Params{
 		"output": "extend",
 		"templateids": []string{
@@ -189,7 +196,7 @@ func getTerraformTemplateTriggers(d *schema.ResourceData, api *zabbix.API) ([]in
 	return triggersTerraform, nil
 }
 
-func getTerraformTemplateLLDRules(d *schema.ResourceData, api *zabbix.API) ([]interface{}, error) {
+func getTerraformTemplateLLDRules(d *schema.ResourceData, api *zabbixClient) ([]interface{}, error) {
 	params := zabbix.Params{
 		"output": "extend",
 		"templateids": []string{
@@ -213,7 +220,7 @@ func getTerraformTemplateLLDRules(d *schema.ResourceData, api *zabbix.API) ([]in
 	return lldRulesTerraform, nil
 }
 
-func updateZabbixTemplateItems(d *schema.ResourceData, api *zabbix.API) error {
+func updateZabbixTemplateItems(d *schema.ResourceData, api *zabbixClient) error {
 	if d.HasChange("item") {
 		oldV, newV := d.GetChange("item")
 		oldItems := oldV.(*schema.Set).List()
@@ -270,7 +277,7 @@ func updateZabbixTemplateItems(d *schema.ResourceData, api *zabbix.API) error {
 	return nil
 }
 
-func updateZabbixTemplateTriggers(d *schema.ResourceData, api *zabbix.API) error {
+func updateZabbixTemplateTriggers(d *schema.ResourceData, api *zabbixClient) error {
 	if d.HasChange("trigger") {
 		oldV, newV := d.GetChange("trigger")
 		oldTriggers := oldV.(*schema.Set).List()
@@ -328,7 +335,7 @@ func updateZabbixTemplateTriggers(d *schema.ResourceData, api *zabbix.API) error
 	return nil
 }
 
-func updateZabbixTemplateDiscoveryRules(d *schema.ResourceData, api *zabbix.API) error {
+func updateZabbixTemplateDiscoveryRules(d *schema.ResourceData, api *zabbixClient) error {
 	if d.HasChange("lld_rule") {
 		oldV, newV := d.GetChange("lld_rule")
 		oldlldRules := oldV.(*schema.Set).List()
@@ -1,7 +1,9 @@
 package zabbix
 
 import (
+	"context"
 	"fmt"
+	"reflect"
 	"testing"
 
 	"github.com/claranet/go-zabbix-api"
@@ -26,8 +28,10 @@ func TestAccZabbixTemplate_Basic(t *testing.T) {
 					resource.TestCheckResourceAttr(resourceName, "name", fmt.Sprintf("template_%s", strID)),
 					resource.TestCheckResourceAttr(resourceName, "host", fmt.Sprintf("template_%s", strID)),
 					resource.TestCheckResourceAttr(resourceName, "groups.#", "1"),
-					resource.TestCheckResourceAttr(resourceName, "macro.MACRO1", "value1"),
-					resource.TestCheckResourceAttr(resourceName, "macro.MACRO2", "value2"),
+					resource.TestCheckResourceAttr(resourceName, "macro.0.name", "MACRO1"),
+					resource.TestCheckResourceAttr(resourceName, "macro.0.value", "value1"),
+					resource.TestCheckResourceAttr(resourceName, "macro.1.name", "MACRO2"),
+					resource.TestCheckResourceAttr(resourceName, "macro.1.value", "value2"),
 				),
 			},
 			{
@@ -37,8 +41,10 @@ func TestAccZabbixTemplate_Basic(t *testing.T) {
 					resource.TestCheckResourceAttr(resourceName, "name", fmt.Sprintf("update_template_%s", strID)),
 					resource.TestCheckResourceAttr(resourceName, "host", fmt.Sprintf("update_template_%s", strID)),
 					resource.TestCheckResourceAttr(resourceName, "groups.#", "1"),
-					resource.TestCheckResourceAttr(resourceName, "macro.MACRO1", "update_value1"),
-					resource.TestCheckResourceAttr(resourceName, "macro.UPDATE_MACRO2", "value2"),
+					resource.TestCheckResourceAttr(resourceName, "macro.0.name", "MACRO1"),
+					resource.TestCheckResourceAttr(resourceName, "macro.0.value", "update_value1"),
+					resource.TestCheckResourceAttr(resourceName, "macro.1.name", "UPDATE_MACRO2"),
+					resource.TestCheckResourceAttr(resourceName, "macro.1.value", "value2"),
 				),
 			},
 		},
@@ -58,39 +64,106 @@ func TestAccZabbixTemplate_UserMacro(t *testing.T) {
 				Config: testAccZabbixTemplateUserMacro(strID),
 				Check: resource.ComposeAggregateTestCheckFunc(
 					resource.TestCheckResourceAttr(resourceName, "host", fmt.Sprintf("template_%s", strID)),
-					resource.TestCheckResourceAttr(resourceName, "macro.%", "1"),
-					resource.TestCheckResourceAttr(resourceName, "macro.MYMACRO1", "value1"),
+					resource.TestCheckResourceAttr(resourceName, "macro.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "macro.0.name", "MYMACRO1"),
+					resource.TestCheckResourceAttr(resourceName, "macro.0.value", "value1"),
 				),
 			},
 			{
 				Config: testAccZabbixTemplateUserMacroAdd(strID),
 				Check: resource.ComposeAggregateTestCheckFunc(
 					resource.TestCheckResourceAttr(resourceName, "host", fmt.Sprintf("template_%s", strID)),
-					resource.TestCheckResourceAttr(resourceName, "macro.%", "2"),
-					resource.TestCheckResourceAttr(resourceName, "macro.MYMACRO1", "value1"),
-					resource.TestCheckResourceAttr(resourceName, "macro.MYMACRO2", "value2"),
+					resource.TestCheckResourceAttr(resourceName, "macro.#", "2"),
+					resource.TestCheckResourceAttr(resourceName, "macro.0.name", "MYMACRO1"),
+					resource.TestCheckResourceAttr(resourceName, "macro.0.value", "value1"),
+					resource.TestCheckResourceAttr(resourceName, "macro.1.name", "MYMACRO2"),
+					resource.TestCheckResourceAttr(resourceName, "macro.1.value", "value2"),
 				),
 			},
 			{
 				Config: testAccZabbixTemplateUserMacroUpdate(strID),
 				Check: resource.ComposeAggregateTestCheckFunc(
 					resource.TestCheckResourceAttr(resourceName, "host", fmt.Sprintf("template_%s", strID)),
-					resource.TestCheckResourceAttr(resourceName, "macro.%", "2"),
-					resource.TestCheckResourceAttr(resourceName, "macro.MYMACRO1", "value3"),
-					resource.TestCheckResourceAttr(resourceName, "macro.MYMACRO3", "value2"),
+					resource.TestCheckResourceAttr(resourceName, "macro.#", "2"),
+					resource.TestCheckResourceAttr(resourceName, "macro.0.name", "MYMACRO1"),
+					resource.TestCheckResourceAttr(resourceName, "macro.0.value", "value3"),
+					resource.TestCheckResourceAttr(resourceName, "macro.1.name", "MYMACRO3"),
+					resource.TestCheckResourceAttr(resourceName, "macro.1.value", "value2"),
 				),
 			},
 			{
 				Config: testAccZabbixTemplateUserMacroDelete(strID),
 				Check: resource.ComposeAggregateTestCheckFunc(
 					resource.TestCheckResourceAttr(resourceName, "host", fmt.Sprintf("template_%s", strID)),
-					resource.TestCheckResourceAttr(resourceName, "macro.%", "0"),
+					resource.TestCheckResourceAttr(resourceName, "macro.#", "0"),
 				),
 			},
 		},
 	})
 }
 
+func TestAccZabbixTemplate_secretMacro(t *testing.T) {
+	resourceName := "zabbix_template.template_test"
+	strID := acctest.RandString(5)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckZabbixTemplateDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccZabbixTemplateSecretMacro(strID),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "macro.#", "2"),
+					resource.TestCheckResourceAttr(resourceName, "macro.0.name", "PASSWORD"),
+					resource.TestCheckResourceAttr(resourceName, "macro.0.value", "s3cr3t"),
+					resource.TestCheckResourceAttr(resourceName, "macro.0.type", "secret"),
+					resource.TestCheckResourceAttr(resourceName, "macro.0.description", "password of the monitoring user"),
+					resource.TestCheckResourceAttr(resourceName, "macro.1.name", "USER"),
+					resource.TestCheckResourceAttr(resourceName, "macro.1.value", "monitoring"),
+					resource.TestCheckResourceAttr(resourceName, "macro.1.type", "text"),
+				),
+			},
+			{
+				ResourceName:            resourceName,
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"macro.0.value"},
+			},
+			{
+				ResourceName:            resourceName,
+				ImportState:             true,
+				ImportStateId:           fmt.Sprintf("host:template_%s", strID),
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"macro.0.value"},
+			},
+		},
+	})
+}
+
+func TestResourceZabbixMacroStateUpgradeV0(t *testing.T) {
+	rawState := map[string]interface{}{
+		"host": "template",
+		"macro": map[string]interface{}{
+			"MACRO2": "value2",
+			"MACRO1": "value1",
+		},
+	}
+
+	actual, err := resourceZabbixMacroStateUpgradeV0(context.Background(), rawState, nil)
+	if err != nil {
+		t.Fatalf("error upgrading state: %s", err)
+	}
+
+	expected := []interface{}{
+		map[string]interface{}{"name": "MACRO1", "value": "value1", "type": "text", "description": ""},
+		map[string]interface{}{"name": "MACRO2", "value": "value2", "type": "text", "description": ""},
+	}
+	if !reflect.DeepEqual(actual["macro"], expected) {
+		t.Fatalf("expected macros %#v, got %#v", expected, actual["macro"])
+	}
+}
+
 func TestAccZabbixTemplate_linkedTemplate(t *testing.T) {
 	resource1Name := "zabbix_template.template_test_1"
 	resource2Name := "zabbix_template.template_test_2"
@@ -126,7 +199,7 @@ func TestAccZabbixTemplate_linkedTemplate(t *testing.T) {
 }
 
 func testAccCheckZabbixTemplateDestroy(s *terraform.State) error {
-	api := testAccProvider.Meta().(*zabbix.API)
+	api := testAccProvider.Meta().(*zabbixClient)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "zabbix_template" {
@@ -158,9 +231,13 @@ func testAccZabbixTemplateSimpleConfig(strID string) string {
 		groups = ["${zabbix_template_group.template_group_test.name}"]
 		name = "template_%s"
 		description = "test_template_description"
-		macro = {
-		  MACRO1 = "value1"
-		  MACRO2 = "value2"
+		macro {
+		  name  = "MACRO1"
+		  value = "value1"
+		}
+		macro {
+		  name  = "MACRO2"
+		  value = "value2"
 		}
 	}
 	`, strID, strID, strID)
@@ -177,9 +254,13 @@ func testAccZabbixTemplateSimpleUpdate(strID string) string {
 		groups = ["${zabbix_template_group.template_group_test.name}"]
 		name = "update_template_%s"
 		description = "update_test_template_description"
-		macro = {
-		  MACRO1 = "update_value1"
-		  UPDATE_MACRO2 = "value2"
+		macro {
+		  name  = "MACRO1"
+		  value = "update_value1"
+		}
+		macro {
+		  name  = "UPDATE_MACRO2"
+		  value = "value2"
 		}
 	}
 	`, strID, strID, strID)
@@ -232,8 +313,9 @@ func testAccZabbixTemplateUserMacro(strID string) string {
 	resource "zabbix_template" "template_test" {
 		host = "template_%s"
 		groups = ["${zabbix_template_group.template_group_test.name}"]
-		macro = {
-			MYMACRO1 = "value1"
+		macro {
+			name  = "MYMACRO1"
+			value = "value1"
 		}
 	}
 	`, strID, strID)
@@ -248,9 +330,13 @@ func testAccZabbixTemplateUserMacroAdd(strID string) string {
 	resource "zabbix_template" "template_test" {
 		host = "template_%s"
 		groups = ["${zabbix_template_group.template_group_test.name}"]
-		macro = {
-			MYMACRO1 = "value1"
-			MYMACRO2 = "value2"
+		macro {
+			name  = "MYMACRO1"
+			value = "value1"
+		}
+		macro {
+			name  = "MYMACRO2"
+			value = "value2"
 		}
 	}
 	`, strID, strID)
@@ -265,9 +351,36 @@ func testAccZabbixTemplateUserMacroUpdate(strID string) string {
 	resource "zabbix_template" "template_test" {
 		host = "template_%s"
 		groups = ["${zabbix_template_group.template_group_test.name}"]
-		macro = {
-			MYMACRO1 = "value3"
-			MYMACRO3 = "value2"
+		macro {
+			name  = "MYMACRO1"
+			value = "value3"
+		}
+		macro {
+			name  = "MYMACRO3"
+			value = "value2"
+		}
+	}
+	`, strID, strID)
+}
+
+func testAccZabbixTemplateSecretMacro(strID string) string {
+	return fmt.Sprintf(`
+	resource "zabbix_template_group" "template_group_test" {
+		name = "template_group_%s"
+	}
+
+	resource "zabbix_template" "template_test" {
+		host = "template_%s"
+		groups = ["${zabbix_template_group.template_group_test.name}"]
+		macro {
+			name = "PASSWORD"
+			value = "s3cr3t"
+			type = "secret"
+			description = "password of the monitoring user"
+		}
+		macro {
+			name = "USER"
+			value = "monitoring"
 		}
 	}
 	`, strID, strID)
@@ -282,6 +395,7 @@ func testAccZabbixTemplateUserMacroDelete(strID string) string {
 	resource "zabbix_template" "template_test" {
 		host = "template_%s"
 		groups = ["${zabbix_template_group.template_group_test.name}"]
+		macro = []
 	}
 	`, strID, strID)
 }
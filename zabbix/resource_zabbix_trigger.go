@@ -1,33 +1,47 @@
 package zabbix
 
 import (
+	"context"
 	"fmt"
 	"log"
-	"strings"
+	"time"
 
 	"github.com/claranet/go-zabbix-api"
-	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func resourceZabbixTrigger() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceZabbixTriggerCreate,
-		Read:   resourceZabbixTriggerRead,
-		Exists: resourceZabbixTriggerExists,
-		Update: resourceZabbixTriggerUpdate,
-		Delete: resourceZabbixTriggerDelete,
+		CreateContext: resourceZabbixTriggerCreate,
+		ReadContext:   resourceZabbixTriggerRead,
+		UpdateContext: resourceZabbixTriggerUpdate,
+		DeleteContext: resourceZabbixTriggerDelete,
 		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
+			StateContext: resourceZabbixTriggerImportState,
 		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(time.Minute),
+			Update: schema.DefaultTimeout(time.Minute),
+			Delete: schema.DefaultTimeout(time.Minute),
+		},
+		CustomizeDiff: customizeDiffVersion(triggerExpressionSyntax),
 		Schema: map[string]*schema.Schema{
 			"description": &schema.Schema{
 				Type:     schema.TypeString,
 				Required: true,
 			},
 			"expression": &schema.Schema{
-				Type:     schema.TypeString,
-				Required: true,
+				Type:             schema.TypeString,
+				Required:         true,
+				ValidateFunc:     validateTriggerExpression,
+				DiffSuppressFunc: suppressTriggerExpressionDiff,
+			},
+			"expression_syntax": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"auto", "check"}, false),
 			},
 			"comment": &schema.Schema{
 				Type:     schema.TypeString,
@@ -67,14 +81,19 @@ func resourceZabbixTrigger() *schema.Resource {
 	}
 }
 
-func resourceZabbixTriggerCreate(d *schema.ResourceData, meta interface{}) error {
+func resourceZabbixTriggerCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	trigger := createTriggerObj(d)
+	expression, err := serverTriggerExpression(d, meta.(*zabbixClient))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	trigger.Expression = expression
 
-	return createRetry(d, meta, createTrigger, trigger, resourceZabbixTriggerRead)
+	return createRetry(ctx, d, meta, createTrigger, trigger, resourceZabbixTriggerRead, d.Timeout(schema.TimeoutCreate))
 }
 
-func resourceZabbixTriggerRead(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixTriggerRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
 
 	params := zabbix.Params{
 		"output":             "extend",
@@ -85,16 +104,18 @@ func resourceZabbixTriggerRead(d *schema.ResourceData, meta interface{}) error {
 	}
 	res, err := api.TriggersGet(params)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
-	if len(res) != 1 {
-		return fmt.Errorf("Expected one result got : %d", len(res))
+	if err := expectOne("trigger", d.Id(), len(res)); err != nil {
+		return readError(d, err)
 	}
 	trigger := res[0]
-	err = getTriggerExpression(&trigger, api)
+	if err := getTriggerExpression(&trigger, api); err != nil {
+		return diag.FromErr(err)
+	}
 	log.Printf("[DEBUG] trigger expression: %s", trigger.Expression)
 	d.Set("description", trigger.Description)
-	d.Set("expression", trigger.Expression)
+	d.Set("expression", stateTriggerExpression(d, trigger.Expression))
 	if trigger.Comments != "" {
 		d.Set("comment", trigger.Comments)
 	}
@@ -109,22 +130,42 @@ func resourceZabbixTriggerRead(d *schema.ResourceData, meta interface{}) error {
 	return nil
 }
 
-func resourceZabbixTriggerExists(d *schema.ResourceData, meta interface{}) (bool, error) {
-	api := meta.(*zabbix.API)
+// resourceZabbixTriggerImportState accepts the ID of the trigger or <host>/<description>
+func resourceZabbixTriggerImportState(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	api := meta.(*zabbixClient)
+
+	parts := splitImportID(d.Id(), 2)
+	if len(parts) != 2 {
+		return []*schema.ResourceData{d}, nil
+	}
 
-	_, err := api.TriggerGetByID(d.Id())
+	triggers, err := api.TriggersGet(zabbix.Params{
+		"output": []string{"triggerid"},
+		"host":   parts[0],
+		"filter": map[string]interface{}{"description": parts[1]},
+	})
 	if err != nil {
-		if strings.Contains(err.Error(), "Expected exactly one result") {
-			log.Printf("[DEBUG] Trigger with id %s doesn't exist", d.Id())
-			return false, nil
-		}
-		return false, err
+		return nil, err
+	}
+	ids := make([]string, len(triggers))
+	for i, trigger := range triggers {
+		ids[i] = trigger.TriggerID
+	}
+	id, err := singleImportID("trigger", fmt.Sprintf("description %q on host %q", parts[1], parts[0]), ids)
+	if err != nil {
+		return nil, err
 	}
-	return true, nil
+	d.SetId(id)
+	return []*schema.ResourceData{d}, nil
 }
 
-func resourceZabbixTriggerUpdate(d *schema.ResourceData, meta interface{}) error {
+func resourceZabbixTriggerUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	trigger := createTriggerObj(d)
+	expression, err := serverTriggerExpression(d, meta.(*zabbixClient))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	trigger.Expression = expression
 
 	trigger.TriggerID = d.Id()
 	if !d.HasChange("description") {
@@ -136,13 +177,13 @@ func resourceZabbixTriggerUpdate(d *schema.ResourceData, meta interface{}) error
 	if !d.HasChange("dependencies") {
 		trigger.Dependencies = nil
 	}
-	return createRetry(d, meta, updateTrigger, trigger, resourceZabbixTriggerRead)
+	return createRetry(ctx, d, meta, updateTrigger, trigger, resourceZabbixTriggerRead, d.Timeout(schema.TimeoutUpdate))
 }
 
-func resourceZabbixTriggerDelete(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixTriggerDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
 
-	return deleteRetry(d.Id(), getTriggerParentID, api.TriggersDeleteIDs, api)
+	return deleteRetry(ctx, d.Id(), getTriggerParentID, api.TriggersDeleteIDs, api, d.Timeout(schema.TimeoutDelete))
 }
 
 func createTriggerDependencies(d *schema.ResourceData) zabbix.TriggerIDs {
@@ -167,38 +208,25 @@ func createTriggerObj(d *schema.ResourceData) zabbix.Trigger {
 	}
 }
 
-func getTriggerExpression(trigger *zabbix.Trigger, api *zabbix.API) error {
-	for _, function := range trigger.Functions {
-		var item zabbix.Item
-
-		items, err := api.ItemsGet(zabbix.Params{
-			"output":      "extend",
-			"selectHosts": "extend",
-			"itemids":     function.ItemID,
-		})
-		if err != nil {
-			return err
+func getTriggerExpression(trigger *zabbix.Trigger, api *zabbixClient) error {
+	functions := make([]triggerFunction, len(trigger.Functions))
+	for i, function := range trigger.Functions {
+		functions[i] = triggerFunction{
+			id:        function.FunctionID,
+			itemID:    function.ItemID,
+			function:  function.Function,
+			parameter: function.Parameter,
 		}
-		if len(items) != 1 {
-			return fmt.Errorf("Expected one item with id : %s and got : %d", function.ItemID, len(items))
-		}
-		item = items[0]
-		if len(item.ItemParent) != 1 {
-			return fmt.Errorf("Expected one parent host for item with id %s, and got : %d", function.ItemID, len(item.ItemParent))
-		}
-		idstr := fmt.Sprintf("{%s}", function.FunctionID)
-		var expendValue string
-		if api.ServerVersion.GreaterThanOrEqual(version.Must(version.NewVersion("5.4"))) {
-			expendValue = fmt.Sprintf("%s(/%s/%s%s)", function.Function, item.ItemParent[0].Host, item.Key, function.Parameter[1:])
-		} else {
-			expendValue = fmt.Sprintf("{%s:%s.%s(%s)}", item.ItemParent[0].Host, item.Key, function.Function, function.Parameter)
-		}
-		trigger.Expression = strings.Replace(trigger.Expression, idstr, expendValue, 1)
 	}
+	expression, err := expandTriggerExpression(api, trigger.Expression, functions, getTriggerItems)
+	if err != nil {
+		return err
+	}
+	trigger.Expression = expression
 	return nil
 }
 
-func getTriggerParentID(api *zabbix.API, id string) (string, error) {
+func getTriggerParentID(api *zabbixClient, id string) (string, error) {
 	triggers, err := api.TriggersGet(zabbix.Params{
 		"ouput":       "extend",
 		"selectHosts": "extend",
@@ -216,7 +244,7 @@ func getTriggerParentID(api *zabbix.API, id string) (string, error) {
 	return triggers[0].ParentHosts[0].HostID, nil
 }
 
-func createTrigger(trigger interface{}, api *zabbix.API) (id string, err error) {
+func createTrigger(trigger interface{}, api *zabbixClient) (id string, err error) {
 	triggers := zabbix.Triggers{trigger.(zabbix.Trigger)}
 
 	err = api.TriggersCreate(triggers)
@@ -227,7 +255,7 @@ func createTrigger(trigger interface{}, api *zabbix.API) (id string, err error)
 	return
 }
 
-func updateTrigger(trigger interface{}, api *zabbix.API) (id string, err error) {
+func updateTrigger(trigger interface{}, api *zabbixClient) (id string, err error) {
 	triggers := zabbix.Triggers{trigger.(zabbix.Trigger)}
 
 	err = api.TriggersUpdate(triggers)
@@ -1,33 +1,47 @@
 package zabbix
 
 import (
+	"context"
 	"fmt"
 	"log"
-	"strings"
+	"time"
 
 	"github.com/claranet/go-zabbix-api"
-	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 )
 
 func resourceZabbixTriggerPrototype() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceZabbixTriggerPrototypeCreate,
-		Read:   resourceZabbixTriggerPrototypeRead,
-		Exists: resourceZabbixTriggerPrototypeExist,
-		Update: resourceZabbixTriggerPrototypeUpdate,
-		Delete: resourceZabbixTriggerPrototypeDelete,
+		CreateContext: resourceZabbixTriggerPrototypeCreate,
+		ReadContext:   resourceZabbixTriggerPrototypeRead,
+		UpdateContext: resourceZabbixTriggerPrototypeUpdate,
+		DeleteContext: resourceZabbixTriggerPrototypeDelete,
 		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
+			StateContext: resourceZabbixTriggerPrototypeImportState,
 		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(time.Minute),
+			Update: schema.DefaultTimeout(time.Minute),
+			Delete: schema.DefaultTimeout(time.Minute),
+		},
+		CustomizeDiff: customizeDiffVersion(triggerExpressionSyntax),
 		Schema: map[string]*schema.Schema{
 			"description": &schema.Schema{
 				Type:     schema.TypeString,
 				Required: true,
 			},
 			"expression": &schema.Schema{
-				Type:     schema.TypeString,
-				Required: true,
+				Type:             schema.TypeString,
+				Required:         true,
+				ValidateFunc:     validateTriggerExpression,
+				DiffSuppressFunc: suppressTriggerExpressionDiff,
+			},
+			"expression_syntax": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"auto", "check"}, false),
 			},
 			"priority": &schema.Schema{
 				Type:     schema.TypeInt,
@@ -63,14 +77,19 @@ func resourceZabbixTriggerPrototype() *schema.Resource {
 	}
 }
 
-func resourceZabbixTriggerPrototypeCreate(d *schema.ResourceData, meta interface{}) error {
+func resourceZabbixTriggerPrototypeCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	trigger := createTriggerPrototypeObj(d)
+	expression, err := serverTriggerExpression(d, meta.(*zabbixClient))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	trigger.Expression = expression
 
-	return createRetry(d, meta, createTriggerPrototype, trigger, resourceZabbixTriggerPrototypeRead)
+	return createRetry(ctx, d, meta, createTriggerPrototype, trigger, resourceZabbixTriggerPrototypeRead, d.Timeout(schema.TimeoutCreate))
 }
 
-func resourceZabbixTriggerPrototypeRead(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixTriggerPrototypeRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
 
 	params := zabbix.Params{
 		"output":             "extend",
@@ -81,16 +100,18 @@ func resourceZabbixTriggerPrototypeRead(d *schema.ResourceData, meta interface{}
 	}
 	res, err := api.TriggerPrototypesGet(params)
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
-	if len(res) != 1 {
-		return fmt.Errorf("Expected one result got : %d", len(res))
+	if err := expectOne("trigger prototype", d.Id(), len(res)); err != nil {
+		return readError(d, err)
 	}
 	trigger := res[0]
-	err = getTriggerPrototypeExpression(&trigger, api)
+	if err := getTriggerPrototypeExpression(&trigger, api); err != nil {
+		return diag.FromErr(err)
+	}
 	log.Printf("[DEBUG] trigger expression: %s", trigger.Expression)
 	d.Set("description", trigger.Description)
-	d.Set("expression", trigger.Expression)
+	d.Set("expression", stateTriggerExpression(d, trigger.Expression))
 	d.Set("priority", trigger.Priority)
 	d.Set("status", trigger.Status)
 
@@ -102,33 +123,59 @@ func resourceZabbixTriggerPrototypeRead(d *schema.ResourceData, meta interface{}
 	return nil
 }
 
-func resourceZabbixTriggerPrototypeExist(d *schema.ResourceData, meta interface{}) (bool, error) {
-	api := meta.(*zabbix.API)
+// resourceZabbixTriggerPrototypeImportState accepts the ID of the trigger prototype or
+// <host>/<lld rule key>/<description>
+func resourceZabbixTriggerPrototypeImportState(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	api := meta.(*zabbixClient)
+
+	parts := splitImportID(d.Id(), 3)
+	if len(parts) != 3 {
+		return []*schema.ResourceData{d}, nil
+	}
 
-	_, err := api.TriggerPrototypeGetByID(d.Id())
+	ruleID, _, err := getLLDRuleByKey(api, parts[0], parts[1])
 	if err != nil {
-		if strings.Contains(err.Error(), "Expected exactly one result") {
-			log.Printf("Trigger prototype with id %s doesn't exist", d.Id())
-			return false, nil
-		}
-		return false, err
+		return nil, err
+	}
+
+	triggers, err := api.TriggerPrototypesGet(zabbix.Params{
+		"output":       []string{"triggerid"},
+		"discoveryids": ruleID,
+		"filter":       map[string]interface{}{"description": parts[2]},
+	})
+	if err != nil {
+		return nil, err
 	}
-	return true, nil
+	ids := make([]string, len(triggers))
+	for i, trigger := range triggers {
+		ids[i] = trigger.TriggerID
+	}
+	id, err := singleImportID("trigger prototype", fmt.Sprintf("description %q in rule %q of host %q", parts[2], parts[1], parts[0]), ids)
+	if err != nil {
+		return nil, err
+	}
+	d.SetId(id)
+	return []*schema.ResourceData{d}, nil
 }
 
-func resourceZabbixTriggerPrototypeUpdate(d *schema.ResourceData, meta interface{}) error {
+func resourceZabbixTriggerPrototypeUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	trigger := createTriggerPrototypeObj(d)
+	expression, err := serverTriggerExpression(d, meta.(*zabbixClient))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	trigger.Expression = expression
 	trigger.TriggerID = d.Id()
 	if !d.HasChange("dependencies") {
 		trigger.Dependencies = nil
 	}
-	return createRetry(d, meta, updateTriggerPrototype, trigger, resourceZabbixTriggerPrototypeRead)
+	return createRetry(ctx, d, meta, updateTriggerPrototype, trigger, resourceZabbixTriggerPrototypeRead, d.Timeout(schema.TimeoutUpdate))
 }
 
-func resourceZabbixTriggerPrototypeDelete(d *schema.ResourceData, meta interface{}) error {
-	api := meta.(*zabbix.API)
+func resourceZabbixTriggerPrototypeDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
 
-	return deleteRetry(d.Id(), getTriggerPrototypeParentID, api.TriggerPrototypesDeleteIDs, api)
+	return deleteRetry(ctx, d.Id(), getTriggerPrototypeParentID, api.TriggerPrototypesDeleteIDs, api, d.Timeout(schema.TimeoutDelete))
 }
 
 func createTriggerPrototypeDependencies(d *schema.ResourceData) zabbix.TriggerPrototypeIDs {
@@ -152,38 +199,25 @@ func createTriggerPrototypeObj(d *schema.ResourceData) zabbix.TriggerPrototype {
 	}
 }
 
-func getTriggerPrototypeExpression(trigger *zabbix.TriggerPrototype, api *zabbix.API) error {
-	for _, function := range trigger.Functions {
-		var item zabbix.ItemPrototype
-
-		items, err := api.ItemPrototypesGet(zabbix.Params{
-			"output":      "extend",
-			"selectHosts": "extend",
-			"itemids":     function.ItemID,
-		})
-		if err != nil {
-			return err
+func getTriggerPrototypeExpression(trigger *zabbix.TriggerPrototype, api *zabbixClient) error {
+	functions := make([]triggerFunction, len(trigger.Functions))
+	for i, function := range trigger.Functions {
+		functions[i] = triggerFunction{
+			id:        function.FunctionID,
+			itemID:    function.ItemID,
+			function:  function.Function,
+			parameter: function.Parameter,
 		}
-		if len(items) != 1 {
-			return fmt.Errorf("Expected one item with id : %s and got : %d", function.ItemID, len(items))
-		}
-		item = items[0]
-		if len(item.Hosts) != 1 {
-			return fmt.Errorf("Expected one parent host for item with id %s, and got : %d", function.ItemID, len(item.Hosts))
-		}
-		idstr := fmt.Sprintf("{%s}", function.FunctionID)
-		var expendValue string
-		if api.ServerVersion.GreaterThanOrEqual(version.Must(version.NewVersion("5.4"))) {
-			expendValue = fmt.Sprintf("%s(/%s/%s%s)", function.Function, item.Hosts[0].Host, item.Key, function.Parameter[1:])
-		} else {
-			expendValue = fmt.Sprintf("{%s:%s.%s(%s)}", item.Hosts[0].Host, item.Key, function.Function, function.Parameter)
-		}
-		trigger.Expression = strings.Replace(trigger.Expression, idstr, expendValue, 1)
 	}
+	expression, err := expandTriggerExpression(api, trigger.Expression, functions, getTriggerItemPrototypes)
+	if err != nil {
+		return err
+	}
+	trigger.Expression = expression
 	return nil
 }
 
-func getTriggerPrototypeParentID(api *zabbix.API, id string) (string, error) {
+func getTriggerPrototypeParentID(api *zabbixClient, id string) (string, error) {
 	triggers, err := api.TriggerPrototypesGet(zabbix.Params{
 		"ouput":       "extend",
 		"selectHosts": "extend",
@@ -192,8 +226,8 @@ func getTriggerPrototypeParentID(api *zabbix.API, id string) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	if len(triggers) != 1 {
-		return "", fmt.Errorf("Expected one trigger prototype and got %d trigger prototype", len(triggers))
+	if err := expectOne("trigger prototype", id, len(triggers)); err != nil {
+		return "", err
 	}
 	if len(triggers[0].ParentHosts) != 1 {
 		return "", fmt.Errorf("Expected one parent for trigger prototype %s and got %d", id, len(triggers[0].ParentHosts))
@@ -201,7 +235,7 @@ func getTriggerPrototypeParentID(api *zabbix.API, id string) (string, error) {
 	return triggers[0].ParentHosts[0].HostID, nil
 }
 
-func createTriggerPrototype(trigger interface{}, api *zabbix.API) (id string, err error) {
+func createTriggerPrototype(trigger interface{}, api *zabbixClient) (id string, err error) {
 	triggers := zabbix.TriggerPrototypes{trigger.(zabbix.TriggerPrototype)}
 
 	err = api.TriggerPrototypesCreate(triggers)
@@ -212,7 +246,7 @@ func createTriggerPrototype(trigger interface{}, api *zabbix.API) (id string, er
 	return
 }
 
-func updateTriggerPrototype(trigger interface{}, api *zabbix.API) (id string, err error) {
+func updateTriggerPrototype(trigger interface{}, api *zabbixClient) (id string, err error) {
 	triggers := zabbix.TriggerPrototypes{trigger.(zabbix.TriggerPrototype)}
 
 	err = api.TriggerPrototypesUpdate(triggers)
@@ -38,6 +38,12 @@ func TestAccZabbixTriggerPrototype_Basic(t *testing.T) {
 					resource.TestCheckResourceAttr("zabbix_trigger_prototype.trigger_prototype_test", "status", "1"),
 				),
 			},
+			{
+				ResourceName:      "zabbix_trigger_prototype.trigger_prototype_test",
+				ImportState:       true,
+				ImportStateId:     fmt.Sprintf("%s/key.lolo/trigger_prototype_test_update", templateName),
+				ImportStateVerify: true,
+			},
 		},
 	})
 }
@@ -105,7 +111,7 @@ func TestAccZabbixTriggerPrototype_ExpressionUpdate(t *testing.T) {
 }
 
 func testAccCheckZabbixTriggerPrototypeDestroy(s *terraform.State) error {
-	api := testAccProvider.Meta().(*zabbix.API)
+	api := testAccProvider.Meta().(*zabbixClient)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "zabbix_trigger_prototype" {
@@ -149,7 +155,7 @@ func testAccZabbixTriggerPrototypeConfig(groupName, templateName string) string
 					macro = "{#TESTMACRO}"
 					value = "^lo$"
 				}
-				eval_type = 0
+				eval_type = "and/or"
 			}
 		}
 
@@ -197,7 +203,7 @@ func testAccZabbixTriggerPrototypeUpdateConfig(groupName, templateName string) s
 					macro = "{#TESTMACRO}"
 					value = "^lo$"
 				}
-				eval_type = 0
+				eval_type = "and/or"
 			}
 		}
 
@@ -245,7 +251,7 @@ func testAccZabbixTriggerPrototypeDependenciesConfig(groupName, templateName str
 					macro = "{#TESTMACRO}"
 					value = "^lo$"
 				}
-				eval_type = 0
+				eval_type = "and/or"
 			}
 		}
 
@@ -301,7 +307,7 @@ func testAccZabbixTriggerPrototypeUpdateKeyConfig(groupName, templateName string
 					macro = "{#TESTMACRO}"
 					value = "^lo$"
 				}
-				eval_type = 0
+				eval_type = "and/or"
 			}
 		}
 
@@ -348,7 +354,7 @@ func testAccZabbixTriggerPrototypeUpdateKeyConfig2(groupName, templateName strin
 					macro = "{#TESTMACRO}"
 					value = "^lo$"
 				}
-				eval_type = 0
+				eval_type = "and/or"
 			}
 		}
 
@@ -373,7 +379,7 @@ func testAccZabbixTriggerPrototypeUpdateKeyConfig2(groupName, templateName strin
 
 func checkServerTriggerPrototypeDependencies() resource.TestCheckFunc {
 	return func(state *terraform.State) error {
-		api := testAccProvider.Meta().(*zabbix.API)
+		api := testAccProvider.Meta().(*zabbixClient)
 
 		trigger0, ok := state.RootModule().Resources["zabbix_trigger_prototype.trigger_prototype_test_0"]
 		if !ok {
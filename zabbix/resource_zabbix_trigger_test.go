@@ -29,6 +29,12 @@ func TestAccZabbixTrigger_Basic(t *testing.T) {
 					resource.TestCheckResourceAttr(resourceName, "status", "1"),
 				),
 			},
+			{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateId:     fmt.Sprintf("template_%s/trigger_%s", strID, strID),
+				ImportStateVerify: true,
+			},
 			{
 				Config: testAccZabbixTriggerSimpleConfigUpdate(strID),
 				Check: resource.ComposeAggregateTestCheckFunc(
@@ -109,7 +115,7 @@ func TestAccZabbixTrigger_BasicDependencies(t *testing.T) {
 }
 
 func testAccCheckZabbixTriggerDestroy(s *terraform.State) error {
-	api := testAccProvider.Meta().(*zabbix.API)
+	api := testAccProvider.Meta().(*zabbixClient)
 
 	for _, rs := range s.RootModule().Resources {
 		if rs.Type != "zabbix_trigger" {
@@ -249,9 +255,13 @@ func testAccZabbixTriggerMacroConfig(strID string) string {
 		host = "template_%s"
 		groups = ["${zabbix_template_group.template_group_test.name}"]
 		description = "description for template"
-		macro = {
-			MACRO_TRIGGER = "12m",
-			MACRO_UPDATE = "21m",
+		macro {
+			name  = "MACRO_TRIGGER"
+			value = "12m"
+		}
+		macro {
+			name  = "MACRO_UPDATE"
+			value = "21m"
 		}
 	  }
 
@@ -290,9 +300,13 @@ func testAccZabbixTriggerMacroConfigUpdate(strID string) string {
 		host = "template_%s"
 		groups = ["${zabbix_template_group.template_group_test.name}"]
 		description = "description for template"
-		macro = {
-			MACRO_TRIGGER = "12m",
-			MACRO_UPDATE = "21m",
+		macro {
+			name  = "MACRO_TRIGGER"
+			value = "12m"
+		}
+		macro {
+			name  = "MACRO_UPDATE"
+			value = "21m"
 		}
 	  }
 
@@ -0,0 +1,426 @@
+package zabbix
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/claranet/go-zabbix-api"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+type userMacro struct {
+	GlobalMacroID string `json:"globalmacroid,omitempty"`
+	HostMacroID   string `json:"hostmacroid,omitempty"`
+	HostID        string `json:"hostid,omitempty"`
+	Macro         string `json:"macro"`
+	Value         string `json:"value"`
+	Type          string `json:"type"`
+	Description   string `json:"description"`
+}
+
+// hostMacro is a macro sent along with a host or a template
+type hostMacro struct {
+	HostMacroID string  `json:"hostmacroid,omitempty"`
+	Macro       string  `json:"macro"`
+	Value       *string `json:"value,omitempty"`
+	Type        string  `json:"type,omitempty"`
+	Description string  `json:"description,omitempty"`
+}
+
+var StringMacroTypeMap = map[string]string{
+	"text":   "0",
+	"secret": "1",
+	"vault":  "2",
+}
+
+var MacroTypeStringMap = map[string]string{
+	"0": "text",
+	"1": "secret",
+	"2": "vault",
+}
+
+func resourceZabbixUserMacro() *schema.Resource {
+	macroSchema := schemaUserMacro()
+	macroSchema["host_id"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Required:    true,
+		ForceNew:    true,
+		Description: "ID of the host or template that the macro belongs to.",
+	}
+
+	return &schema.Resource{
+		CreateContext: resourceZabbixUserMacroCreate,
+		ReadContext:   resourceZabbixUserMacroRead,
+		UpdateContext: resourceZabbixUserMacroUpdate,
+		DeleteContext: resourceZabbixUserMacroDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		CustomizeDiff: customizeDiffVersion(
+			requiresVersion("4.4", "description", ""),
+			requiresVersion("5.0", "type", "text"),
+		),
+		Schema: macroSchema,
+	}
+}
+
+func schemaUserMacro() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"name": &schema.Schema{
+			Type:        schema.TypeString,
+			Required:    true,
+			Description: "Name of the macro, without the surrounding {$ and }.",
+		},
+		"value": &schema.Schema{
+			Type:        schema.TypeString,
+			Required:    true,
+			Sensitive:   true,
+			Description: "Value of the macro. Secret values are never read back from Zabbix.",
+		},
+		"type": &schema.Schema{
+			Type:     schema.TypeString,
+			Optional: true,
+			Default:  "text",
+			ValidateFunc: validation.StringInSlice(
+				[]string{"text", "secret", "vault"},
+				false,
+			),
+			Description: "Type of the macro.",
+		},
+		"description": &schema.Schema{
+			Type:        schema.TypeString,
+			Optional:    true,
+			Default:     "",
+			Description: "Description of the macro.",
+		},
+	}
+}
+
+// createUserMacroParams only sends type and description to servers that know about them
+func createUserMacroParams(d *schema.ResourceData, api *zabbixClient) (zabbix.Params, error) {
+	params := zabbix.Params{
+		"macro": fmt.Sprintf("{$%s}", d.Get("name").(string)),
+		"value": d.Get("value").(string),
+	}
+
+	if api.ServerVersion.GreaterThanOrEqual(version.Must(version.NewVersion("4.4"))) {
+		params["description"] = d.Get("description").(string)
+	} else if d.Get("description").(string) != "" {
+		return nil, fmt.Errorf("Macro description requires Zabbix 4.4 or later")
+	}
+
+	macroType := d.Get("type").(string)
+	if api.ServerVersion.GreaterThanOrEqual(version.Must(version.NewVersion("5.0"))) {
+		params["type"] = StringMacroTypeMap[macroType]
+	} else if macroType != "text" {
+		return nil, fmt.Errorf("Macro type %s requires Zabbix 5.0 or later", macroType)
+	}
+	return params, nil
+}
+
+func setTerraformUserMacro(d *schema.ResourceData, macro userMacro) error {
+	name, err := trimMacroName(macro.Macro)
+	if err != nil {
+		return err
+	}
+
+	macroType := "text"
+	if macro.Type != "" {
+		macroType = MacroTypeStringMap[macro.Type]
+	}
+
+	d.Set("name", name)
+	d.Set("type", macroType)
+	d.Set("description", macro.Description)
+	// The API never returns the value of secret macros
+	if macroType != "secret" {
+		d.Set("value", macro.Value)
+	}
+	return nil
+}
+
+// trimMacroName turns a Zabbix macro like {$NAME} into the name used in the configuration
+func trimMacroName(macro string) (string, error) {
+	if !strings.HasPrefix(macro, "{$") || !strings.HasSuffix(macro, "}") {
+		return "", fmt.Errorf("Invalid macro name \"%s\"", macro)
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(macro, "{$"), "}"), nil
+}
+
+func schemaMacros(description string) *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Optional: true,
+		Computed: true,
+		// Allows macro = [] to remove every macro, omitting the blocks leaves them untouched
+		ConfigMode:  schema.SchemaConfigModeAttr,
+		Description: description,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"name": &schema.Schema{
+					Type:        schema.TypeString,
+					Required:    true,
+					Description: "Name of the macro, without the surrounding {$ and }.",
+				},
+				"value": &schema.Schema{
+					Type:             schema.TypeString,
+					Optional:         true,
+					Default:          "",
+					Sensitive:        true,
+					DiffSuppressFunc: suppressSecretMacroValueDiff,
+					Description:      "Value of the macro. Secret values are never read back from Zabbix.",
+				},
+				"type": &schema.Schema{
+					Type:     schema.TypeString,
+					Optional: true,
+					Default:  "text",
+					ValidateFunc: validation.StringInSlice(
+						[]string{"text", "secret", "vault"},
+						false,
+					),
+					Description: "Type of the macro.",
+				},
+				"description": &schema.Schema{
+					Type:        schema.TypeString,
+					Optional:    true,
+					Default:     "",
+					Description: "Description of the macro.",
+				},
+			},
+		},
+	}
+}
+
+func schemaMacrosV0() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeMap,
+		Elem:     &schema.Schema{Type: schema.TypeString},
+		Optional: true,
+		Computed: true,
+	}
+}
+
+// suppressSecretMacroValueDiff ignores the value of an existing secret macro when it is unknown, the API never returns it
+func suppressSecretMacroValueDiff(k, old, new string, d *schema.ResourceData) bool {
+	prefix := strings.TrimSuffix(k, "value")
+	if d.Get(prefix+"type").(string) != "secret" || old != "" {
+		return false
+	}
+	oldName, newName := d.GetChange(prefix + "name")
+	return oldName.(string) != "" && oldName.(string) == newName.(string)
+}
+
+// createZabbixMacros returns nil when the macros are unchanged, so that macros managed elsewhere are left untouched
+func createZabbixMacros(d *schema.ResourceData, api *zabbixClient) (*[]hostMacro, error) {
+	if !d.HasChange("macro") {
+		return nil, nil
+	}
+
+	description := api.ServerVersion.GreaterThanOrEqual(version.Must(version.NewVersion("4.4")))
+	types := api.ServerVersion.GreaterThanOrEqual(version.Must(version.NewVersion("5.0")))
+
+	// Secret macros whose value is unknown are updated by ID to keep their value
+	var existingIDs map[string]string
+	macros := []hostMacro{}
+	for _, m := range d.Get("macro").([]interface{}) {
+		terraformMacro := m.(map[string]interface{})
+		macroName := fmt.Sprintf("{$%s}", terraformMacro["name"].(string))
+		macroType := terraformMacro["type"].(string)
+		value := terraformMacro["value"].(string)
+
+		macro := hostMacro{
+			Macro: macroName,
+			Value: &value,
+		}
+		if description {
+			macro.Description = terraformMacro["description"].(string)
+		} else if terraformMacro["description"].(string) != "" {
+			return nil, fmt.Errorf("Macro description requires Zabbix 4.4 or later")
+		}
+		if types {
+			macro.Type = StringMacroTypeMap[macroType]
+		} else if macroType != "text" {
+			return nil, fmt.Errorf("Macro type %s requires Zabbix 5.0 or later", macroType)
+		}
+
+		if macroType == "secret" && value == "" && d.Id() != "" {
+			if existingIDs == nil {
+				existing, err := getUserMacros(api, zabbix.Params{
+					"output":  "extend",
+					"hostids": d.Id(),
+				})
+				if err != nil {
+					return nil, err
+				}
+				existingIDs = make(map[string]string, len(existing))
+				for _, e := range existing {
+					existingIDs[e.Macro] = e.HostMacroID
+				}
+			}
+			if id, ok := existingIDs[macroName]; ok {
+				macro.HostMacroID = id
+				macro.Value = nil
+			}
+		}
+		macros = append(macros, macro)
+	}
+	return &macros, nil
+}
+
+// createTerraformMacros keeps the order of the macros in the state and their secret values, which the API hides
+func createTerraformMacros(d *schema.ResourceData, macros []userMacro) ([]interface{}, error) {
+	stateIndex := map[string]int{}
+	stateValues := map[string]string{}
+	for i, m := range d.Get("macro").([]interface{}) {
+		terraformMacro := m.(map[string]interface{})
+		stateIndex[terraformMacro["name"].(string)] = i
+		stateValues[terraformMacro["name"].(string)] = terraformMacro["value"].(string)
+	}
+
+	terraformMacros := make([]map[string]interface{}, 0, len(macros))
+	for _, macro := range macros {
+		name, err := trimMacroName(macro.Macro)
+		if err != nil {
+			return nil, err
+		}
+
+		macroType := "text"
+		if macro.Type != "" {
+			macroType = MacroTypeStringMap[macro.Type]
+		}
+		value := macro.Value
+		if macroType == "secret" {
+			value = stateValues[name]
+		}
+
+		terraformMacros = append(terraformMacros, map[string]interface{}{
+			"name":        name,
+			"value":       value,
+			"type":        macroType,
+			"description": macro.Description,
+		})
+	}
+
+	sort.SliceStable(terraformMacros, func(i, j int) bool {
+		iIndex, iOk := stateIndex[terraformMacros[i]["name"].(string)]
+		jIndex, jOk := stateIndex[terraformMacros[j]["name"].(string)]
+		if iOk && jOk {
+			return iIndex < jIndex
+		}
+		if iOk != jOk {
+			return iOk
+		}
+		return terraformMacros[i]["name"].(string) < terraformMacros[j]["name"].(string)
+	})
+
+	result := make([]interface{}, len(terraformMacros))
+	for i, macro := range terraformMacros {
+		result[i] = macro
+	}
+	return result, nil
+}
+
+// resourceZabbixMacroStateUpgradeV0 turns the macro map of hosts and templates into macro blocks
+func resourceZabbixMacroStateUpgradeV0(ctx context.Context, rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	rawMacros, ok := rawState["macro"].(map[string]interface{})
+	if !ok {
+		rawState["macro"] = []interface{}{}
+		return rawState, nil
+	}
+
+	names := make([]string, 0, len(rawMacros))
+	for name := range rawMacros {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	macros := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		value, _ := rawMacros[name].(string)
+		macros = append(macros, map[string]interface{}{
+			"name":        name,
+			"value":       value,
+			"type":        "text",
+			"description": "",
+		})
+	}
+	rawState["macro"] = macros
+	return rawState, nil
+}
+
+func getUserMacros(api *zabbixClient, params zabbix.Params) ([]userMacro, error) {
+	var macros []userMacro
+	err := api.CallWithErrorParse("usermacro.get", params, &macros)
+	return macros, err
+}
+
+func resourceZabbixUserMacroCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
+
+	params, err := createUserMacroParams(d, api)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	params["hostid"] = d.Get("host_id").(string)
+
+	response, err := api.CallWithError("usermacro.create", params)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	id, err := idFromResponse(response, "hostmacroids")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	log.Printf("[DEBUG] Created user macro, id is %s", id)
+
+	d.SetId(id)
+	return resourceZabbixUserMacroRead(ctx, d, meta)
+}
+
+func resourceZabbixUserMacroRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
+
+	macros, err := getUserMacros(api, zabbix.Params{
+		"output":       "extend",
+		"hostmacroids": d.Id(),
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err := expectOne("user macro", d.Id(), len(macros)); err != nil {
+		return readError(d, err)
+	}
+
+	d.Set("host_id", macros[0].HostID)
+	return diag.FromErr(setTerraformUserMacro(d, macros[0]))
+}
+
+func resourceZabbixUserMacroUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
+
+	params, err := createUserMacroParams(d, api)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	params["hostmacroid"] = d.Id()
+
+	_, err = api.CallWithError("usermacro.update", params)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	return resourceZabbixUserMacroRead(ctx, d, meta)
+}
+
+func resourceZabbixUserMacroDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
+
+	_, err := api.CallWithError("usermacro.delete", []string{d.Id()})
+	return diag.FromErr(err)
+}
@@ -0,0 +1,91 @@
+package zabbix
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/claranet/go-zabbix-api"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccZabbixUserMacro_Basic(t *testing.T) {
+	strID := acctest.RandString(5)
+	groupName := fmt.Sprintf("template_group_%s", strID)
+	templateName := fmt.Sprintf("template_%s", strID)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckZabbixUserMacroDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccZabbixUserMacroConfig(groupName, templateName, "text", "public"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrPair("zabbix_user_macro.macro_test", "host_id", "zabbix_template.template_test", "id"),
+					resource.TestCheckResourceAttr("zabbix_user_macro.macro_test", "name", "SNMP_COMMUNITY"),
+					resource.TestCheckResourceAttr("zabbix_user_macro.macro_test", "value", "public"),
+					resource.TestCheckResourceAttr("zabbix_user_macro.macro_test", "type", "text"),
+					resource.TestCheckResourceAttr("zabbix_user_macro.macro_test", "description", "community owned by the network team"),
+				),
+			},
+			{
+				Config: testAccZabbixUserMacroConfig(groupName, templateName, "secret", "private"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("zabbix_user_macro.macro_test", "value", "private"),
+					resource.TestCheckResourceAttr("zabbix_user_macro.macro_test", "type", "secret"),
+				),
+			},
+			{
+				ResourceName:            "zabbix_user_macro.macro_test",
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"value"},
+			},
+		},
+	})
+}
+
+func testAccCheckZabbixUserMacroDestroy(s *terraform.State) error {
+	api := testAccProvider.Meta().(*zabbixClient)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "zabbix_user_macro" {
+			continue
+		}
+
+		macros, err := getUserMacros(api, zabbix.Params{
+			"hostmacroids": rs.Primary.ID,
+		})
+		if err != nil {
+			return err
+		}
+		if len(macros) != 0 {
+			return fmt.Errorf("User macro still exist %s", rs.Primary.ID)
+		}
+	}
+	return nil
+}
+
+func testAccZabbixUserMacroConfig(groupName, templateName, macroType, value string) string {
+	return fmt.Sprintf(`
+		resource "zabbix_template_group" "zabbix" {
+			name = "%s"
+		}
+
+		resource "zabbix_template" "template_test" {
+			host = "%s"
+			groups = ["${zabbix_template_group.zabbix.name}"]
+			name = "display name %s"
+	  	}
+
+		resource "zabbix_user_macro" "macro_test" {
+			host_id = zabbix_template.template_test.id
+			name = "SNMP_COMMUNITY"
+			value = "%s"
+			type = "%s"
+			description = "community owned by the network team"
+		}
+	`, groupName, templateName, templateName, value, macroType)
+}
@@ -0,0 +1,291 @@
+package zabbix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/claranet/go-zabbix-api"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+type zabbixValueMap struct {
+	ValueMapID string            `json:"valuemapid,omitempty"`
+	Name       string            `json:"name"`
+	HostID     string            `json:"hostid,omitempty"`
+	Mappings   []valueMapMapping `json:"mappings"`
+}
+
+type valueMapMapping struct {
+	Type     string `json:"type,omitempty"`
+	Value    string `json:"value"`
+	NewValue string `json:"newvalue"`
+}
+
+var StringValueMapMappingTypeMap = map[string]string{
+	"equals":           "0",
+	"greater_or_equal": "1",
+	"less_or_equal":    "2",
+	"range":            "3",
+	"regex":            "4",
+	"default":          "5",
+}
+
+var ValueMapMappingTypeStringMap = map[string]string{
+	"0": "equals",
+	"1": "greater_or_equal",
+	"2": "less_or_equal",
+	"3": "range",
+	"4": "regex",
+	"5": "default",
+}
+
+func resourceZabbixValueMap() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceZabbixValueMapCreate,
+		ReadContext:   resourceZabbixValueMapRead,
+		UpdateContext: resourceZabbixValueMapUpdate,
+		DeleteContext: resourceZabbixValueMapDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+		CustomizeDiff: customizeDiffVersion(
+			requiredSince("5.4", "host_id"),
+			requiresVersion("5.4", "host_id", ""),
+			requiresVersion("6.0", "mapping.*.type", "equals"),
+		),
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of the value map.",
+			},
+			"host_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "ID of the host or template that the value map belongs to. Required on Zabbix 5.4 or later, not supported before.",
+			},
+			"mapping": &schema.Schema{
+				Type:        schema.TypeList,
+				Required:    true,
+				Description: "Value mappings, in the order they are evaluated.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"type": &schema.Schema{
+							Type:     schema.TypeString,
+							Optional: true,
+							Default:  "equals",
+							ValidateFunc: validation.StringInSlice(
+								[]string{"equals", "greater_or_equal", "less_or_equal", "range", "regex", "default"},
+								false,
+							),
+							Description: "Match type of the mapping. Types other than equals require Zabbix 6.0 or later.",
+						},
+						"value": &schema.Schema{
+							Type:        schema.TypeString,
+							Optional:    true,
+							Default:     "",
+							Description: "Original value, range or regular expression, depending on the type.",
+						},
+						"new_value": &schema.Schema{
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "Value to which the original value is mapped to.",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func createValueMapObject(d *schema.ResourceData, api *zabbixClient) (*zabbixValueMap, error) {
+	hostID := d.Get("host_id").(string)
+	hostLevel := api.ServerVersion.GreaterThanOrEqual(version.Must(version.NewVersion("5.4")))
+	if hostLevel && hostID == "" {
+		return nil, fmt.Errorf("host_id is required for value maps on Zabbix 5.4 or later")
+	}
+	if !hostLevel && hostID != "" {
+		return nil, fmt.Errorf("host_id is only supported for value maps on Zabbix 5.4 or later, value maps are global before")
+	}
+
+	mappingTypes := api.ServerVersion.GreaterThanOrEqual(version.Must(version.NewVersion("6.0")))
+	mappings := []valueMapMapping{}
+	for _, m := range d.Get("mapping").([]interface{}) {
+		mapping := m.(map[string]interface{})
+
+		object := valueMapMapping{
+			Value:    mapping["value"].(string),
+			NewValue: mapping["new_value"].(string),
+		}
+		if mappingTypes {
+			object.Type = StringValueMapMappingTypeMap[mapping["type"].(string)]
+		} else if mapping["type"].(string) != "equals" {
+			return nil, fmt.Errorf("Value map mapping type %s requires Zabbix 6.0 or later", mapping["type"].(string))
+		}
+		mappings = append(mappings, object)
+	}
+
+	return &zabbixValueMap{
+		Name:     d.Get("name").(string),
+		HostID:   hostID,
+		Mappings: mappings,
+	}, nil
+}
+
+func resourceZabbixValueMapCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
+
+	valueMap, err := createValueMapObject(d, api)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	response, err := api.CallWithError("valuemap.create", valueMap)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	id, err := idFromResponse(response, "valuemapids")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	log.Printf("[DEBUG] Created value map, id is %s", id)
+
+	d.SetId(id)
+	return resourceZabbixValueMapRead(ctx, d, meta)
+}
+
+func resourceZabbixValueMapRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
+
+	valueMap, err := getValueMapByID(api, d.Id())
+	if err != nil {
+		return readError(d, err)
+	}
+
+	var mappings []interface{}
+	for _, mapping := range valueMap.Mappings {
+		mappingType := "equals"
+		if mapping.Type != "" {
+			mappingType = ValueMapMappingTypeStringMap[mapping.Type]
+		}
+		mappings = append(mappings, map[string]interface{}{
+			"type":      mappingType,
+			"value":     mapping.Value,
+			"new_value": mapping.NewValue,
+		})
+	}
+
+	d.Set("name", valueMap.Name)
+	d.Set("host_id", valueMap.HostID)
+	d.Set("mapping", mappings)
+
+	log.Printf("[DEBUG] Value map name is %s\n", valueMap.Name)
+	return nil
+}
+
+func resourceZabbixValueMapUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
+
+	valueMap, err := createValueMapObject(d, api)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	valueMap.ValueMapID = d.Id()
+	// Read-only when updated
+	valueMap.HostID = ""
+
+	_, err = api.CallWithError("valuemap.update", valueMap)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	return resourceZabbixValueMapRead(ctx, d, meta)
+}
+
+func resourceZabbixValueMapDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	api := meta.(*zabbixClient)
+
+	_, err := api.CallWithError("valuemap.delete", []string{d.Id()})
+	return diag.FromErr(err)
+}
+
+// getValueMapByID returns the value map with the given ID or a notFoundError
+func getValueMapByID(api *zabbixClient, id string) (*zabbixValueMap, error) {
+	var valueMaps []zabbixValueMap
+	err := api.CallWithErrorParse("valuemap.get", zabbix.Params{
+		"output":         "extend",
+		"selectMappings": "extend",
+		"valuemapids":    id,
+	}, &valueMaps)
+	if err != nil {
+		return nil, err
+	}
+	if err := expectOne("value map", id, len(valueMaps)); err != nil {
+		return nil, err
+	}
+	return &valueMaps[0], nil
+}
+
+// getValueMapID resolves the valuemap argument of items, which is either a value map ID or its name. No ID is
+// returned when it is unset, unless a value map was set before and must be removed with the ID 0.
+func getValueMapID(d *schema.ResourceData, api *zabbixClient) (string, error) {
+	valueMapRef := d.Get("valuemap").(string)
+	if valueMapRef == "" {
+		if d.Id() != "" && d.HasChange("valuemap") {
+			return "0", nil
+		}
+		return "", nil
+	}
+	if _, err := strconv.Atoi(valueMapRef); err == nil {
+		return valueMapRef, nil
+	}
+
+	params := zabbix.Params{
+		"output": "extend",
+		"filter": map[string]interface{}{
+			"name": valueMapRef,
+		},
+	}
+	if api.ServerVersion.GreaterThanOrEqual(version.Must(version.NewVersion("5.4"))) {
+		params["hostids"] = d.Get("host_id").(string)
+	}
+
+	var valueMaps []zabbixValueMap
+	err := api.CallWithErrorParse("valuemap.get", params, &valueMaps)
+	if err != nil {
+		return "", err
+	}
+	if len(valueMaps) != 1 {
+		return "", fmt.Errorf("Expected one value map named %s and got %d value maps", valueMapRef, len(valueMaps))
+	}
+	return valueMaps[0].ValueMapID, nil
+}
+
+// getValueMapRef returns the value map of an item the same way it is configured, by ID or by name
+func getValueMapRef(api *zabbixClient, configured, valueMapID string) (string, error) {
+	if valueMapID == "" || valueMapID == "0" {
+		return "", nil
+	}
+	if _, err := strconv.Atoi(configured); configured == "" || err == nil {
+		return valueMapID, nil
+	}
+
+	valueMap, err := getValueMapByID(api, valueMapID)
+	var notFound *notFoundError
+	if errors.As(err, &notFound) {
+		// Clearing the value map plans to set it again, which fails if it doesn't exist anymore
+		log.Printf("[WARN] %s, clearing the value map of the item", err)
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return valueMap.Name, nil
+}
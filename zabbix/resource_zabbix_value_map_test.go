@@ -0,0 +1,200 @@
+package zabbix
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/claranet/go-zabbix-api"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccZabbixValueMap_Basic(t *testing.T) {
+	strID := acctest.RandString(5)
+	groupName := fmt.Sprintf("template_group_%s", strID)
+	templateName := fmt.Sprintf("template_%s", strID)
+	var valueMapID string
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckZabbixValueMapDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccZabbixValueMapConfig(groupName, templateName),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("zabbix_value_map.service_state", "name", "Service state"),
+					resource.TestCheckResourceAttrPair("zabbix_value_map.service_state", "host_id", "zabbix_template.template_test", "id"),
+					resource.TestCheckResourceAttr("zabbix_value_map.service_state", "mapping.#", "2"),
+					resource.TestCheckResourceAttr("zabbix_value_map.service_state", "mapping.0.type", "equals"),
+					resource.TestCheckResourceAttr("zabbix_value_map.service_state", "mapping.0.value", "0"),
+					resource.TestCheckResourceAttr("zabbix_value_map.service_state", "mapping.0.new_value", "Down"),
+					resource.TestCheckResourceAttr("zabbix_value_map.service_state", "mapping.1.value", "1"),
+					resource.TestCheckResourceAttr("zabbix_value_map.service_state", "mapping.1.new_value", "Up"),
+					resource.TestCheckResourceAttr("zabbix_item.item_test", "valuemap", "Service state"),
+					resource.TestCheckResourceAttrPair("zabbix_item.item_by_id_test", "valuemap", "zabbix_value_map.service_state", "id"),
+				),
+			},
+			{
+				Config: testAccZabbixValueMapUpdateConfig(groupName, templateName),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("zabbix_value_map.service_state", "name", "Service status"),
+					resource.TestCheckResourceAttr("zabbix_value_map.service_state", "mapping.#", "3"),
+					resource.TestCheckResourceAttr("zabbix_value_map.service_state", "mapping.0.type", "range"),
+					resource.TestCheckResourceAttr("zabbix_value_map.service_state", "mapping.0.value", "2-10"),
+					resource.TestCheckResourceAttr("zabbix_value_map.service_state", "mapping.1.type", "regex"),
+					resource.TestCheckResourceAttr("zabbix_value_map.service_state", "mapping.1.value", "^up"),
+					resource.TestCheckResourceAttr("zabbix_value_map.service_state", "mapping.2.type", "default"),
+					resource.TestCheckResourceAttr("zabbix_value_map.service_state", "mapping.2.new_value", "Unknown"),
+					resource.TestCheckResourceAttr("zabbix_item.item_test", "valuemap", "Service status"),
+					resource.TestCheckResourceAttr("zabbix_item.item_by_id_test", "valuemap", ""),
+					testAccCheckValueMapID("zabbix_value_map.service_state", &valueMapID),
+				),
+			},
+			{
+				// The items referencing a value map deleted outside of Terraform are updated once it is created again
+				PreConfig: testAccZabbixValueMapDelete(t, &valueMapID),
+				Config:    testAccZabbixValueMapUpdateConfig(groupName, templateName),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("zabbix_item.item_test", "valuemap", "Service status"),
+				),
+			},
+			{
+				ResourceName:      "zabbix_value_map.service_state",
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testAccCheckValueMapID(n string, valueMapID *string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Not found: %s", n)
+		}
+		*valueMapID = rs.Primary.ID
+		return nil
+	}
+}
+
+func testAccZabbixValueMapDelete(t *testing.T, valueMapID *string) func() {
+	return func() {
+		api := testAccProvider.Meta().(*zabbixClient)
+		if _, err := api.CallWithError("valuemap.delete", []string{*valueMapID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func testAccCheckZabbixValueMapDestroy(s *terraform.State) error {
+	api := testAccProvider.Meta().(*zabbixClient)
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "zabbix_value_map" {
+			continue
+		}
+
+		_, err := getValueMapByID(api, rs.Primary.ID)
+		if err == nil {
+			return fmt.Errorf("Value map still exist %s", rs.Primary.ID)
+		}
+		var notFound *notFoundError
+		if !errors.As(err, &notFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+func testAccZabbixValueMapTemplateConfig(groupName, templateName string) string {
+	return fmt.Sprintf(`
+		resource "zabbix_template_group" "zabbix" {
+			name = "%s"
+		}
+
+		resource "zabbix_template" "template_test" {
+			host = "%s"
+			groups = ["${zabbix_template_group.zabbix.name}"]
+			name = "display name %s"
+	  	}
+	`, groupName, templateName, templateName)
+}
+
+func testAccZabbixValueMapConfig(groupName, templateName string) string {
+	return testAccZabbixValueMapTemplateConfig(groupName, templateName) + `
+		resource "zabbix_value_map" "service_state" {
+			name = "Service state"
+			host_id = zabbix_template.template_test.id
+			mapping {
+				value = "0"
+				new_value = "Down"
+			}
+			mapping {
+				value = "1"
+				new_value = "Up"
+			}
+		}
+
+		resource "zabbix_item" "item_test" {
+			name = "service state"
+			key = "service.state"
+			delay = "60"
+			value_type = 3
+			host_id = zabbix_template.template_test.id
+			valuemap = zabbix_value_map.service_state.name
+		}
+
+		resource "zabbix_item" "item_by_id_test" {
+			name = "service state by id"
+			key = "service.state.id"
+			delay = "60"
+			value_type = 3
+			host_id = zabbix_template.template_test.id
+			valuemap = zabbix_value_map.service_state.id
+		}
+	`
+}
+
+func testAccZabbixValueMapUpdateConfig(groupName, templateName string) string {
+	return testAccZabbixValueMapTemplateConfig(groupName, templateName) + `
+		resource "zabbix_value_map" "service_state" {
+			name = "Service status"
+			host_id = zabbix_template.template_test.id
+			mapping {
+				type = "range"
+				value = "2-10"
+				new_value = "Degraded"
+			}
+			mapping {
+				type = "regex"
+				value = "^up"
+				new_value = "Up"
+			}
+			mapping {
+				type = "default"
+				new_value = "Unknown"
+			}
+		}
+
+		resource "zabbix_item" "item_test" {
+			name = "service state"
+			key = "service.state"
+			delay = "60"
+			value_type = 3
+			host_id = zabbix_template.template_test.id
+			valuemap = zabbix_value_map.service_state.name
+		}
+
+		resource "zabbix_item" "item_by_id_test" {
+			name = "service state by id"
+			key = "service.state.id"
+			delay = "60"
+			value_type = 3
+			host_id = zabbix_template.template_test.id
+		}
+	`
+}
@@ -0,0 +1,699 @@
+package zabbix
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+type triggerTokenKind int
+
+const (
+	triggerTokenEnd triggerTokenKind = iota
+	triggerTokenValue
+	triggerTokenLegacyFunction
+	triggerTokenName
+	triggerTokenOperator
+	triggerTokenOpen
+	triggerTokenClose
+	triggerTokenComma
+)
+
+// triggerToken is a token of a trigger expression, position being its offset in the expression
+type triggerToken struct {
+	kind     triggerTokenKind
+	text     string
+	position int
+	// function is the function of legacy tokens, {host:key.function(parameters)} being a single token
+	function *triggerExpression
+}
+
+func (t triggerToken) String() string {
+	if t.kind == triggerTokenEnd {
+		return "end of expression"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+type triggerNodeKind int
+
+const (
+	// triggerNodeValue are numbers, strings and macros, kept as written
+	triggerNodeValue triggerNodeKind = iota
+	triggerNodeFunction
+	triggerNodeUnary
+	triggerNodeBinary
+)
+
+// triggerExpression is a node of a parsed trigger expression
+type triggerExpression struct {
+	kind triggerNodeKind
+	// value is the text of values, the operator of unary and binary nodes, or the name of functions
+	value string
+	// host, key and filter are the item of history functions, empty for the other functions
+	host, key, filter string
+	// legacy is whether the function uses the {host:key.function(parameters)} syntax of Zabbix before 5.4
+	legacy     bool
+	parameters []triggerParameter
+	operands   []*triggerExpression
+}
+
+// triggerParameter is a parameter of a function as written, parsed as an expression when it is one
+type triggerParameter struct {
+	text       string
+	expression *triggerExpression
+}
+
+// triggerOperatorPrecedence of the binary operators of trigger expressions, unary - and not binding tighter
+var triggerOperatorPrecedence = map[string]int{
+	"or":  1,
+	"and": 2,
+	"=":   3,
+	"<>":  3,
+	"<":   4,
+	"<=":  4,
+	">":   4,
+	">=":  4,
+	"+":   5,
+	"-":   5,
+	"*":   6,
+	"/":   6,
+}
+
+// triggerLexer splits a trigger expression in tokens. Parameters of functions are read by the parser with
+// rawParameter, as they can be periods like #3 or 1h:now/d that are not expressions.
+type triggerLexer struct {
+	input string
+	pos   int
+	// offset is added to positions in errors, for the expressions parsed out of parameters
+	offset int
+}
+
+func (l *triggerLexer) errorf(position int, format string, args ...interface{}) error {
+	return fmt.Errorf("%s at position %d", fmt.Sprintf(format, args...), l.offset+position+1)
+}
+
+func (l *triggerLexer) skipSpaces() {
+	for l.pos < len(l.input) && strings.ContainsRune(" \t\r\n", rune(l.input[l.pos])) {
+		l.pos++
+	}
+}
+
+func isTriggerDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isTriggerLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
+
+func isItemKeyCharacter(c byte) bool {
+	return isTriggerLetter(c) || isTriggerDigit(c) || c == '.' || c == '-' || c == '*'
+}
+
+func (l *triggerLexer) next() (triggerToken, error) {
+	l.skipSpaces()
+	start := l.pos
+	if start >= len(l.input) {
+		return triggerToken{kind: triggerTokenEnd, position: start}, nil
+	}
+
+	token := func(kind triggerTokenKind, end int) (triggerToken, error) {
+		l.pos = end
+		return triggerToken{kind: kind, text: l.input[start:end], position: start}, nil
+	}
+
+	c := l.input[start]
+	switch {
+	case c == '(':
+		return token(triggerTokenOpen, start+1)
+	case c == ')':
+		return token(triggerTokenClose, start+1)
+	case c == ',':
+		return token(triggerTokenComma, start+1)
+	case strings.ContainsRune("+-*/=", rune(c)):
+		return token(triggerTokenOperator, start+1)
+	case c == '<' || c == '>':
+		if start+1 < len(l.input) && (l.input[start+1] == '=' || c == '<' && l.input[start+1] == '>') {
+			return token(triggerTokenOperator, start+2)
+		}
+		return token(triggerTokenOperator, start+1)
+	case c == '"':
+		end, err := l.quoted(start)
+		if err != nil {
+			return triggerToken{}, err
+		}
+		return token(triggerTokenValue, end)
+	case c == '{':
+		return l.brace()
+	case isTriggerDigit(c) || c == '.' && start+1 < len(l.input) && isTriggerDigit(l.input[start+1]):
+		return token(triggerTokenValue, l.number(start))
+	case isTriggerLetter(c):
+		end := start
+		for end < len(l.input) && (isTriggerLetter(l.input[end]) || isTriggerDigit(l.input[end])) {
+			end++
+		}
+		switch l.input[start:end] {
+		case "and", "or", "not":
+			return token(triggerTokenOperator, end)
+		}
+		return token(triggerTokenName, end)
+	}
+	return triggerToken{}, l.errorf(start, "unexpected character %q", c)
+}
+
+// quoted returns the end of the string starting at start, where quotes are escaped with a backslash
+func (l *triggerLexer) quoted(start int) (int, error) {
+	for i := start + 1; i < len(l.input); i++ {
+		switch l.input[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, nil
+		}
+	}
+	return 0, l.errorf(start, "unterminated string")
+}
+
+// number returns the end of the number starting at start, with an optional exponent and unit suffix
+func (l *triggerLexer) number(start int) int {
+	end := start
+	for end < len(l.input) && (isTriggerDigit(l.input[end]) || l.input[end] == '.') {
+		end++
+	}
+	if end+1 < len(l.input) && (l.input[end] == 'e' || l.input[end] == 'E') {
+		exponent := end + 1
+		if exponent < len(l.input) && (l.input[exponent] == '+' || l.input[exponent] == '-') {
+			exponent++
+		}
+		if exponent < len(l.input) && isTriggerDigit(l.input[exponent]) {
+			for end = exponent; end < len(l.input) && isTriggerDigit(l.input[end]); end++ {
+			}
+		}
+	}
+	if end < len(l.input) && strings.ContainsRune("KMGTsmhdw", rune(l.input[end])) {
+		end++
+	}
+	return end
+}
+
+// brace reads the macros, like {$MACRO}, {#MACRO} or {TRIGGER.VALUE}, and the legacy functions starting at a {
+func (l *triggerLexer) brace() (triggerToken, error) {
+	start := l.pos
+	if start+1 < len(l.input) && strings.ContainsRune("$#{", rune(l.input[start+1])) {
+		depth := 0
+		for i := start; i < len(l.input); i++ {
+			switch l.input[i] {
+			case '"':
+				end, err := l.quoted(i)
+				if err != nil {
+					return triggerToken{}, err
+				}
+				i = end - 1
+			case '{':
+				depth++
+			case '}':
+				depth--
+				if depth == 0 {
+					l.pos = i + 1
+					return triggerToken{kind: triggerTokenValue, text: l.input[start:l.pos], position: start}, nil
+				}
+			}
+		}
+		return triggerToken{}, l.errorf(start, "unterminated macro")
+	}
+
+	colon := strings.IndexAny(l.input[start:], ":}")
+	if colon < 0 {
+		return triggerToken{}, l.errorf(start, "unterminated macro")
+	}
+	if l.input[start+colon] == '}' {
+		// Built-in macros and the IDs of functions in the expressions returned by the API
+		l.pos = start + colon + 1
+		return triggerToken{kind: triggerTokenValue, text: l.input[start:l.pos], position: start}, nil
+	}
+
+	function, err := l.legacyFunction(start, start+colon)
+	if err != nil {
+		return triggerToken{}, err
+	}
+	return triggerToken{kind: triggerTokenLegacyFunction, text: l.input[start:l.pos], position: start, function: function}, nil
+}
+
+// legacyFunction reads a {host:key.function(parameters)} function, colon being the position of the colon
+func (l *triggerLexer) legacyFunction(start, colon int) (*triggerExpression, error) {
+	function := &triggerExpression{kind: triggerNodeFunction, host: l.input[start+1 : colon], legacy: true}
+	if function.host == "" {
+		return nil, l.errorf(start+1, "missing host")
+	}
+
+	keyStart := colon + 1
+	end, err := l.itemKey(keyStart)
+	if err != nil {
+		return nil, err
+	}
+	if end < len(l.input) && l.input[end] == '(' && l.input[end-1] != ']' {
+		// Without parameters, the key and the function are only separated by the last dot
+		dot := strings.LastIndex(l.input[keyStart:end], ".")
+		if dot <= 0 {
+			return nil, l.errorf(keyStart, "missing function")
+		}
+		function.key, function.value = l.input[keyStart:keyStart+dot], l.input[keyStart+dot+1:end]
+	} else {
+		if end >= len(l.input) || l.input[end] != '.' {
+			return nil, l.errorf(end, "expected a function after the item key")
+		}
+		function.key = l.input[keyStart:end]
+		nameStart := end + 1
+		for end = nameStart; end < len(l.input) && isTriggerLetter(l.input[end]); end++ {
+		}
+		function.value = l.input[nameStart:end]
+	}
+	if function.key == "" || function.value == "" {
+		return nil, l.errorf(keyStart, "missing item key or function")
+	}
+	if end >= len(l.input) || l.input[end] != '(' {
+		return nil, l.errorf(end, "expected ( after the function %s", function.value)
+	}
+
+	l.pos = end + 1
+	for {
+		parameter, err := l.rawParameter()
+		if err != nil {
+			return nil, err
+		}
+		function.parameters = append(function.parameters, triggerParameter{text: strings.TrimSpace(parameter)})
+		if l.input[l.pos] == ')' {
+			break
+		}
+		l.pos++
+	}
+	if len(function.parameters) == 1 && function.parameters[0].text == "" {
+		function.parameters = nil
+	}
+	l.pos++
+	if l.pos >= len(l.input) || l.input[l.pos] != '}' {
+		return nil, l.errorf(l.pos, "expected } after the function %s", function.value)
+	}
+	l.pos++
+	return function, nil
+}
+
+// itemKey returns the end of the item key starting at start, including its parameters between brackets
+func (l *triggerLexer) itemKey(start int) (int, error) {
+	end := start
+	for end < len(l.input) && isItemKeyCharacter(l.input[end]) {
+		end++
+	}
+	if end == start {
+		return 0, l.errorf(start, "missing item key")
+	}
+	if end >= len(l.input) || l.input[end] != '[' {
+		return end, nil
+	}
+
+	depth := 0
+	for i := end; i < len(l.input); i++ {
+		switch l.input[i] {
+		case '"':
+			quoteEnd, err := l.quoted(i)
+			if err != nil {
+				return 0, err
+			}
+			i = quoteEnd - 1
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return i + 1, nil
+			}
+		}
+	}
+	return 0, l.errorf(end, "unterminated item key parameters")
+}
+
+// rawParameter reads a function parameter up to the next , or ) outside of quotes and parentheses, and stops
+// before it
+func (l *triggerLexer) rawParameter() (string, error) {
+	start := l.pos
+	depth := 0
+	for i := start; i < len(l.input); i++ {
+		switch l.input[i] {
+		case '"':
+			end, err := l.quoted(i)
+			if err != nil {
+				return "", err
+			}
+			i = end - 1
+		case '(', '[':
+			depth++
+		case ']':
+			depth--
+		case ')':
+			if depth == 0 {
+				l.pos = i
+				return l.input[start:i], nil
+			}
+			depth--
+		case ',':
+			if depth == 0 {
+				l.pos = i
+				return l.input[start:i], nil
+			}
+		}
+	}
+	return "", l.errorf(start, "unterminated function parameters")
+}
+
+// normalizeTriggerParameter trims a parameter and removes the quotes it does not need, since Zabbix reads
+// "5m" and 5m alike
+func normalizeTriggerParameter(parameter string) string {
+	parameter = strings.TrimSpace(parameter)
+	if len(parameter) < 2 || parameter[0] != '"' || parameter[len(parameter)-1] != '"' {
+		return parameter
+	}
+	unquoted := strings.ReplaceAll(parameter[1:len(parameter)-1], `\"`, `"`)
+	if unquoted == "" || strings.ContainsAny(unquoted, `",)\ `) || unquoted != strings.TrimSpace(unquoted) {
+		return parameter
+	}
+	return unquoted
+}
+
+// triggerParser parses trigger expressions written in the syntax of Zabbix before or after 5.4, or mixing both
+type triggerParser struct {
+	lexer     *triggerLexer
+	lookahead *triggerToken
+}
+
+func (p *triggerParser) peek() (triggerToken, error) {
+	if p.lookahead == nil {
+		token, err := p.lexer.next()
+		if err != nil {
+			return token, err
+		}
+		p.lookahead = &token
+	}
+	return *p.lookahead, nil
+}
+
+func (p *triggerParser) next() (triggerToken, error) {
+	token, err := p.peek()
+	p.lookahead = nil
+	return token, err
+}
+
+// parseTriggerExpression parses a trigger expression, errors giving the position of the problem
+func parseTriggerExpression(expression string) (*triggerExpression, error) {
+	return parseTriggerExpressionAt(expression, 0)
+}
+
+func parseTriggerExpressionAt(expression string, offset int) (*triggerExpression, error) {
+	p := &triggerParser{lexer: &triggerLexer{input: expression, offset: offset}}
+	node, err := p.expression(1)
+	if err != nil {
+		return nil, err
+	}
+	token, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if token.kind != triggerTokenEnd {
+		return nil, p.lexer.errorf(token.position, "unexpected %s", token)
+	}
+	return node, nil
+}
+
+// expression parses the binary operations of at least the given precedence, operators being left associative
+func (p *triggerParser) expression(precedence int) (*triggerExpression, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		token, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		operatorPrecedence, ok := triggerOperatorPrecedence[token.text]
+		if token.kind != triggerTokenOperator || !ok || operatorPrecedence < precedence {
+			return left, nil
+		}
+		p.next()
+		right, err := p.expression(operatorPrecedence + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &triggerExpression{kind: triggerNodeBinary, value: token.text, operands: []*triggerExpression{left, right}}
+	}
+}
+
+func (p *triggerParser) unary() (*triggerExpression, error) {
+	token, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+	if token.kind == triggerTokenOperator && (token.text == "-" || token.text == "not") {
+		p.next()
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &triggerExpression{kind: triggerNodeUnary, value: token.text, operands: []*triggerExpression{operand}}, nil
+	}
+	return p.primary()
+}
+
+func (p *triggerParser) primary() (*triggerExpression, error) {
+	token, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token.kind {
+	case triggerTokenValue:
+		return &triggerExpression{kind: triggerNodeValue, value: token.text}, nil
+	case triggerTokenLegacyFunction:
+		return token.function, nil
+	case triggerTokenOpen:
+		node, err := p.expression(1)
+		if err != nil {
+			return nil, err
+		}
+		closing, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if closing.kind != triggerTokenClose {
+			return nil, p.lexer.errorf(closing.position, "expected ) instead of %s", closing)
+		}
+		return node, nil
+	case triggerTokenName:
+		open, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if open.kind != triggerTokenOpen {
+			return nil, p.lexer.errorf(open.position, "expected ( after the function %s", token.text)
+		}
+		return p.function(token.text)
+	}
+	return nil, p.lexer.errorf(token.position, "unexpected %s", token)
+}
+
+// function parses the parameters of a function(/host/key,parameters) function, after its opening parenthesis
+func (p *triggerParser) function(name string) (*triggerExpression, error) {
+	l := p.lexer
+	function := &triggerExpression{kind: triggerNodeFunction, value: name}
+
+	l.skipSpaces()
+	if l.pos < len(l.input) && l.input[l.pos] == '/' {
+		if err := p.itemQuery(function); err != nil {
+			return nil, err
+		}
+		l.skipSpaces()
+		if l.pos < len(l.input) && l.input[l.pos] == ',' {
+			l.pos++
+		} else if l.pos >= len(l.input) || l.input[l.pos] != ')' {
+			return nil, l.errorf(l.pos, "expected , or ) after the item of the function %s", name)
+		}
+	}
+
+	for l.pos < len(l.input) && l.input[l.pos] != ')' || function.host == "" && len(function.parameters) == 0 {
+		start := l.pos
+		raw, err := l.rawParameter()
+		if err != nil {
+			return nil, err
+		}
+		parameter := triggerParameter{text: strings.TrimSpace(raw)}
+		if strings.TrimSpace(raw) != "" {
+			if expression, err := parseTriggerExpressionAt(raw, l.offset+start); err == nil && expression.kind != triggerNodeValue {
+				parameter.expression = expression
+			}
+		}
+		function.parameters = append(function.parameters, parameter)
+		if l.input[l.pos] == ',' {
+			l.pos++
+		}
+	}
+	if len(function.parameters) == 1 && function.parameters[0].text == "" && function.parameters[0].expression == nil {
+		function.parameters = nil
+	}
+	if l.pos >= len(l.input) {
+		return nil, l.errorf(l.pos, "unterminated function %s", name)
+	}
+	l.pos++
+	return function, nil
+}
+
+// itemQuery parses the /host/key?[filter] item of a history function
+func (p *triggerParser) itemQuery(function *triggerExpression) error {
+	l := p.lexer
+	hostStart := l.pos + 1
+	hostEnd := strings.IndexAny(l.input[hostStart:], "/,)")
+	if hostEnd < 0 || l.input[hostStart+hostEnd] != '/' {
+		return l.errorf(hostStart, "expected /host/key")
+	}
+	function.host = l.input[hostStart : hostStart+hostEnd]
+
+	keyStart := hostStart + hostEnd + 1
+	end, err := l.itemKey(keyStart)
+	if err != nil {
+		return err
+	}
+	function.key = l.input[keyStart:end]
+	if strings.HasPrefix(l.input[end:], "?[") {
+		filterEnd, err := l.brackets(end + 1)
+		if err != nil {
+			return err
+		}
+		function.filter = l.input[end:filterEnd]
+		end = filterEnd
+	}
+	if function.host == "" && function.key == "" {
+		return l.errorf(hostStart, "missing item")
+	}
+	l.pos = end
+	return nil
+}
+
+// brackets returns the end of the balanced brackets starting at start
+func (l *triggerLexer) brackets(start int) (int, error) {
+	depth := 0
+	for i := start; i < len(l.input); i++ {
+		switch l.input[i] {
+		case '"':
+			end, err := l.quoted(i)
+			if err != nil {
+				return 0, err
+			}
+			i = end - 1
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return i + 1, nil
+			}
+		}
+	}
+	return 0, l.errorf(start, "unterminated filter")
+}
+
+// String formats the expression the same way whatever the whitespace, parentheses or parameter quoting it was
+// written with
+func (e *triggerExpression) String() string {
+	return e.format(true)
+}
+
+// source formats the expression to be sent to Zabbix, with its parameters as written
+func (e *triggerExpression) source() string {
+	return e.format(false)
+}
+
+// format formats the expression without whitespace nor redundant parentheses, normalize removing the quotes that
+// parameters do not need
+func (e *triggerExpression) format(normalize bool) string {
+	switch e.kind {
+	case triggerNodeUnary:
+		operand := e.operands[0].format(normalize)
+		if e.operands[0].kind == triggerNodeBinary {
+			operand = "(" + operand + ")"
+		}
+		if e.value == "not" {
+			return "not " + operand
+		}
+		return e.value + operand
+	case triggerNodeBinary:
+		precedence := triggerOperatorPrecedence[e.value]
+		left, right := e.operands[0].format(normalize), e.operands[1].format(normalize)
+		if e.operands[0].kind == triggerNodeBinary && triggerOperatorPrecedence[e.operands[0].value] < precedence {
+			left = "(" + left + ")"
+		}
+		if e.operands[1].kind == triggerNodeBinary && triggerOperatorPrecedence[e.operands[1].value] <= precedence {
+			right = "(" + right + ")"
+		}
+		if e.value == "and" || e.value == "or" {
+			return left + " " + e.value + " " + right
+		}
+		return left + e.value + right
+	case triggerNodeFunction:
+		parameters := make([]string, len(e.parameters))
+		for i, parameter := range e.parameters {
+			switch {
+			case parameter.expression != nil:
+				parameters[i] = parameter.expression.format(normalize)
+			case normalize:
+				parameters[i] = normalizeTriggerParameter(parameter.text)
+			default:
+				parameters[i] = parameter.text
+			}
+		}
+		if e.legacy {
+			return fmt.Sprintf("{%s:%s.%s(%s)}", e.host, e.key, e.value, strings.Join(parameters, ","))
+		}
+		if e.host != "" || e.key != "" {
+			parameters = append([]string{"/" + e.host + "/" + e.key + e.filter}, parameters...)
+		}
+		return fmt.Sprintf("%s(%s)", e.value, strings.Join(parameters, ","))
+	}
+	return e.value
+}
+
+// triggerExpressionsMatch is whether the expression configured only differs by its formatting from the one of
+// the server, once translated to the syntax of the server when translate is set
+func triggerExpressionsMatch(server, configured string, translate bool) bool {
+	if server == configured {
+		return true
+	}
+	parsedServer, err := parseTriggerExpression(server)
+	if err != nil {
+		return false
+	}
+	parsedConfigured, err := parseTriggerExpression(configured)
+	if err != nil {
+		return false
+	}
+	if legacy, ok := parsedServer.legacySyntax(); ok && translate {
+		if translated, err := parsedConfigured.translate(!legacy); err == nil {
+			parsedConfigured = translated
+		}
+	}
+	return parsedServer.String() == parsedConfigured.String()
+}
+
+// suppressTriggerExpressionDiff ignores the differences of whitespace, parentheses or quoting of parameters
+// between the configured expression and the one rebuilt from the functions returned by Zabbix, and the
+// differences of syntax when expression_syntax is auto
+func suppressTriggerExpressionDiff(k, old, new string, d *schema.ResourceData) bool {
+	return old != "" && new != "" && triggerExpressionsMatch(old, new, d.Get("expression_syntax").(string) == "auto")
+}
+
+// validateTriggerExpression reports the syntax errors of expressions when planning
+func validateTriggerExpression(v interface{}, k string) (warns []string, errs []error) {
+	if _, err := parseTriggerExpression(v.(string)); err != nil {
+		errs = append(errs, fmt.Errorf("%q is not a valid trigger expression: %s", k, err))
+	}
+	return
+}
@@ -0,0 +1,392 @@
+package zabbix
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// triggerSyntaxVersion is the version of Zabbix replacing {host:key.function(parameters)} functions in trigger
+// expressions with function(/host/key,parameters)
+const triggerSyntaxVersion = "5.4"
+
+// triggerClockFunctions are the functions that do not read the history of an item, that legacy expressions still
+// attach to one
+var triggerClockFunctions = map[string]bool{
+	"date":       true,
+	"dayofmonth": true,
+	"dayofweek":  true,
+	"now":        true,
+	"time":       true,
+}
+
+// legacySyntax returns whether the functions of the expression use the syntax of Zabbix before 5.4, ok being false
+// when the expression has no history function telling the syntax
+func (e *triggerExpression) legacySyntax() (legacy, ok bool) {
+	if item := e.firstItem(); item != nil {
+		return item.legacy, true
+	}
+	return false, false
+}
+
+// firstItem returns the first history function of the expression
+func (e *triggerExpression) firstItem() *triggerExpression {
+	if e.kind == triggerNodeFunction && e.host != "" {
+		return e
+	}
+	for _, operand := range e.operands {
+		if item := operand.firstItem(); item != nil {
+			return item
+		}
+	}
+	for _, parameter := range e.parameters {
+		if parameter.expression != nil {
+			if item := parameter.expression.firstItem(); item != nil {
+				return item
+			}
+		}
+	}
+	return nil
+}
+
+// translate returns the expression with its functions in the syntax of Zabbix 5.4 and later when current is set,
+// or in the syntax of older versions. Functions without equivalent in the other syntax fail.
+func (e *triggerExpression) translate(current bool) (*triggerExpression, error) {
+	return e.translateWith(current, e.firstItem())
+}
+
+// translateWith translates the expression, item being the history function that the legacy clock functions are
+// attached to
+func (e *triggerExpression) translateWith(current bool, item *triggerExpression) (*triggerExpression, error) {
+	switch e.kind {
+	case triggerNodeUnary, triggerNodeBinary:
+		translated := *e
+		translated.operands = make([]*triggerExpression, len(e.operands))
+		for i, operand := range e.operands {
+			var err error
+			if translated.operands[i], err = operand.translateWith(current, item); err != nil {
+				return nil, err
+			}
+		}
+		return &translated, nil
+	case triggerNodeFunction:
+		switch {
+		case current && e.legacy:
+			return e.currentFunction()
+		case !current && !e.legacy:
+			return e.legacyFunction(item)
+		}
+	}
+	return e, nil
+}
+
+// parameter returns the parameter i of a function as written, or an empty string
+func (e *triggerExpression) parameter(i int) string {
+	if i < len(e.parameters) {
+		return e.parameters[i].text
+	}
+	return ""
+}
+
+// newTriggerFunction returns a function, without its trailing empty parameters
+func newTriggerFunction(name, host, key string, legacy bool, parameters ...string) *triggerExpression {
+	for len(parameters) > 0 && parameters[len(parameters)-1] == "" {
+		parameters = parameters[:len(parameters)-1]
+	}
+	function := &triggerExpression{kind: triggerNodeFunction, value: name, host: host, key: key, legacy: legacy}
+	for _, parameter := range parameters {
+		function.parameters = append(function.parameters, triggerParameter{text: parameter})
+	}
+	return function
+}
+
+// newTriggerMathFunction returns a function taking an expression followed by parameters, like abs or bitand
+func newTriggerMathFunction(name string, operand *triggerExpression, parameters ...string) *triggerExpression {
+	function := newTriggerFunction(name, "", "", false, parameters...)
+	function.parameters = append([]triggerParameter{{text: operand.source(), expression: operand}}, function.parameters...)
+	return function
+}
+
+// unquoteTriggerParameter returns the value of a quoted parameter
+func unquoteTriggerParameter(parameter string) string {
+	if len(parameter) < 2 || parameter[0] != '"' || parameter[len(parameter)-1] != '"' {
+		return parameter
+	}
+	return strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(parameter[1 : len(parameter)-1])
+}
+
+// quoteTriggerString quotes a string parameter for Zabbix 5.4 and later, leaving empty parameters empty
+func quoteTriggerString(parameter string) string {
+	if parameter == "" {
+		return ""
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(parameter) + `"`
+}
+
+// quoteLegacyParameter quotes a parameter for Zabbix before 5.4 when it needs to be
+func quoteLegacyParameter(parameter string) string {
+	if parameter != strings.TrimSpace(parameter) || strings.ContainsAny(parameter, `",)`) {
+		return `"` + strings.ReplaceAll(parameter, `"`, `\"`) + `"`
+	}
+	return parameter
+}
+
+// currentPeriod joins the sec|#num and time shift parameters of legacy functions in a period of Zabbix 5.4
+func currentPeriod(period, shift string) string {
+	if shift == "" {
+		return period
+	}
+	if period == "" || period == "0" {
+		period = "#1"
+	}
+	return period + ":now-" + shift
+}
+
+// legacyPeriod splits a period of Zabbix 5.4 in the sec|#num and time shift parameters of legacy functions
+func legacyPeriod(period string) (string, string, error) {
+	parts := strings.SplitN(period, ":", 2)
+	if len(parts) == 1 {
+		return period, "", nil
+	}
+	if !strings.HasPrefix(parts[1], "now-") || strings.ContainsAny(parts[1][4:], "/+-") {
+		return "", "", fmt.Errorf("the period %s has no equivalent before Zabbix %s", period, triggerSyntaxVersion)
+	}
+	return parts[0], parts[1][4:], nil
+}
+
+// currentFunction translates a legacy function to the syntax of Zabbix 5.4, after the changes of its functions
+func (e *triggerExpression) currentFunction() (*triggerExpression, error) {
+	// Quoting parameters was optional before Zabbix 5.4
+	parameter := func(i int) string {
+		return unquoteTriggerParameter(e.parameter(i))
+	}
+	history := func(name string, parameters ...string) *triggerExpression {
+		return newTriggerFunction(name, e.host, e.key, false, parameters...)
+	}
+	// last ignored a number of seconds, and #1 is the default of Zabbix 5.4
+	last := func(period, shift string) *triggerExpression {
+		if !strings.HasPrefix(period, "#") || period == "#1" && shift == "" {
+			period = ""
+		}
+		return history("last", currentPeriod(period, shift))
+	}
+	// The aggregating functions take sec|#num followed by a time shift
+	period := func() (string, error) {
+		if parameter(0) == "" {
+			return "", fmt.Errorf("the function %s of %s:%s has no period", e.value, e.host, e.key)
+		}
+		return currentPeriod(parameter(0), parameter(1)), nil
+	}
+
+	switch e.value {
+	case "last":
+		return last(parameter(0), parameter(1)), nil
+	case "prev":
+		return history("last", "#2"), nil
+	case "diff":
+		return &triggerExpression{kind: triggerNodeBinary, value: "<>", operands: []*triggerExpression{
+			history("last", "#1"), history("last", "#2"),
+		}}, nil
+	case "change", "logseverity":
+		return history(e.value), nil
+	case "abschange":
+		return newTriggerMathFunction("abs", history("change")), nil
+	case "strlen":
+		return newTriggerMathFunction("length", last(parameter(0), parameter(1))), nil
+	case "band":
+		return newTriggerMathFunction("bitand", last(parameter(0), parameter(2)), parameter(1)), nil
+	case "avg", "min", "max", "sum", "percentile", "forecast", "timeleft":
+		p, err := period()
+		if err != nil {
+			return nil, err
+		}
+		switch e.value {
+		case "percentile":
+			return history(e.value, p, parameter(2)), nil
+		case "timeleft":
+			return history(e.value, p, parameter(2), quoteTriggerString(parameter(3))), nil
+		case "forecast":
+			return history(e.value, p, parameter(2), quoteTriggerString(parameter(3)),
+				quoteTriggerString(parameter(4))), nil
+		}
+		return history(e.value, p), nil
+	case "delta":
+		p, err := period()
+		if err != nil {
+			return nil, err
+		}
+		return &triggerExpression{kind: triggerNodeBinary, value: "-", operands: []*triggerExpression{
+			history("max", p), history("min", p),
+		}}, nil
+	case "count":
+		// count(sec|#num,pattern,operator,time shift) became count(/host/key,period,operator,pattern)
+		if parameter(0) == "" {
+			return nil, fmt.Errorf("the function count of %s:%s has no period", e.host, e.key)
+		}
+		return history("count", currentPeriod(parameter(0), parameter(3)), quoteTriggerString(parameter(2)),
+			quoteTriggerString(parameter(1))), nil
+	case "nodata", "fuzzytime":
+		return history(e.value, parameter(0), quoteTriggerString(parameter(1))), nil
+	case "str", "regexp", "iregexp":
+		operator := e.value
+		if operator == "str" {
+			operator = "like"
+		}
+		return history("find", parameter(1), quoteTriggerString(operator),
+			quoteTriggerString(parameter(0))), nil
+	case "logeventid", "logsource":
+		return history(e.value, "", quoteTriggerString(parameter(0))), nil
+	}
+	if triggerClockFunctions[e.value] {
+		return newTriggerFunction(e.value, "", "", false), nil
+	}
+	return nil, fmt.Errorf("the function %s of %s:%s has no equivalent in Zabbix %s, rewrite it by hand", e.value, e.host, e.key, triggerSyntaxVersion)
+}
+
+// legacyFunction translates a function of Zabbix 5.4 to the syntax of older versions, item being the history
+// function the clock functions are attached to
+func (e *triggerExpression) legacyFunction(item *triggerExpression) (*triggerExpression, error) {
+	unsupported := fmt.Errorf("the function %s has no equivalent before Zabbix %s, rewrite it by hand", e.source(), triggerSyntaxVersion)
+	if triggerClockFunctions[e.value] {
+		if item == nil {
+			return nil, fmt.Errorf("the function %s needs an item before Zabbix %s", e.value, triggerSyntaxVersion)
+		}
+		return newTriggerFunction(e.value, item.host, item.key, true), nil
+	}
+
+	// Math functions are only translated when they stand for a function of the legacy syntax
+	if e.host == "" {
+		var operand *triggerExpression
+		if len(e.parameters) > 0 {
+			operand = e.parameters[0].expression
+		}
+		if operand == nil || operand.kind != triggerNodeFunction || operand.host == "" {
+			return nil, unsupported
+		}
+		switch {
+		case e.value == "abs" && operand.value == "change" && len(operand.parameters) == 0:
+			return newTriggerFunction("abschange", operand.host, operand.key, true), nil
+		case e.value == "length" && operand.value == "last":
+			legacy, err := operand.legacyFunction(item)
+			if err != nil {
+				return nil, err
+			}
+			legacy.value = "strlen"
+			return legacy, nil
+		case e.value == "bitand" && operand.value == "last" && len(e.parameters) == 2:
+			period, shift, err := legacyPeriod(operand.parameter(0))
+			if err != nil {
+				return nil, err
+			}
+			return newTriggerFunction("band", operand.host, operand.key, true, period, e.parameter(1), shift), nil
+		}
+		return nil, unsupported
+	}
+	if e.filter != "" || strings.Contains(e.host+e.key, "*") {
+		return nil, unsupported
+	}
+
+	legacy := func(name string, parameters ...string) *triggerExpression {
+		return newTriggerFunction(name, e.host, e.key, true, parameters...)
+	}
+	parameter := func(i int) string {
+		return quoteLegacyParameter(unquoteTriggerParameter(e.parameter(i)))
+	}
+	period, shift, err := legacyPeriod(e.parameter(0))
+	if err != nil {
+		return nil, err
+	}
+
+	switch e.value {
+	case "last":
+		if period == "" && shift != "" {
+			period = "#1"
+		}
+		return legacy("last", period, shift), nil
+	case "avg", "min", "max", "sum":
+		return legacy(e.value, period, shift), nil
+	case "percentile", "timeleft":
+		return legacy(e.value, period, shift, e.parameter(1), parameter(2)), nil
+	case "forecast":
+		return legacy(e.value, period, shift, e.parameter(1), parameter(2), parameter(3)), nil
+	case "count":
+		return legacy("count", period, parameter(2), parameter(1), shift), nil
+	case "change", "logseverity":
+		return legacy(e.value), nil
+	case "nodata", "fuzzytime":
+		return legacy(e.value, e.parameter(0), parameter(1)), nil
+	case "logeventid", "logsource":
+		return legacy(e.value, parameter(1)), nil
+	case "find":
+		name := unquoteTriggerParameter(e.parameter(1))
+		switch name {
+		case "", "like":
+			name = "str"
+		case "regexp", "iregexp":
+		default:
+			return nil, unsupported
+		}
+		return legacy(name, parameter(2), e.parameter(0)), nil
+	}
+	return nil, unsupported
+}
+
+// serverTriggerExpression returns the configured expression, translated to the syntax of the server when
+// expression_syntax is auto
+func serverTriggerExpression(d *schema.ResourceData, api *zabbixClient) (string, error) {
+	expression := d.Get("expression").(string)
+	if d.Get("expression_syntax").(string) != "auto" || api.ServerVersion == nil {
+		return expression, nil
+	}
+
+	parsed, err := parseTriggerExpression(expression)
+	if err != nil {
+		return "", err
+	}
+	translated, err := parsed.translate(versionAtLeast(api.ServerVersion, triggerSyntaxVersion))
+	if err != nil {
+		return "", err
+	}
+	if translated.String() == parsed.String() {
+		return expression, nil
+	}
+	return translated.source(), nil
+}
+
+// stateTriggerExpression keeps the expression of the state when the one rebuilt from Zabbix matches it, so that the
+// state holds the expression as configured
+func stateTriggerExpression(d *schema.ResourceData, expression string) string {
+	state := d.Get("expression").(string)
+	if state != "" && triggerExpressionsMatch(expression, state, d.Get("expression_syntax").(string) == "auto") {
+		return state
+	}
+	return expression
+}
+
+// triggerExpressionSyntax checks that the expressions translate to the syntax of the server when expression_syntax
+// is auto, and fails for the expressions that need migrating to it when expression_syntax is check
+func triggerExpressionSyntax(d *schema.ResourceDiff, serverVersion *version.Version) error {
+	mode := d.Get("expression_syntax").(string)
+	if mode == "" {
+		return nil
+	}
+
+	for _, value := range diffValues(d, "expression") {
+		expression, _ := value.(string)
+		parsed, err := parseTriggerExpression(expression)
+		if err != nil {
+			// Reported by validateTriggerExpression
+			continue
+		}
+		translated, err := parsed.translate(versionAtLeast(serverVersion, triggerSyntaxVersion))
+		switch {
+		case err != nil:
+			return fmt.Errorf("expression: %q cannot be translated to the syntax of Zabbix %s: %s", expression, serverVersion, err)
+		case mode == "check" && translated.String() != parsed.String():
+			return fmt.Errorf("expression: %q must be migrated to the syntax of Zabbix %s: %s", expression, serverVersion, translated.source())
+		}
+	}
+	return nil
+}
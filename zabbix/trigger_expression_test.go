@@ -0,0 +1,136 @@
+package zabbix
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseTriggerExpression(t *testing.T) {
+	cases := []struct {
+		expression string
+		expected   string
+	}{
+		{"{host:system.cpu.load[percpu,avg1].last()}>5", "{host:system.cpu.load[percpu,avg1].last()}>5"},
+		{"{Zabbix server:agent.ping.nodata( 300 )} = 1", "{Zabbix server:agent.ping.nodata(300)}=1"},
+		{`{host:vfs.fs.size[/,pused].last("0")}>{$FS_MAX:"/"}`, "{host:vfs.fs.size[/,pused].last(0)}>{$FS_MAX:\"/\"}"},
+		{`{host:log[/var/log/syslog].str("error, critical")}=1`, `{host:log[/var/log/syslog].str("error, critical")}=1`},
+		{"last(/host/system.cpu.load[percpu,avg1])>5", "last(/host/system.cpu.load[percpu,avg1])>5"},
+		{"avg( /host/key , 5m ) > {$MAX} and nodata(/host/agent.ping,\"5m\")=1", "avg(/host/key,5m)>{$MAX} and nodata(/host/agent.ping,5m)=1"},
+		{"(last(/host/key)>1) or (last(/host/key)<0 and last(/host/key,#2)=1)", "last(/host/key)>1 or last(/host/key)<0 and last(/host/key,#2)=1"},
+		{"(last(/host/a)+last(/host/b))*2>10", "(last(/host/a)+last(/host/b))*2>10"},
+		{"abs(change(/host/key))>1 and dayofweek()<6", "abs(change(/host/key))>1 and dayofweek()<6"},
+		{"not (count(/host/key,1h:now/h,\"gt\",\"0\") > 1)", `not (count(/host/key,1h:now/h,gt,0)>1)`},
+		{"avg(/*/key?[group=\"Linux servers\"],5m)>-1e3", "avg(/*/key?[group=\"Linux servers\"],5m)>-1e3"},
+		{"last(/{#HOST}/key[{#NAME}])=\"up\" or {TRIGGER.VALUE}=1", "last(/{#HOST}/key[{#NAME}])=\"up\" or {TRIGGER.VALUE}=1"},
+	}
+
+	for _, c := range cases {
+		parsed, err := parseTriggerExpression(c.expression)
+		if err != nil {
+			t.Errorf("%s: %s", c.expression, err)
+			continue
+		}
+		if parsed.String() != c.expected {
+			t.Errorf("%s: expected %s, got %s", c.expression, c.expected, parsed)
+		}
+	}
+}
+
+func TestParseTriggerExpressionErrors(t *testing.T) {
+	cases := []struct {
+		expression string
+		expected   string
+	}{
+		{"last(/host/key)>", "unexpected end of expression at position 17"},
+		{"last(/host/key)>5)", `unexpected ")" at position 18`},
+		{"(last(/host/key)>5", "expected ) instead of end of expression at position 19"},
+		{"{host:key.last()>5", "expected } after the function last at position 17"},
+		{"{host:key}>5", "expected a function after the item key at position 10"},
+		{`last(/host/key)="up`, "unterminated string at position 17"},
+		{"last(/host/key) & 1", `unexpected character '&' at position 17`},
+		{"last /host/key", `expected ( after the function last at position 6`},
+	}
+
+	for _, c := range cases {
+		_, err := parseTriggerExpression(c.expression)
+		if err == nil {
+			t.Errorf("%s: expected %s", c.expression, c.expected)
+		} else if err.Error() != c.expected {
+			t.Errorf("%s: expected %s, got %s", c.expression, c.expected, err)
+		}
+	}
+}
+
+func TestTriggerExpressionsMatch(t *testing.T) {
+	cases := []struct {
+		a, b string
+		// translate matches expressions of different syntaxes
+		translate, equal bool
+	}{
+		{"last(/host/key)>5", "last(/host/key) > 5", false, true},
+		{"last(/host/key,#1)>5", "last(/host/key)>5", false, false},
+		{"count(/host/key,5m,\"eq\",\"1\")>0", "count(/host/key, 5m, eq, 1) > 0", false, true},
+		{"{host:key.last(0)}>5 & 1", "{host:key.last(0)}>5 & 1", false, true},
+		{"{host:key.last(0)}>{$MAX}", "{host:key.last(0)}>5", false, false},
+		{"{host:key[a,b].avg(300)}>5 or {host:key.nodata(60)}=1", "({host:key[a,b].avg( \"300\" )}>5) or ({host:key.nodata(60)}=1)", false, true},
+		{"last(/host/key)-1>5", "last(/host/key)-(1>5)", false, false},
+		{"last(/host/key)=\"a b\"", "last(/host/key)=\"a  b\"", false, false},
+		{"last(/host/key)>5", "{host:key.last()}>5", false, false},
+		{"last(/host/key)>5", "{host:key.last()} > 5", true, true},
+		{"{host:key.avg(5m)}>5", "avg(/host/key,\"5m\")>5", true, true},
+	}
+
+	for _, c := range cases {
+		if equal := triggerExpressionsMatch(c.a, c.b, c.translate); equal != c.equal {
+			t.Errorf("%s and %s: expected equal to be %t", c.a, c.b, c.equal)
+		}
+	}
+}
+
+func TestTranslateTriggerExpression(t *testing.T) {
+	cases := []struct {
+		expression string
+		current    bool
+		// expected is the translated expression, or the error of the translation when it starts with !
+		expected string
+	}{
+		{"{host:key.last()}>5", true, "last(/host/key)>5"},
+		{"{host:key.last(#3,1d)} > 5", true, "last(/host/key,#3:now-1d)>5"},
+		{"{host:key.avg(5m)}>{$MAX} and {host:agent.ping.nodata(300)}=1", true, "avg(/host/key,5m)>{$MAX} and nodata(/host/agent.ping,300)=1"},
+		{`{host:key.count(10m,"error",like)}>2`, true, `count(/host/key,10m,"like","error")>2`},
+		{"{host:key.diff()}=1", true, "last(/host/key,#1)<>last(/host/key,#2)=1"},
+		{"{host:key.abschange()}>10", true, "abs(change(/host/key))>10"},
+		{"{host:key.delta(1h)}>5", true, "max(/host/key,1h)-min(/host/key,1h)>5"},
+		{"{host:log.str(error)}=1 and {host:log.now()}>0", true, `find(/host/log,,"like","error")=1 and now()>0`},
+		{"last(/host/key)>5", true, "last(/host/key)>5"},
+		{"{host:key.trendavg(1h,now/h)}>0", true, "!the function trendavg of host:key has no equivalent in Zabbix 5.4"},
+		{"{host:key.avg()}>0", true, "!the function avg of host:key has no period"},
+		{"last(/host/key)>5", false, "{host:key.last()}>5"},
+		{"last(/host/key,#2:now-1d)>5", false, "{host:key.last(#2,1d)}>5"},
+		{`count(/host/key,10m,"like","error")>2`, false, "{host:key.count(10m,error,like)}>2"},
+		{`find(/host/log,,"regexp","a,b")=1 and dayofweek()<6`, false, `{host:log.regexp("a,b")}=1 and {host:log.dayofweek()}<6`},
+		{"abs(change(/host/key))>10", false, "{host:key.abschange()}>10"},
+		{"length(last(/host/key,#2))>0", false, "{host:key.strlen(#2)}>0"},
+		{"{host:key.last()}>5", false, "{host:key.last()}>5"},
+		{"avg(/host/key,1h:now/h)>0", false, "!the period 1h:now/h has no equivalent before Zabbix 5.4"},
+		{"max(last(/host/a),last(/host/b))>0", false, "!the function max(last(/host/a),last(/host/b)) has no equivalent before Zabbix 5.4"},
+		{"now()>0", false, "!the function now needs an item before Zabbix 5.4"},
+	}
+
+	for _, c := range cases {
+		parsed, err := parseTriggerExpression(c.expression)
+		if err != nil {
+			t.Errorf("%s: %s", c.expression, err)
+			continue
+		}
+		translated, err := parsed.translate(c.current)
+		switch {
+		case err != nil && !strings.HasPrefix(c.expected, "!"):
+			t.Errorf("%s: expected %s, got the error %s", c.expression, c.expected, err)
+		case err != nil && !strings.HasPrefix(err.Error(), c.expected[1:]):
+			t.Errorf("%s: expected the error %s, got %s", c.expression, c.expected[1:], err)
+		case err == nil && translated.source() != c.expected:
+			t.Errorf("%s: expected %s, got %s", c.expression, c.expected, translated.source())
+		}
+	}
+}
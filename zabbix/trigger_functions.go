@@ -0,0 +1,97 @@
+package zabbix
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/claranet/go-zabbix-api"
+	"github.com/hashicorp/go-version"
+)
+
+// triggerFunction is a function of the expression of a trigger or a trigger prototype, as returned by the API
+type triggerFunction struct {
+	id, itemID, function, parameter string
+}
+
+// triggerItem is the item or item prototype of a trigger function
+type triggerItem struct {
+	host, key string
+}
+
+// triggerItemsGetter fetches the items or item prototypes of the IDs in a single request
+type triggerItemsGetter func(api *zabbixClient, ids []string) (map[string]triggerItem, error)
+
+func getTriggerItems(api *zabbixClient, ids []string) (map[string]triggerItem, error) {
+	items, err := api.ItemsGet(zabbix.Params{
+		"output":      "extend",
+		"selectHosts": "extend",
+		"itemids":     ids,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]triggerItem, len(items))
+	for _, item := range items {
+		if len(item.ItemParent) != 1 {
+			return nil, fmt.Errorf("Expected one parent host for item with id %s, and got : %d", item.ItemID, len(item.ItemParent))
+		}
+		result[item.ItemID] = triggerItem{host: item.ItemParent[0].Host, key: item.Key}
+	}
+	return result, nil
+}
+
+func getTriggerItemPrototypes(api *zabbixClient, ids []string) (map[string]triggerItem, error) {
+	items, err := api.ItemPrototypesGet(zabbix.Params{
+		"output":      "extend",
+		"selectHosts": "extend",
+		"itemids":     ids,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]triggerItem, len(items))
+	for _, item := range items {
+		if len(item.Hosts) != 1 {
+			return nil, fmt.Errorf("Expected one parent host for item with id %s, and got : %d", item.ItemID, len(item.Hosts))
+		}
+		result[item.ItemID] = triggerItem{host: item.Hosts[0].Host, key: item.Key}
+	}
+	return result, nil
+}
+
+// expandTriggerExpression replaces the {functionid} references of an expression returned by the API with the
+// functions in the syntax of the server. The items of all the functions are fetched at once, or taken from the
+// cache of the client.
+func expandTriggerExpression(api *zabbixClient, expression string, functions []triggerFunction, get triggerItemsGetter) (string, error) {
+	if len(functions) == 0 {
+		return expression, nil
+	}
+
+	ids := make([]string, len(functions))
+	for i, function := range functions {
+		ids[i] = function.itemID
+	}
+	items, err := api.cache.triggerItems(api, ids, get)
+	if err != nil {
+		return "", err
+	}
+
+	current := api.ServerVersion.GreaterThanOrEqual(version.Must(version.NewVersion(triggerSyntaxVersion)))
+	for _, function := range functions {
+		item, ok := items[function.itemID]
+		if !ok {
+			return "", fmt.Errorf("Expected one item with id : %s and got : 0", function.itemID)
+		}
+		var expanded string
+		if current {
+			// The parameters start with $, standing for the item
+			expanded = fmt.Sprintf("%s(/%s/%s%s)", function.function, item.host, item.key, strings.TrimPrefix(function.parameter, "$"))
+		} else {
+			expanded = fmt.Sprintf("{%s:%s.%s(%s)}", item.host, item.key, function.function, function.parameter)
+		}
+		expression = strings.Replace(expression, "{"+function.id+"}", expanded, 1)
+	}
+	return expression, nil
+}
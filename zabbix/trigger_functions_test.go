@@ -0,0 +1,77 @@
+package zabbix
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestExpandTriggerExpressionBatch(t *testing.T) {
+	for _, serverVersion := range []string{"4.0.0", "6.0.0"} {
+		s, api := newFakeZabbixClient(t, serverVersion)
+
+		expression := func(function, key, parameter, operator string) string {
+			if s.atLeast(triggerSyntaxVersion) {
+				if parameter != "" {
+					parameter = "," + parameter
+				}
+				return fmt.Sprintf("%s(/template/%s%s)%s", function, key, parameter, operator)
+			}
+			return fmt.Sprintf("{template:%s.%s(%s)}%s", key, function, parameter, operator)
+		}
+		templateID := fakeCreatedID(t, s, "template.create", "templateids", []interface{}{map[string]interface{}{
+			"host":   "template",
+			"groups": []interface{}{map[string]string{"groupid": "1"}},
+		}})
+		itemIDs := map[string]string{}
+		for _, key := range []string{"a", "b"} {
+			itemIDs[key] = fakeCreatedID(t, s, "item.create", "itemids", []interface{}{map[string]interface{}{
+				"hostid": templateID, "name": key, "key_": key, "type": 2, "value_type": 3,
+			}})
+		}
+		expressions := []string{
+			expression("last", "a", "", ">0") + " and " + expression("last", "b", "", ">0") + " or " + expression("avg", "a", "5m", ">1"),
+			expression("last", "b", "", "=0"),
+		}
+		var triggerIDs []string
+		for i, e := range expressions {
+			triggerIDs = append(triggerIDs, fakeCreatedID(t, s, "trigger.create", "triggerids", []interface{}{map[string]interface{}{
+				"description": fmt.Sprintf("trigger %d", i), "expression": e,
+			}}))
+		}
+
+		read := func(id string) string {
+			t.Helper()
+			r := resourceZabbixTrigger()
+			d := r.Data(&terraform.InstanceState{ID: id})
+			if diags := r.ReadContext(context.Background(), d, api); diags.HasError() {
+				t.Fatalf("%s: %v", serverVersion, diags)
+			}
+			return d.Get("expression").(string)
+		}
+
+		start := len(s.calls("item.get"))
+		for i, id := range triggerIDs {
+			if got := read(id); got != expressions[i] {
+				t.Errorf("%s: expected the expression %s, got %s", serverVersion, expressions[i], got)
+			}
+		}
+		if calls := len(s.calls("item.get")) - start; calls != 1 {
+			t.Errorf("%s: expected the items of both triggers to be fetched in one request, got %d", serverVersion, calls)
+		}
+
+		// Writing through the provider flushes the cache
+		if _, err := api.CallWithError("item.update", []interface{}{map[string]interface{}{"itemid": itemIDs["b"], "key_": "c"}}); err != nil {
+			t.Fatalf("%s: %s", serverVersion, err)
+		}
+		start = len(s.calls("item.get"))
+		if got, expected := read(triggerIDs[1]), expression("last", "c", "", "=0"); got != expected {
+			t.Errorf("%s: expected the expression %s after the item was renamed, got %s", serverVersion, expected, got)
+		}
+		if calls := len(s.calls("item.get")) - start; calls != 1 {
+			t.Errorf("%s: expected the items to be fetched again after a write, got %d requests", serverVersion, calls)
+		}
+	}
+}
@@ -0,0 +1,713 @@
+package zabbix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/claranet/go-zabbix-api"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+// testVersions are the versions of Zabbix the resources are tested against with the fake server
+var testVersions = []string{"3.4.0", "4.0.0", "5.0.0", "5.4.0", "6.0.0", "6.2.0", "6.4.0", "7.0.0"}
+
+// matrixRun is the state of the lifecycle tests against one version of the fake server
+type matrixRun struct {
+	server *fakeZabbixServer
+	api    *zabbixClient
+	// ids are the IDs of the resources created so far, by resource name
+	ids map[string]string
+}
+
+func (m *matrixRun) atLeast(v string) bool {
+	return m.server.atLeast(v)
+}
+
+// templateGroup returns the name of the group the template is created in
+func (m *matrixRun) templateGroup() string {
+	if m.atLeast("6.2") {
+		return "template group updated"
+	}
+	return "group updated"
+}
+
+// expression returns a trigger expression in the syntax of the server
+func (m *matrixRun) expression(key, function, operator string) string {
+	if m.atLeast("5.4") {
+		return fmt.Sprintf("%s(/template/%s)%s", function, key, operator)
+	}
+	return fmt.Sprintf("{template:%s.%s()}%s", key, function, operator)
+}
+
+// absentBefore returns the value, or null to assert that a property is not sent, before version v
+func (m *matrixRun) absentBefore(v string, value interface{}) interface{} {
+	if m.atLeast(v) {
+		return value
+	}
+	return nil
+}
+
+// fakePayload is a request expected by a test
+type fakePayload struct {
+	method string
+	params interface{}
+}
+
+// matrixResource is a resource whose lifecycle is tested against every version of testVersions
+type matrixResource struct {
+	name     string
+	resource func() *schema.Resource
+	// since is the first version of Zabbix supporting the resource
+	since string
+	// configs are applied in turn, the first one creating the resource and the others updating it
+	configs func(m *matrixRun) []map[string]interface{}
+	// requests are the requests expected, in order, while the first configuration is applied
+	requests func(m *matrixRun) []fakePayload
+}
+
+// matrixResources are ordered so that each resource only depends on the ones before
+var matrixResources = []matrixResource{
+	{
+		name:     "zabbix_host_group",
+		resource: resourceZabbixHostGroup,
+		configs: func(m *matrixRun) []map[string]interface{} {
+			return []map[string]interface{}{
+				{"name": "group"},
+				{"name": "group updated"},
+			}
+		},
+		requests: func(m *matrixRun) []fakePayload {
+			return []fakePayload{
+				{"hostgroup.create", []interface{}{map[string]interface{}{"name": "group"}}},
+			}
+		},
+	},
+	{
+		name:     "zabbix_template_group",
+		resource: resourceZabbixTemplateGroup,
+		since:    "6.2",
+		configs: func(m *matrixRun) []map[string]interface{} {
+			return []map[string]interface{}{
+				{"name": "template group"},
+				{"name": "template group updated"},
+			}
+		},
+		requests: func(m *matrixRun) []fakePayload {
+			return []fakePayload{
+				{"templategroup.create", []interface{}{map[string]interface{}{"name": "template group"}}},
+			}
+		},
+	},
+	{
+		name:     "zabbix_template",
+		resource: resourceZabbixTemplate,
+		configs: func(m *matrixRun) []map[string]interface{} {
+			return []map[string]interface{}{
+				{
+					"host":   "template",
+					"groups": []interface{}{m.templateGroup()},
+					"macro":  []interface{}{map[string]interface{}{"name": "A", "value": "1"}},
+				},
+				{
+					"host":        "template",
+					"description": "Template",
+					"groups":      []interface{}{m.templateGroup()},
+					"macro":       []interface{}{map[string]interface{}{"name": "A", "value": "2"}},
+				},
+			}
+		},
+		requests: func(m *matrixRun) []fakePayload {
+			groupGet, groupID, groupsParam := "hostgroup.get", m.ids["zabbix_host_group"], "hostids"
+			if m.atLeast("6.2") {
+				groupGet, groupID, groupsParam = "templategroup.get", m.ids["zabbix_template_group"], "templateids"
+			}
+			return []fakePayload{
+				{groupGet, map[string]interface{}{
+					"output": []interface{}{"groupid", "name"},
+					"filter": map[string]interface{}{"name": []interface{}{m.templateGroup()}},
+				}},
+				{"template.create", []interface{}{map[string]interface{}{
+					"host":   "template",
+					"groups": []interface{}{map[string]interface{}{"groupid": groupID}},
+					"macros": []interface{}{map[string]interface{}{
+						"macro":       "{$A}",
+						"value":       "1",
+						"type":        m.absentBefore("5.0", "0"),
+						"description": nil,
+					}},
+				}}},
+				{"template.get", map[string]interface{}{"templateids": m.ids["zabbix_template"], "output": "extend"}},
+				{"usermacro.get", map[string]interface{}{"output": "extend", "hostids": m.ids["zabbix_template"]}},
+				{groupGet, map[string]interface{}{"output": "extend", groupsParam: []interface{}{m.ids["zabbix_template"]}}},
+			}
+		},
+	},
+	{
+		name:     "zabbix_host",
+		resource: resourceZabbixHost,
+		configs: func(m *matrixRun) []map[string]interface{} {
+			host := map[string]interface{}{
+				"host":       "host",
+				"groups":     []interface{}{"group updated"},
+				"interfaces": []interface{}{map[string]interface{}{"ip": "127.0.0.1", "main": true}},
+				"templates":  []interface{}{"template"},
+			}
+			updated := map[string]interface{}{"monitored": false}
+			for key, value := range host {
+				updated[key] = value
+			}
+			return []map[string]interface{}{host, updated}
+		},
+		requests: func(m *matrixRun) []fakePayload {
+			return []fakePayload{
+				{"hostgroup.get", map[string]interface{}{
+					"output": []interface{}{"groupid", "name"},
+					"filter": map[string]interface{}{"name": []interface{}{"group updated"}},
+				}},
+				{"template.get", map[string]interface{}{
+					"output": []interface{}{"templateid", "host"},
+					"filter": map[string]interface{}{"host": []interface{}{"template"}},
+				}},
+				{"host.create", []interface{}{map[string]interface{}{
+					"host":       "host",
+					"groups":     []interface{}{map[string]interface{}{"groupid": m.ids["zabbix_host_group"]}},
+					"interfaces": []interface{}{map[string]interface{}{"ip": "127.0.0.1", "main": "1", "port": "10050", "type": "1"}},
+					"templates":  []interface{}{map[string]interface{}{"templateid": m.ids["zabbix_template"]}},
+				}}},
+				{"host.get", map[string]interface{}{
+					"hostids":               m.ids["zabbix_host"],
+					"selectInterfaces":      "extend",
+					"selectParentTemplates": []interface{}{"name"},
+				}},
+				{"hostgroup.get", map[string]interface{}{"output": []interface{}{"name"}, "hostids": []interface{}{m.ids["zabbix_host"]}}},
+			}
+		},
+	},
+	{
+		name:     "zabbix_item",
+		resource: resourceZabbixItem,
+		configs: func(m *matrixRun) []map[string]interface{} {
+			item := map[string]interface{}{
+				"host_id":    m.ids["zabbix_template"],
+				"key":        "item.key",
+				"name":       "Item",
+				"type":       2,
+				"value_type": 3,
+			}
+			updated := map[string]interface{}{"name": "Item updated"}
+			for key, value := range item {
+				if key != "name" {
+					updated[key] = value
+				}
+			}
+			return []map[string]interface{}{item, updated}
+		},
+		requests: func(m *matrixRun) []fakePayload {
+			return []fakePayload{
+				{"item.create", []interface{}{map[string]interface{}{
+					"hostid":     m.ids["zabbix_template"],
+					"key_":       "item.key",
+					"name":       "Item",
+					"type":       "2",
+					"value_type": "3",
+					"valuemapid": nil,
+				}}},
+				{"item.get", map[string]interface{}{"itemids": m.ids["zabbix_item"], "output": "extend"}},
+			}
+		},
+	},
+	{
+		name:     "zabbix_trigger",
+		resource: resourceZabbixTrigger,
+		configs: func(m *matrixRun) []map[string]interface{} {
+			return []map[string]interface{}{
+				{"description": "Trigger", "expression": m.expression("item.key", "last", ">0"), "priority": 2},
+				{"description": "Trigger", "expression": m.expression("item.key", "last", ">1"), "priority": 3},
+			}
+		},
+		requests: func(m *matrixRun) []fakePayload {
+			return []fakePayload{
+				{"trigger.create", []interface{}{map[string]interface{}{
+					"description": "Trigger",
+					"expression":  m.expression("item.key", "last", ">0"),
+					"priority":    "2",
+				}}},
+				{"trigger.get", map[string]interface{}{
+					"output":             "extend",
+					"selectDependencies": "extend",
+					"selectFunctions":    "extend",
+					"selectItems":        "extend",
+					"triggerids":         m.ids["zabbix_trigger"],
+				}},
+				{"item.get", map[string]interface{}{"output": "extend", "selectHosts": "extend", "itemids": []interface{}{m.ids["zabbix_item"]}}},
+			}
+		},
+	},
+	{
+		name:     "zabbix_lld_rule",
+		resource: resourceZabbixLLDRule,
+		configs: func(m *matrixRun) []map[string]interface{} {
+			rule := map[string]interface{}{
+				"delay":        "1h",
+				"host_id":      m.ids["zabbix_template"],
+				"interface_id": "0",
+				"key":          "lld.key",
+				"name":         "LLD rule",
+				"type":         2,
+			}
+			updated := map[string]interface{}{"name": "LLD rule updated"}
+			for key, value := range rule {
+				if key != "name" {
+					updated[key] = value
+				}
+			}
+			return []map[string]interface{}{rule, updated}
+		},
+		requests: func(m *matrixRun) []fakePayload {
+			get := map[string]interface{}{
+				"itemids":      m.ids["zabbix_lld_rule"],
+				"output":       "extend",
+				"selectFilter": "extend",
+				"inherited":    false,
+			}
+			if m.atLeast("4.2") {
+				get["selectLLDMacroPaths"] = "extend"
+				get["selectPreprocessing"] = "extend"
+			}
+			if m.atLeast("5.0") {
+				get["selectOverrides"] = "extend"
+			}
+			return []fakePayload{
+				{"discoveryrule.create", []interface{}{map[string]interface{}{
+					"hostid": m.ids["zabbix_template"],
+					"key_":   "lld.key",
+					"name":   "LLD rule",
+					"delay":  "1h",
+				}}},
+				{"discoveryrule.get", get},
+			}
+		},
+	},
+	{
+		name:     "zabbix_item_prototype",
+		resource: resourceZabbixItemPrototype,
+		configs: func(m *matrixRun) []map[string]interface{} {
+			prototype := map[string]interface{}{
+				"host_id":    m.ids["zabbix_template"],
+				"rule_id":    m.ids["zabbix_lld_rule"],
+				"key":        "prototype.key[{#NAME}]",
+				"name":       "Prototype {#NAME}",
+				"type":       2,
+				"value_type": 3,
+			}
+			updated := map[string]interface{}{"name": "Prototype {#NAME} updated"}
+			for key, value := range prototype {
+				if key != "name" {
+					updated[key] = value
+				}
+			}
+			return []map[string]interface{}{prototype, updated}
+		},
+		requests: func(m *matrixRun) []fakePayload {
+			return []fakePayload{
+				{"itemprototype.create", []interface{}{map[string]interface{}{
+					"hostid": m.ids["zabbix_template"],
+					"ruleid": m.ids["zabbix_lld_rule"],
+					"key_":   "prototype.key[{#NAME}]",
+					"name":   "Prototype {#NAME}",
+				}}},
+				{"itemprototype.get", map[string]interface{}{
+					"itemids":             m.ids["zabbix_item_prototype"],
+					"output":              "extend",
+					"selectDiscoveryRule": "extend",
+				}},
+			}
+		},
+	},
+	{
+		name:     "zabbix_trigger_prototype",
+		resource: resourceZabbixTriggerPrototype,
+		configs: func(m *matrixRun) []map[string]interface{} {
+			return []map[string]interface{}{
+				{"description": "Prototype {#NAME}", "expression": m.expression("prototype.key[{#NAME}]", "last", ">0")},
+				{"description": "Prototype {#NAME}", "expression": m.expression("prototype.key[{#NAME}]", "last", ">1")},
+			}
+		},
+		requests: func(m *matrixRun) []fakePayload {
+			return []fakePayload{
+				{"triggerprototype.create", []interface{}{map[string]interface{}{
+					"description": "Prototype {#NAME}",
+					"expression":  m.expression("prototype.key[{#NAME}]", "last", ">0"),
+				}}},
+				{"triggerprototype.get", map[string]interface{}{
+					"output":             "extend",
+					"selectDependencies": "extend",
+					"selectFunctions":    "extend",
+					"selectItems":        "extend",
+					"triggerids":         m.ids["zabbix_trigger_prototype"],
+				}},
+				{"itemprototype.get", map[string]interface{}{"output": "extend", "selectHosts": "extend", "itemids": []interface{}{m.ids["zabbix_item_prototype"]}}},
+			}
+		},
+	},
+	{
+		name:     "zabbix_user_macro",
+		resource: resourceZabbixUserMacro,
+		configs: func(m *matrixRun) []map[string]interface{} {
+			return []map[string]interface{}{
+				{"host_id": m.ids["zabbix_template"], "name": "B", "value": "1"},
+				{"host_id": m.ids["zabbix_template"], "name": "B", "value": "2"},
+			}
+		},
+		requests: func(m *matrixRun) []fakePayload {
+			return []fakePayload{
+				{"usermacro.create", map[string]interface{}{
+					"hostid":      m.ids["zabbix_template"],
+					"macro":       "{$B}",
+					"value":       "1",
+					"description": m.absentBefore("4.4", ""),
+					"type":        m.absentBefore("5.0", "0"),
+				}},
+				{"usermacro.get", map[string]interface{}{"output": "extend", "hostmacroids": m.ids["zabbix_user_macro"]}},
+			}
+		},
+	},
+	{
+		name:     "zabbix_global_macro",
+		resource: resourceZabbixGlobalMacro,
+		configs: func(m *matrixRun) []map[string]interface{} {
+			return []map[string]interface{}{
+				{"name": "GLOBAL", "value": "1"},
+				{"name": "GLOBAL", "value": "2"},
+			}
+		},
+		requests: func(m *matrixRun) []fakePayload {
+			return []fakePayload{
+				{"usermacro.createglobal", map[string]interface{}{
+					"macro":       "{$GLOBAL}",
+					"value":       "1",
+					"description": m.absentBefore("4.4", ""),
+					"type":        m.absentBefore("5.0", "0"),
+				}},
+				{"usermacro.get", map[string]interface{}{
+					"output":         "extend",
+					"globalmacro":    true,
+					"globalmacroids": m.ids["zabbix_global_macro"],
+				}},
+			}
+		},
+	},
+	{
+		name:     "zabbix_value_map",
+		resource: resourceZabbixValueMap,
+		configs: func(m *matrixRun) []map[string]interface{} {
+			valueMap := map[string]interface{}{
+				"name":    "Value map",
+				"mapping": []interface{}{map[string]interface{}{"value": "1", "new_value": "Up"}},
+			}
+			updated := map[string]interface{}{
+				"name":    "Value map",
+				"mapping": []interface{}{map[string]interface{}{"value": "1", "new_value": "Up"}, map[string]interface{}{"value": "0", "new_value": "Down"}},
+			}
+			if m.atLeast("5.4") {
+				valueMap["host_id"] = m.ids["zabbix_template"]
+				updated["host_id"] = m.ids["zabbix_template"]
+			}
+			return []map[string]interface{}{valueMap, updated}
+		},
+		requests: func(m *matrixRun) []fakePayload {
+			var hostID interface{}
+			if m.atLeast("5.4") {
+				hostID = m.ids["zabbix_template"]
+			}
+			return []fakePayload{
+				{"valuemap.create", map[string]interface{}{
+					"name":   "Value map",
+					"hostid": hostID,
+					"mappings": []interface{}{map[string]interface{}{
+						"value":    "1",
+						"newvalue": "Up",
+						"type":     m.absentBefore("6.0", "0"),
+					}},
+				}},
+			}
+		},
+	},
+	{
+		name:     "zabbix_action",
+		resource: resourceZabbixAction,
+		configs: func(m *matrixRun) []map[string]interface{} {
+			action := func(enabled bool) map[string]interface{} {
+				return map[string]interface{}{
+					"name":         "Action",
+					"event_source": "trigger",
+					"enabled":      enabled,
+					"condition": []interface{}{map[string]interface{}{
+						"type":     "trigger_name",
+						"operator": "contains",
+						"value":    "test",
+					}},
+					"operation": []interface{}{map[string]interface{}{
+						"type": "send_message",
+						"message": []interface{}{map[string]interface{}{
+							"default_message": false,
+							"subject":         "Subject",
+							"message":         "Message",
+							"target": []interface{}{map[string]interface{}{
+								"type":  "user_group",
+								"value": "Zabbix administrators",
+							}},
+						}},
+					}},
+				}
+			}
+			return []map[string]interface{}{action(true), action(false)}
+		},
+		requests: func(m *matrixRun) []fakePayload {
+			return []fakePayload{
+				{"usergroup.get", map[string]interface{}{
+					"output": []interface{}{"usrgrpid", "name"},
+					"filter": map[string]interface{}{"name": []interface{}{"Zabbix administrators"}},
+				}},
+				{"action.create", []interface{}{map[string]interface{}{
+					"name":        "Action",
+					"eventsource": "0",
+					"operations": []interface{}{map[string]interface{}{
+						"operationtype": "0",
+						"opmessage_grp": []interface{}{map[string]interface{}{"usrgrpid": "7"}},
+					}},
+				}}},
+				{"action.get", map[string]interface{}{"output": []interface{}{"actionid"}, "actionids": m.ids["zabbix_action"]}},
+			}
+		},
+	},
+	{
+		name:     "zabbix_service",
+		resource: resourceZabbixService,
+		since:    "6.0",
+		configs: func(m *matrixRun) []map[string]interface{} {
+			service := func(weight int) map[string]interface{} {
+				return map[string]interface{}{
+					"name":        "Service",
+					"weight":      weight,
+					"tag":         []interface{}{map[string]interface{}{"tag": "sla", "value": "shop"}},
+					"problem_tag": []interface{}{map[string]interface{}{"tag": "service", "value": "mysql"}},
+				}
+			}
+			return []map[string]interface{}{service(0), service(2)}
+		},
+		requests: func(m *matrixRun) []fakePayload {
+			return []fakePayload{
+				{"service.create", map[string]interface{}{
+					"name":         "Service",
+					"algorithm":    "2",
+					"tags":         []interface{}{map[string]interface{}{"tag": "sla", "value": "shop"}},
+					"problem_tags": []interface{}{map[string]interface{}{"tag": "service", "operator": "0", "value": "mysql"}},
+					"parents":      []interface{}{},
+					"children":     []interface{}{},
+				}},
+			}
+		},
+	},
+	{
+		name:     "zabbix_sla",
+		resource: resourceZabbixSLA,
+		since:    "6.0",
+		configs: func(m *matrixRun) []map[string]interface{} {
+			sla := func(slo float64) map[string]interface{} {
+				return map[string]interface{}{
+					"name":        "SLA",
+					"slo":         slo,
+					"schedule":    []interface{}{map[string]interface{}{"period_from": 115200, "period_to": 158400}},
+					"service_tag": []interface{}{map[string]interface{}{"tag": "sla", "value": "shop"}},
+				}
+			}
+			return []map[string]interface{}{sla(99.9), sla(99.5)}
+		},
+		requests: func(m *matrixRun) []fakePayload {
+			return []fakePayload{
+				{"sla.create", map[string]interface{}{
+					"name":           "SLA",
+					"slo":            "99.9",
+					"period":         "2",
+					"timezone":       "UTC",
+					"status":         "1",
+					"effective_date": nil,
+					"schedule":       []interface{}{map[string]interface{}{"period_from": "115200", "period_to": "158400"}},
+					"service_tags":   []interface{}{map[string]interface{}{"tag": "sla", "operator": "0", "value": "shop"}},
+				}},
+			}
+		},
+	},
+}
+
+func TestVersionMatrix(t *testing.T) {
+	for _, serverVersion := range testVersions {
+		t.Run(serverVersion, func(t *testing.T) {
+			server, api := newFakeZabbixClient(t, serverVersion)
+			m := &matrixRun{server: server, api: api, ids: map[string]string{}}
+
+			var created []matrixResource
+			states := map[string]*terraform.InstanceState{}
+			for _, r := range matrixResources {
+				if r.since != "" && !m.atLeast(r.since) {
+					continue
+				}
+
+				var state *terraform.InstanceState
+				for i, config := range r.configs(m) {
+					start := server.requestCount()
+					state = testApplyResource(t, r.name, r.resource(), m.api, state, config)
+					m.ids[r.name] = state.ID
+
+					if i == 0 {
+						testAssertRequests(t, r.name, server.requestsFrom(start), r.requests(m))
+					}
+				}
+				created = append(created, r)
+				states[r.name] = state
+			}
+
+			for i := len(created) - 1; i >= 0; i-- {
+				r := created[i]
+				testDestroyResource(t, r.name, r.resource(), m.api, states[r.name])
+			}
+		})
+	}
+}
+
+// testApplyResource plans and applies a configuration, then checks that the resource read back from the server
+// leaves nothing to plan
+func testApplyResource(t *testing.T, name string, r *schema.Resource, api *zabbixClient, state *terraform.InstanceState, raw map[string]interface{}) *terraform.InstanceState {
+	t.Helper()
+	ctx := context.Background()
+
+	diff, err := r.Diff(ctx, state, terraform.NewResourceConfigRaw(raw), api)
+	if err != nil {
+		t.Fatalf("%s: %s", name, err)
+	}
+	if diff == nil || diff.Empty() {
+		t.Fatalf("%s: expected changes to apply for %v", name, raw)
+	}
+
+	state, diags := r.Apply(ctx, state, diff, api)
+	if diags.HasError() {
+		t.Fatalf("%s: %v", name, diags)
+	}
+	state, diags = r.RefreshWithoutUpgrade(ctx, state, api)
+	if diags.HasError() {
+		t.Fatalf("%s: %v", name, diags)
+	}
+	if state == nil || state.ID == "" {
+		t.Fatalf("%s: the resource is gone once applied", name)
+	}
+
+	diff, err = r.Diff(ctx, state, terraform.NewResourceConfigRaw(raw), api)
+	if err != nil {
+		t.Fatalf("%s: %s", name, err)
+	}
+	if diff != nil && !diff.Empty() {
+		t.Fatalf("%s: expected nothing to plan once applied, got %v", name, diff.Attributes)
+	}
+	return state
+}
+
+// testDestroyResource destroys a resource and checks that it is removed from the state when read
+func testDestroyResource(t *testing.T, name string, r *schema.Resource, api *zabbixClient, state *terraform.InstanceState) {
+	t.Helper()
+	ctx := context.Background()
+
+	if _, diags := r.Apply(ctx, state, &terraform.InstanceDiff{Destroy: true}, api); diags.HasError() {
+		t.Fatalf("%s: %v", name, diags)
+	}
+	state, diags := r.RefreshWithoutUpgrade(ctx, state, api)
+	if diags.HasError() {
+		t.Fatalf("%s: %v", name, diags)
+	}
+	if state != nil && state.ID != "" {
+		t.Fatalf("%s: expected the resource to be removed from the state once destroyed", name)
+	}
+}
+
+// testAssertRequests checks that the requests expected were received in order, other requests being ignored
+func testAssertRequests(t *testing.T, name string, requests []fakeRequest, expected []fakePayload) {
+	t.Helper()
+
+	next := 0
+	for _, want := range expected {
+		found := false
+		for next < len(requests) && !found {
+			request := requests[next]
+			next++
+			if request.Method != want.method {
+				continue
+			}
+			found = true
+			if err := matchFakeParams("params", normalizeFakeValue(want.params), normalizeFakeValue(request.Params)); err != nil {
+				t.Errorf("%s: unexpected %s request: %s\n%s", name, want.method, err, testJSON(request.Params))
+			}
+		}
+		if !found {
+			var methods []string
+			for _, request := range requests {
+				methods = append(methods, request.Method)
+			}
+			t.Errorf("%s: expected a %s request among %v", name, want.method, methods)
+		}
+	}
+}
+
+// matchFakeParams compares the parameters of a request to the expected ones. Lists and scalars must be equal, but
+// only the properties listed are compared for objects, a nil value asserting that the property is not sent.
+func matchFakeParams(path string, want, got interface{}) error {
+	switch w := want.(type) {
+	case nil:
+		if got != nil {
+			return fmt.Errorf("%s: expected nothing, got %s", path, testJSON(got))
+		}
+	case fakeObject:
+		g, ok := got.(fakeObject)
+		if !ok {
+			return fmt.Errorf("%s: expected an object, got %s", path, testJSON(got))
+		}
+		keys := make([]string, 0, len(w))
+		for key := range w {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if err := matchFakeParams(path+"."+key, w[key], g[key]); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok || len(g) != len(w) {
+			return fmt.Errorf("%s: expected %s, got %s", path, testJSON(want), testJSON(got))
+		}
+		for i := range w {
+			if err := matchFakeParams(fmt.Sprintf("%s[%d]", path, i), w[i], g[i]); err != nil {
+				return err
+			}
+		}
+	default:
+		if got != want {
+			return fmt.Errorf("%s: expected %s, got %s", path, testJSON(want), testJSON(got))
+		}
+	}
+	return nil
+}
+
+func testJSON(value interface{}) string {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(value)
+	return strings.TrimSpace(buffer.String())
+}